@@ -0,0 +1,206 @@
+// Package auxfile reads LaTeX .aux files to determine the citations of a document.
+package auxfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// AuxFile represents the bibliography-related information within a LaTeX .aux file
+type AuxFile struct {
+	Citations []string // cited keys from '\citation', in order of first occurrence
+	BibData   []string // bibliography databases from '\bibdata'
+	BibStyle  string   // bibliography style from '\bibstyle'
+	Inputs    []string // nested .aux files from '\@input'
+}
+
+// NewAuxFileFromReader reads an AuxFile from the given reader.
+// Nested files referenced by '\@input' are recorded in Inputs, but not read.
+// If not nil, err is an instance of utils.ReaderError.
+func NewAuxFileFromReader(reader *utils.RuneReader) (aux *AuxFile, err error) {
+	aux = &AuxFile{}
+	err = aux.readAux(reader)
+	return
+}
+
+// ReadAuxFile reads the AuxFile with the given name, including all nested files referenced by '\@input'.
+// Nested files are resolved relative to the directory of filename, as LaTeX writes them relative to the main file.
+func ReadAuxFile(filename string) (aux *AuxFile, err error) {
+	aux = &AuxFile{}
+	err = aux.readAuxFile(filename, filepath.Dir(filename), make(map[string]struct{}))
+	return
+}
+
+// readAuxFile reads filename into aux, followed by all nested files.
+// seen contains the files already read, to prevent cycles.
+func (aux *AuxFile) readAuxFile(filename string, base string, seen map[string]struct{}) error {
+	clean := filepath.Clean(filename)
+	if _, ok := seen[clean]; ok {
+		return nil
+	}
+	seen[clean] = struct{}{}
+
+	f, err := os.Open(clean)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// read the file itself, but remember which files it included
+	inputs := len(aux.Inputs)
+	if err := aux.readAux(utils.NewRuneReaderFromReader(f)); err != nil {
+		return err
+	}
+
+	for _, input := range aux.Inputs[inputs:] {
+		if !filepath.IsAbs(input) {
+			input = filepath.Join(base, input)
+		}
+		if err := aux.readAuxFile(input, base, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readAux reads the commands within reader and records them in aux
+func (aux *AuxFile) readAux(reader *utils.RuneReader) (err error) {
+	// citations that we have already seen
+	seen := make(map[string]struct{}, len(aux.Citations))
+	for _, c := range aux.Citations {
+		seen[c] = struct{}{}
+	}
+
+	for {
+		// skip ahead to the next command
+		if _, err = reader.EatWhile(func(r rune) bool { return r != '\\' }); err != nil {
+			err = utils.WrapErrorF(reader, err, "Unexpected error while attempting to read aux file")
+			return
+		}
+		if _, pos, _ := reader.Peek(); pos.EOF {
+			return nil
+		}
+		reader.Eat()
+
+		// read the name of the command
+		var name string
+		name, _, err = reader.ReadWhile(func(r rune) bool { return unicode.IsLetter(r) || r == '@' })
+		if err != nil {
+			err = utils.WrapErrorF(reader, err, "Unexpected error while attempting to read aux file")
+			return
+		}
+
+		// only care about the commands we know about
+		switch name {
+		case "citation", "bibdata", "bibstyle", "@input":
+		default:
+			continue
+		}
+
+		// read the argument
+		var arg string
+		var ok bool
+		arg, ok, err = readArgument(reader)
+		if err != nil {
+			return
+		}
+		if !ok {
+			continue
+		}
+
+		switch name {
+		case "citation":
+			for _, key := range splitList(arg) {
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				aux.Citations = append(aux.Citations, key)
+			}
+		case "bibdata":
+			aux.BibData = append(aux.BibData, splitList(arg)...)
+		case "bibstyle":
+			aux.BibStyle = strings.TrimSpace(arg)
+		case "@input":
+			aux.Inputs = append(aux.Inputs, strings.TrimSpace(arg))
+		}
+	}
+}
+
+// readArgument reads a mandatory '{' '}' delimited argument of a command, skipping leading spaces.
+// When the next non-space character is not a '{', returns ok = false.
+func readArgument(reader *utils.RuneReader) (arg string, ok bool, err error) {
+	if _, err = reader.EatWhile(unicode.IsSpace); err != nil {
+		err = utils.WrapErrorF(reader, err, "Unexpected error while attempting to read argument")
+		return
+	}
+	if r, pos, _ := reader.Peek(); pos.EOF || r != '{' {
+		return
+	}
+	reader.Eat()
+
+	// iteratively read chars, keeping track of the current level
+	var builder strings.Builder
+	level := 1
+	for {
+		var char rune
+		var pos utils.ReaderPosition
+		char, pos, err = reader.Read()
+		if err != nil {
+			err = utils.WrapErrorF(reader, err, "Unexpected error while attempting to read argument")
+			return
+		}
+		if pos.EOF {
+			err = utils.NewUnexpectedError(reader, utils.CodeUnbalancedBrace, "argument", "}", "")
+			return
+		}
+
+		// update level
+		if char == '{' {
+			level++
+		} else if char == '}' {
+			level--
+		}
+
+		// final closing brace => exit
+		if level == 0 {
+			return builder.String(), true, nil
+		}
+
+		builder.WriteRune(char)
+	}
+}
+
+// splitList splits a comma-separated list, trimming spaces and dropping empty elements
+func splitList(s string) (list []string) {
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return
+}
+
+// BibFiles returns the filenames of the bibliography databases referenced in BibData.
+// As in BibTeX, the '.bib' extension is added unless it is already present.
+func (aux *AuxFile) BibFiles() (files []string) {
+	for _, name := range aux.BibData {
+		if !strings.HasSuffix(name, ".bib") {
+			name += ".bib"
+		}
+		files = append(files, name)
+	}
+	return
+}
+
+// Extract returns a new BibFile containing only those entries of file needed to typeset the citations in aux.
+// See BibFile.Subset for details on which entries are included.
+func (aux *AuxFile) Extract(file *bibliography.BibFile) (subset *bibliography.BibFile, missing []string) {
+	return file.Subset(aux.Citations)
+}
@@ -0,0 +1,63 @@
+package auxfile
+
+import (
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+func Test_readAux(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    *AuxFile
+		wantErr bool
+	}{
+		{"empty", ``, &AuxFile{}, false},
+		{"citations", `\citation{a,b}\citation{ b , c }`, &AuxFile{Citations: []string{"a", "b", "c"}}, false},
+		{"bibliography", "\\bibstyle{plain}\n\\bibdata{refs,more.bib}", &AuxFile{BibStyle: "plain", BibData: []string{"refs", "more.bib"}}, false},
+		{"nested braces", `\citation{a}\@writefile{toc}{\contentsline{x}}\citation {b}`, &AuxFile{Citations: []string{"a", "b"}}, false},
+		{"input", `\@input{chapter.aux}`, &AuxFile{Inputs: []string{"chapter.aux"}}, false},
+		{"unbalanced", `\citation{a`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewAuxFileFromReader(utils.NewRuneReaderFromString(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AuxFile.readAux() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AuxFile.readAux() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadAuxFile(t *testing.T) {
+	got, err := ReadAuxFile(path.Join("testdata", "paper.aux"))
+	if err != nil {
+		t.Errorf("ReadAuxFile() error = %v, wantErr %v", err, false)
+		return
+	}
+
+	want := &AuxFile{
+		Citations: []string{"MRx05", "presstudy2002", "patashnik-bibtexing", "BreCodJuc:tospsme14"},
+		BibData:   []string{"refs", "extra.bib"},
+		BibStyle:  "plain",
+		Inputs:    []string{"nested/chapter.aux"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadAuxFile() = %v, want %v", got, want)
+	}
+
+	wantFiles := []string{"refs.bib", "extra.bib"}
+	if gotFiles := got.BibFiles(); !reflect.DeepEqual(gotFiles, wantFiles) {
+		t.Errorf("AuxFile.BibFiles() = %v, want %v", gotFiles, wantFiles)
+	}
+}
@@ -1,7 +1,9 @@
 package bibliography
 
 import (
+	"errors"
 	"io"
+	"strings"
 	"unicode"
 
 	"github.com/tkw1536/gotexml/utils"
@@ -33,6 +35,35 @@ func (entry *BibEntry) Label() string {
 	return elements[0].Value.Value
 }
 
+// IsKind checks if this BibEntry is of the given kind.
+// The comparison is case-insensitive, as BibTeX ignores the case of entry types.
+func (entry *BibEntry) IsKind(kind string) bool {
+	if entry == nil || entry.Kind == nil {
+		return false
+	}
+	return strings.EqualFold(entry.Kind.Value, kind)
+}
+
+// IsSpecial checks if this BibEntry is a '@string', '@preamble' or '@comment' entry.
+// Such entries do not represent a citable reference.
+func (entry *BibEntry) IsSpecial() bool {
+	return entry.IsKind("string") || entry.IsKind("preamble") || entry.IsKind("comment")
+}
+
+// GetField returns the first 'key = value' field of this BibEntry with the given key.
+// Keys are compared case-insensitively. If no such field exists, returns nil.
+func (entry *BibEntry) GetField(key string) *BibField {
+	if entry == nil {
+		return nil
+	}
+	for _, field := range entry.Fields {
+		if field.IsKeyValue() && strings.EqualFold(field.Name(), key) {
+			return field
+		}
+	}
+	return nil
+}
+
 // readEntry reads a BibEntry from reader
 // Entries end with '}' as a terminating character.
 // when err is io.EOF, no beginning entry was found and only Prefix is populated
@@ -65,7 +96,7 @@ func (entry *BibEntry) readEntry(reader *utils.RuneReader) (err error) {
 		return
 	}
 	if char != '@' {
-		err = utils.NewErrorF(reader, "Expected to find an '@' but got %q", char)
+		err = utils.NewUnexpectedError(reader, utils.CodeMissingAt, "entry", "@", string(char))
 		return
 	}
 	entry.Source.Start = pos
@@ -74,22 +105,22 @@ func (entry *BibEntry) readEntry(reader *utils.RuneReader) (err error) {
 	entry.Kind = &BibString{}
 	entry.KindSuffix, err = entry.Kind.readLiteral(reader)
 	if err != nil {
-		err = utils.WrapErrorF(reader, err, "Unexpected error while attempting to read entry")
+		err = entry.annotate(utils.WrapErrorF(reader, err, "Unexpected error while attempting to read entry"), nil)
 		return
 	}
 
 	// read a '{' or bail out
 	char, pos, err = reader.Read()
 	if err != nil {
-		err = utils.WrapErrorF(reader, err, "Unexpected error while attempting to read entry")
+		err = entry.annotate(utils.WrapErrorF(reader, err, "Unexpected error while attempting to read entry"), nil)
 		return
 	}
 	if pos.EOF {
-		err = utils.NewErrorF(reader, "Unexpected end of input while attempting to read entry")
+		err = entry.annotate(utils.NewUnexpectedError(reader, utils.CodeUnexpectedEOF, "entry", "{", ""), nil)
 		return
 	}
 	if char != '{' {
-		err = utils.NewErrorF(reader, "Expected to find an '{' but got %q", char)
+		err = entry.annotate(utils.NewUnexpectedError(reader, utils.CodeMissingBrace, "entry", "{", string(char)), nil)
 		return
 	}
 
@@ -100,7 +131,7 @@ func (entry *BibEntry) readEntry(reader *utils.RuneReader) (err error) {
 		f := &BibField{}
 		err = f.readField(reader)
 		if err != nil {
-			err = utils.WrapErrorF(reader, err, "Unexpected error while attempting to read entry")
+			err = entry.annotate(utils.WrapErrorF(reader, err, "Unexpected error while attempting to read entry"), f)
 			return
 		}
 
@@ -113,8 +144,8 @@ func (entry *BibEntry) readEntry(reader *utils.RuneReader) (err error) {
 		}
 	}
 
-	// the last source entry
-	entry.Source.End = pos
+	// the entry ends with the closing brace of the last field
+	entry.Source.End = entry.Fields[len(entry.Fields)-1].Suffix.Source.End
 
 	// and return the element
 	return
@@ -152,3 +183,28 @@ func (entry *BibEntry) Write(writer io.Writer) error {
 
 	return nil
 }
+
+// annotate records the kind and label of entry in all ReaderErrors of err that do not have an entry yet.
+// current is the field that was being read when err occured, if any.
+func (entry *BibEntry) annotate(err error, current *BibField) error {
+	kind := ""
+	if entry.Kind != nil {
+		kind = entry.Kind.Value
+	}
+
+	// the label may be part of the current field
+	label := ""
+	if !entry.IsSpecial() {
+		label = entry.Label()
+		if len(entry.Fields) == 0 && current != nil {
+			label = (&BibEntry{Fields: []*BibField{current}}).Label()
+		}
+	}
+
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if rerr, ok := e.(*utils.ReaderError); ok && rerr.EntryKind == "" {
+			rerr.EntryKind, rerr.EntryLabel = kind, label
+		}
+	}
+	return err
+}
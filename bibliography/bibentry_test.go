@@ -2,6 +2,7 @@ package bibliography
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"os"
 	"path"
@@ -82,6 +83,41 @@ func benchmarkReadEntry(content string, b *testing.B) {
 	}
 }
 
+func Test_readEntry_error(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantCode  utils.ErrorCode
+		wantKind  string
+		wantLabel string
+		wantToken string // the actual token
+	}{
+		{"missing brace", "@book, a}", utils.CodeMissingBrace, "book", "", ","},
+		{"eof after kind", "@book", utils.CodeUnexpectedEOF, "book", "", ""},
+		{"eof within label", "@book{knuth", utils.CodeUnexpectedEOF, "book", "knuth", ""},
+		{"unexpected equal sign", "@book{knuth, title = = x}", utils.CodeUnexpectedCharacter, "book", "knuth", "="},
+		{"unbalanced brace", "@article{knuth, title = {The {Art}", utils.CodeUnbalancedBrace, "article", "knuth", ""},
+		{"unterminated quote", "@book{knuth, title = \"The Art}", utils.CodeUnterminatedQuote, "book", "knuth", ""},
+		{"special entries have no label", "@string{foo = {bar}", utils.CodeUnexpectedEOF, "string", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&BibEntry{}).readEntry(utils.NewRuneReaderFromString(tt.input))
+			if !errors.Is(err, tt.wantCode) {
+				t.Fatalf("BibEntry.readEntry() error = %v, want %v", err, tt.wantCode)
+			}
+
+			var rerr *utils.ReaderError
+			if !errors.As(err, &rerr) {
+				t.Fatalf("BibEntry.readEntry() error = %v, want a *utils.ReaderError", err)
+			}
+			if rerr.EntryKind != tt.wantKind || rerr.EntryLabel != tt.wantLabel || rerr.Actual != tt.wantToken {
+				t.Errorf("BibEntry.readEntry() error = (%q, %q, %q), want (%q, %q, %q)", rerr.EntryKind, rerr.EntryLabel, rerr.Actual, tt.wantKind, tt.wantLabel, tt.wantToken)
+			}
+		})
+	}
+}
+
 var emptyEntryText = utils.ReadFileOrPanic(path.Join("testdata", "bibentry_read", "0001_empty.bib"))
 var preambleEntryText = utils.ReadFileOrPanic(path.Join("testdata", "bibentry_read", "0002_preamble.bib"))
 var stringEntryText = utils.ReadFileOrPanic(path.Join("testdata", "bibentry_read", "0003_string.bib"))
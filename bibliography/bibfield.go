@@ -56,9 +56,10 @@ func (field *BibField) readField(reader *utils.RuneReader) (err error) {
 	r, pos, err = reader.Peek()
 	if err != nil {
 		err = utils.WrapErrorF(reader, err, "Unexpected error while attempting to read field")
+		return
 	}
 	if pos.EOF {
-		err = utils.NewErrorF(reader, "Unexpected end of input while attempting to read field")
+		err = utils.NewUnexpectedError(reader, utils.CodeUnexpectedEOF, "field", "", "")
 		return
 	}
 
@@ -79,7 +80,7 @@ func (field *BibField) readField(reader *utils.RuneReader) (err error) {
 		switch r {
 		case '=':
 			if !mayEqualNext {
-				err = utils.NewErrorF(reader, "Unexpected \"=\" while attempting to read field")
+				err = utils.NewUnexpectedError(reader, utils.CodeUnexpectedCharacter, "field", "", string(r))
 				return
 			}
 
@@ -103,7 +104,7 @@ func (field *BibField) readField(reader *utils.RuneReader) (err error) {
 			mayEqualNext = false
 		case '#':
 			if !mayConcatNext {
-				err = utils.NewErrorF(reader, "Unexpected \"#\" while attempting to read field")
+				err = utils.NewUnexpectedError(reader, utils.CodeUnexpectedCharacter, "field", "", string(r))
 				return
 			}
 
@@ -126,7 +127,7 @@ func (field *BibField) readField(reader *utils.RuneReader) (err error) {
 			mayEqualNext = false
 		case '"':
 			if !mayStringNext {
-				err = utils.NewErrorF(reader, "Unexpected '\"' while attempting to read field")
+				err = utils.NewUnexpectedError(reader, utils.CodeUnexpectedCharacter, "field", "", string(r))
 				return
 			}
 
@@ -152,7 +153,7 @@ func (field *BibField) readField(reader *utils.RuneReader) (err error) {
 
 		case '{':
 			if !mayStringNext {
-				err = utils.NewErrorF(reader, "Unexpected '{' while attempting to read field")
+				err = utils.NewUnexpectedError(reader, utils.CodeUnexpectedCharacter, "field", "", string(r))
 				return
 			}
 
@@ -176,7 +177,7 @@ func (field *BibField) readField(reader *utils.RuneReader) (err error) {
 			mayEqualNext = !hadEqualSign
 		default:
 			if !mayStringNext {
-				err = utils.NewErrorF(reader, "Unexpected start of literal while attempting to read field")
+				err = utils.NewUnexpectedError(reader, utils.CodeUnexpectedCharacter, "field", "", string(r))
 				return
 			}
 
@@ -221,7 +222,7 @@ func (field *BibField) readField(reader *utils.RuneReader) (err error) {
 			return
 		}
 		if pos.EOF {
-			err = utils.NewErrorF(reader, "Unexpected end of input while attempting to read field")
+			err = utils.NewUnexpectedError(reader, utils.CodeUnexpectedEOF, "field", "", "")
 			return
 		}
 	}
@@ -278,6 +279,16 @@ func (field *BibField) GetKey() *BibFieldElement {
 	return field.Elements[0]
 }
 
+// Name returns the name of the key of this BibField.
+// If this BibField is not of the form 'key = value', returns the empty string.
+func (field *BibField) Name() string {
+	key := field.GetKey()
+	if key == nil {
+		return ""
+	}
+	return key.Value.Value
+}
+
 // GetValue returns the value elements of this key, i.e. everything after the first element in a 'key = value' assignment
 // if the BibEntry is not of the form key == value, returns 0
 func (field *BibField) GetValue() []*BibFieldElement {
@@ -1,7 +1,9 @@
 package bibliography
 
 import (
+	"bytes"
 	"io"
+	"os"
 
 	"github.com/tkw1536/gotexml/utils"
 )
@@ -12,6 +14,20 @@ type BibFile struct {
 	Suffix  BibString   `json:"suffix"`  // the suffix of this file
 
 	Source utils.ReaderRange `json:"source"` // source range that contains this BibFile
+
+	// LineEnding is the line break used by Write, such as "\r\n".
+	// When reading a file, it is set to the first line break of the input, unless that is "\n".
+	// The empty string means "\n".
+	//
+	// Line breaks are kept per file, not per occurrence.
+	// Writing a file that mixes different line breaks uses LineEnding for all of them, so such a file is not written back unchanged.
+	LineEnding string `json:"lineEnding,omitempty"`
+
+	// Encoding is the encoding used by Write, and BOM indicates if a byte order mark is written.
+	// When reading a file, they are set to the encoding of the reader, unless that is UTF-8 without a byte order mark.
+	// The empty string means UTF-8.
+	Encoding utils.Encoding `json:"encoding,omitempty"`
+	BOM      bool           `json:"bom,omitempty"`
 }
 
 // NewBibFileFromReader makes a new BibFile from the given reader
@@ -21,6 +37,17 @@ func NewBibFileFromReader(reader *utils.RuneReader) (file *BibFile, err error) {
 	return
 }
 
+// NewBibFileFromFile reads a new BibFile from the file with the given name
+func NewBibFileFromFile(filename string) (file *BibFile, err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return NewBibFileFromReader(utils.NewRuneReaderFromReader(f))
+}
+
 // readFile reads a BibFile from reader
 func (file *BibFile) readFile(reader *utils.RuneReader) (err error) {
 	// store the original position
@@ -47,11 +74,34 @@ func (file *BibFile) readFile(reader *utils.RuneReader) (err error) {
 		file.Entries = append(file.Entries, entry)
 		file.Source.End = entry.Source.End
 	}
+
+	// strings only contain normalized line breaks, so remember the original ones
+	if ending := reader.LineEnding(); ending != "\n" {
+		file.LineEnding = ending
+	}
+	if encoding := reader.Encoding(); encoding != utils.EncodingUTF8 || reader.BOM() {
+		file.Encoding, file.BOM = encoding, reader.BOM()
+	}
 	return
 }
 
-// Write writes this BibFile into a writer
+// Write writes this BibFile into a writer.
+// Line breaks are written as LineEnding, and text is encoded as Encoding.
 func (file *BibFile) Write(writer io.Writer) error {
+	encoding := file.Encoding
+	if encoding == "" {
+		encoding = utils.EncodingUTF8
+	}
+	if file.BOM {
+		if _, err := writer.Write(encoding.BOM()); err != nil {
+			return err
+		}
+	}
+	writer = utils.NewEncodingWriter(writer, encoding)
+
+	if file.LineEnding != "" && file.LineEnding != "\n" {
+		writer = &lineEndingWriter{writer: writer, ending: []byte(file.LineEnding)}
+	}
 	for _, e := range file.Entries {
 		if err := e.Write(writer); err != nil {
 			return err
@@ -59,3 +109,31 @@ func (file *BibFile) Write(writer io.Writer) error {
 	}
 	return file.Suffix.Write(writer)
 }
+
+// lineEndingWriter writes to writer, replacing every '\n' by ending
+type lineEndingWriter struct {
+	writer io.Writer
+	ending []byte
+}
+
+func (lw *lineEndingWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i == -1 {
+			m, err := lw.writer.Write(p)
+			return n + m, err
+		}
+
+		m, err := lw.writer.Write(p[:i])
+		n += m
+		if err != nil {
+			return n, err
+		}
+		if _, err := lw.writer.Write(lw.ending); err != nil {
+			return n, err
+		}
+		n++
+		p = p[i+1:]
+	}
+	return
+}
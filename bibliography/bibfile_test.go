@@ -2,21 +2,26 @@ package bibliography
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"os"
 	"path"
 	"reflect"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/tkw1536/gotexml/utils"
 )
 
 func Test_readFile(t *testing.T) {
 	tests := []struct {
-		name  string
-		asset string
+		name    string
+		asset   string
+		offsets bool // if the asset includes offsets and UTF-16 columns, otherwise see Test_readFile_offsets
 	}{
-		{"complicated.bib", "0001_complicated"},
-		{"kwarc.bib", "0002_kwarc"},
+		{"complicated.bib", "0001_complicated", true},
+		{"kwarc.bib", "0002_kwarc", false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -40,6 +45,9 @@ func Test_readFile(t *testing.T) {
 				t.Errorf("BibFile.readFile() error = %v, wantErr %v", err, false)
 				return
 			}
+			if !tt.offsets {
+				clearOffsets(gotFile)
+			}
 
 			if !reflect.DeepEqual(gotFile, wantFile) {
 				t.Errorf("BibFile.readFile() = %v, want %v", gotFile, wantFile)
@@ -48,6 +56,108 @@ func Test_readFile(t *testing.T) {
 	}
 }
 
+func Test_readFile_offsets(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"complicated.bib", complicatedBibFileText},
+		{"kwarc.bib", kwarcBibFileText},
+		{"kwarc.bib with CRLF", strings.ReplaceAll(kwarcBibFileText, "\n", "\r\n")},
+		{"multibyte", "@book{\u00e4, title = {\U0001F600 and \u00fc},\r\n  author = \"\u00df\" # x}\n% \U0001F600\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := NewBibFileFromReader(utils.NewRuneReaderFromString(tt.source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := checkOffsets(tt.source, file); err != nil {
+				t.Errorf("BibFile.readFile() %v", err)
+			}
+		})
+	}
+}
+
+// checkOffsets checks that the offsets and UTF-16 columns of all positions within file match their lines and columns within source
+func checkOffsets(source string, file *BibFile) (err error) {
+	// find the start of each line, line breaks belong to the line they end
+	type lineStart struct{ offset, runeOffset int }
+	starts := []lineStart{{}}
+	lines := strings.SplitAfter(source, "\n")
+	for _, line := range lines {
+		last := starts[len(starts)-1]
+		starts = append(starts, lineStart{last.offset + len(line), last.runeOffset + utf8.RuneCountInString(line)})
+	}
+
+	check := func(pos utils.ReaderPosition) error {
+		if int(pos.Line) >= len(lines) {
+			return fmt.Errorf("position %v: line out of range", pos)
+		}
+		line := []rune(lines[pos.Line])
+		if int(pos.Column) > len(line) {
+			return fmt.Errorf("position %v: column out of range", pos)
+		}
+
+		want := pos
+		want.Offset = uint(starts[pos.Line].offset + len(string(line[:pos.Column])))
+		want.RuneOffset = uint(starts[pos.Line].runeOffset) + pos.Column
+		want.UTF16Column = 0
+		for _, r := range line[:pos.Column] {
+			want.UTF16Column += uint(utils.UTF16Len(r))
+		}
+		if pos != want {
+			return fmt.Errorf("position = %#v, want %#v", pos, want)
+		}
+		return nil
+	}
+
+	Walk(file.Node(), func(node Node) bool {
+		var source utils.ReaderRange
+		switch n := node.(type) {
+		case *FileNode:
+			source = n.File.Source
+		case *EntryNode:
+			source = n.Entry.Source
+		case *FieldNode:
+			source = n.Field.Source
+		case *StringNode:
+			source = n.String.Source
+		}
+		if err == nil {
+			err = check(source.Start)
+		}
+		if err == nil {
+			err = check(source.End)
+		}
+		return err == nil
+	})
+	return
+}
+
+// clearOffsets clears the offsets and UTF-16 columns of all positions within file.
+// This keeps large assets small, as only lines and columns remain.
+func clearOffsets(file *BibFile) {
+	clear := func(source *utils.ReaderRange) {
+		for _, pos := range []*utils.ReaderPosition{&source.Start, &source.End} {
+			pos.UTF16Column, pos.Offset, pos.RuneOffset = 0, 0, 0
+		}
+	}
+	Walk(file.Node(), func(node Node) bool {
+		switch n := node.(type) {
+		case *FileNode:
+			clear(&n.File.Source)
+		case *EntryNode:
+			clear(&n.Entry.Source)
+		case *FieldNode:
+			clear(&n.Field.Source)
+		case *StringNode:
+			clear(&n.String.Source)
+		}
+		return true
+	})
+}
+
 func Benchmark_ReadFile_Complicated(b *testing.B) {
 	benchmarkReadFile(complicatedBibFileText, b)
 }
@@ -95,3 +205,102 @@ func TestBibFile_Write(t *testing.T) {
 		})
 	}
 }
+
+func TestBibFile_Write_lineEnding(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		formatter      *Formatter
+		wantLineEnding string
+		wantOutput     string
+	}{
+		{"unix", "@book{a,\n  title = {x\ny}\n}\n", nil, "", "@book{a,\n  title = {x\ny}\n}\n"},
+		{"windows", "@book{a,\r\n  title = {x\r\ny}\r\n}\r\n", nil, "\r\n", "@book{a,\r\n  title = {x\r\ny}\r\n}\r\n"},
+		{"reversed", "@book{a,\n\r  title = {x}\n\r}\n\r", nil, "\n\r", "@book{a,\n\r  title = {x}\n\r}\n\r"},
+		{"lone carriage return", "@book{a,\r\n  title = {x\ry}\r\n}", nil, "\r\n", "@book{a,\r\n  title = {x\ry}\r\n}"},
+		{"no line breaks", "@book{a, title = {x}}", nil, "", "@book{a, title = {x}}"},
+		{"mixed, windows first", "@book{a,\r\n  title = {x}\n}\n", nil, "\r\n", "@book{a,\r\n  title = {x}\r\n}\r\n"},
+		{"mixed, unix first", "@book{a,\n  title = {x}\r\n}\r\n", nil, "", "@book{a,\n  title = {x}\n}\n"},
+		{"format as crlf", "@book{a,\n  title = {x}\n}\n", &Formatter{FieldSeparator: "\n  ", FieldSpace: " ", EntrySuffix: "\n", LineEnding: LineEndingCRLF}, "\r\n", "@book{a,\r\n  title = {x}\r\n}\r\n"},
+		{"format as lf", "@book{a,\r\n  title = {x}\r\n}\r\n", &Formatter{FieldSeparator: "\n  ", FieldSpace: " ", EntrySuffix: "\n", LineEnding: LineEndingLF}, "\n", "@book{a,\n  title = {x}\n}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := NewBibFileFromReader(utils.NewRuneReaderFromString(tt.input))
+			if err != nil {
+				t.Fatalf("NewBibFileFromReader() error = %v", err)
+			}
+			if tt.formatter != nil {
+				tt.formatter.Format(file)
+			}
+			if file.LineEnding != tt.wantLineEnding {
+				t.Errorf("BibFile.LineEnding = %q, want %q", file.LineEnding, tt.wantLineEnding)
+			}
+
+			writer := &bytes.Buffer{}
+			if err := file.Write(writer); err != nil {
+				t.Errorf("BibFile.Write() error = %v", err)
+			}
+			if got := writer.String(); got != tt.wantOutput {
+				t.Errorf("BibFile.Write() = %q, want %q", got, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestBibFile_Write_encoding(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		encoding     utils.Encoding
+		wantEncoding utils.Encoding
+		wantBOM      bool
+		wantTitle    string
+	}{
+		{"utf-8", "@book{a, title = {M\xc3\xbcller}}", utils.EncodingUTF8, "", false, "Müller"},
+		{"utf-8 with bom", "\xef\xbb\xbf@book{a, title = {M\xc3\xbcller}}", utils.EncodingUTF8, utils.EncodingUTF8, true, "Müller"},
+		{"utf-16le", "\xff\xfe@\x00b\x00o\x00o\x00k\x00{\x00a\x00,\x00t\x00=\x00{\x00\xfc\x00}\x00}\x00", utils.EncodingUTF8, utils.EncodingUTF16LE, true, "ü"},
+		{"latin-1", "@book{a, title = {M\xfcller}}", utils.EncodingLatin1, utils.EncodingLatin1, false, "Müller"},
+		{"detected windows-1252", "@book{a,\r\n title = {\x93M\xfcller\x94}}", utils.EncodingAuto, utils.EncodingWindows1252, false, "“Müller”"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := NewBibFileFromReader(utils.NewRuneReaderWithEncoding(bytes.NewBufferString(tt.input), tt.encoding))
+			if err != nil {
+				t.Fatalf("NewBibFileFromReader() error = %v", err)
+			}
+			if file.Encoding != tt.wantEncoding || file.BOM != tt.wantBOM {
+				t.Errorf("BibFile.Encoding, BibFile.BOM = %v, %v, want %v, %v", file.Encoding, file.BOM, tt.wantEncoding, tt.wantBOM)
+			}
+			if got := file.Entries[0].Fields[1].Evaluate(nil); got != tt.wantTitle {
+				t.Errorf("BibField.Evaluate() = %q, want %q", got, tt.wantTitle)
+			}
+
+			writer := &bytes.Buffer{}
+			if err := file.Write(writer); err != nil {
+				t.Errorf("BibFile.Write() error = %v", err)
+			}
+			if got := writer.String(); got != tt.input {
+				t.Errorf("BibFile.Write() = %q, want %q", got, tt.input)
+			}
+		})
+	}
+}
+
+func TestNewBibFileFromReader_invalidEncoding(t *testing.T) {
+	_, err := NewBibFileFromReader(utils.NewRuneReaderFromString("@book{a,\n title = {M\xfcller}}"))
+	if !errors.Is(err, utils.CodeInvalidEncoding) {
+		t.Fatalf("NewBibFileFromReader() error = %v, want %v", err, utils.CodeInvalidEncoding)
+	}
+
+	// find the innermost error, which is the one reported by the reader
+	var rerr *utils.ReaderError
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := err.(*utils.ReaderError); ok {
+			rerr = e
+		}
+	}
+	if want := (utils.ReaderPosition{Line: 1, Column: 11, UTF16Column: 11, Offset: 20, RuneOffset: 20}); rerr.Location != want {
+		t.Errorf("NewBibFileFromReader() error location = %v, want %v", rerr.Location, want)
+	}
+}
@@ -51,7 +51,7 @@ func (bs *BibString) AppendRaw(value string, loc utils.ReaderRange) {
 // readLiteral reads a BibString of kind BibStringLiteral from the input
 // Skips and returns spaces after the BibString.
 // If not nil, err is an instance of utils.ReaderError
-func (bs *BibString) readLiteral(reader *utils.RuneReader) (space *BibString, rr error) {
+func (bs *BibString) readLiteral(reader *utils.RuneReader) (space *BibString, err error) {
 	// read the next character or bail out when an error or EOF occurs
 	char, pos, err := reader.Read()
 	if err != nil {
@@ -59,7 +59,7 @@ func (bs *BibString) readLiteral(reader *utils.RuneReader) (space *BibString, rr
 		return
 	}
 	if pos.EOF {
-		err = utils.NewErrorF(reader, "Unexpected end of input while attempting to read literal")
+		err = utils.NewUnexpectedError(reader, utils.CodeUnexpectedEOF, "literal", "", "")
 		return
 	}
 
@@ -102,7 +102,7 @@ func (bs *BibString) readLiteral(reader *utils.RuneReader) (space *BibString, rr
 			return
 		}
 		if pos.EOF {
-			err = utils.NewErrorF(reader, "Unexpected end of input while attempting to read literal")
+			err = utils.NewUnexpectedError(reader, utils.CodeUnexpectedEOF, "literal", "", "")
 			return
 		}
 	}
@@ -135,12 +135,12 @@ func (bs *BibString) readBrace(reader *utils.RuneReader) (err error) {
 		return
 	}
 	if pos.EOF {
-		err = utils.NewErrorF(reader, "Unexpected end of input while attempting to read brace")
+		err = utils.NewUnexpectedError(reader, utils.CodeUnexpectedEOF, "braces", "{", "")
 		return
 	}
 
 	if char != '{' {
-		err = utils.NewErrorF(reader, "Expected to find an '{' but got %q", char)
+		err = utils.NewUnexpectedError(reader, utils.CodeMissingBrace, "braces", "{", string(char))
 		return
 	}
 
@@ -159,7 +159,7 @@ func (bs *BibString) readBrace(reader *utils.RuneReader) (err error) {
 			return
 		}
 		if pos.EOF {
-			err = utils.NewErrorF(reader, "Unexpected end of input while attempting to read braces")
+			err = utils.NewUnexpectedError(reader, utils.CodeUnbalancedBrace, "braces", "}", "")
 			return
 		}
 
@@ -197,12 +197,12 @@ func (bs *BibString) readQuote(reader *utils.RuneReader) (err error) {
 		return
 	}
 	if pos.EOF {
-		err = utils.NewErrorF(reader, "Unexpected end of input while attempting to read quote")
+		err = utils.NewUnexpectedError(reader, utils.CodeUnexpectedEOF, "quote", "\"", "")
 		return
 	}
 
 	if char != '"' {
-		err = utils.NewErrorF(reader, "Expected to find an '\"' but got %q", char)
+		err = utils.NewUnexpectedError(reader, utils.CodeMissingQuote, "quote", "\"", string(char))
 		return
 	}
 
@@ -221,7 +221,7 @@ func (bs *BibString) readQuote(reader *utils.RuneReader) (err error) {
 			return
 		}
 		if pos.EOF {
-			err = utils.NewErrorF(reader, "Unexpected end of input while attempting to read quote")
+			err = utils.NewUnexpectedError(reader, utils.CodeUnterminatedQuote, "quote", "\"", "")
 			return
 		}
 
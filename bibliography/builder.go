@@ -0,0 +1,167 @@
+package bibliography
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// EntryBuilder builds a new BibEntry, see NewEntry
+type EntryBuilder struct {
+	kind   string
+	label  string
+	fields [][]*BibFieldElement // elements of each field after the label
+}
+
+// NewEntry starts building an entry of the given kind and label, such as NewEntry("article", "knuth1984").
+// An empty label omits it, as in '@string' or '@preamble' entries.
+//
+// The built entry is formatted like DefaultFormatter formats entries.
+// Values are not validated, and the source ranges of the built entry are empty.
+func NewEntry(kind, label string) *EntryBuilder {
+	return &EntryBuilder{kind: kind, label: label}
+}
+
+// Field adds a field 'key = {value}'
+func (builder *EntryBuilder) Field(key, value string) *EntryBuilder {
+	return builder.FieldValue(key, BibString{Kind: BibStringBracket, Value: value})
+}
+
+// Macro adds a field 'key = name' referencing the macro with the given name, such as Macro("month", "jan")
+func (builder *EntryBuilder) Macro(key, name string) *EntryBuilder {
+	return builder.FieldValue(key, BibString{Kind: BibStringLiteral, Value: name})
+}
+
+// FieldValue adds a field 'key = value1 # value2 # ...' concatenating the given values.
+// Each value should be a BibStringLiteral, BibStringQuote or BibStringBracket.
+func (builder *EntryBuilder) FieldValue(key string, values ...BibString) *EntryBuilder {
+	elements := []*BibFieldElement{{
+		Value:  &BibString{Kind: BibStringLiteral, Value: key},
+		Suffix: &BibString{Value: " = "},
+		Role:   KeyElementRole,
+	}}
+	builder.fields = append(builder.fields, append(elements, newTerms(values)...))
+	return builder
+}
+
+// Value adds a field without a key concatenating the given values, such as the value of a '@preamble' entry
+func (builder *EntryBuilder) Value(values ...BibString) *EntryBuilder {
+	builder.fields = append(builder.fields, newTerms(values))
+	return builder
+}
+
+// newTerms creates elements concatenating values
+func newTerms(values []BibString) (elements []*BibFieldElement) {
+	for i := range values {
+		element := &BibFieldElement{Value: &values[i], Suffix: &BibString{}}
+		if i != len(values)-1 {
+			element.Suffix.Value = " # "
+			element.Role = TermElementRole
+		}
+		elements = append(elements, element)
+	}
+	return
+}
+
+// Build returns the built entry.
+// The builder can be reused, entries built earlier are not affected by further calls.
+func (builder *EntryBuilder) Build() *BibEntry {
+	entry := &BibEntry{
+		Kind:       &BibString{Kind: BibStringLiteral, Value: builder.kind},
+		KindSuffix: &BibString{},
+	}
+
+	if builder.label != "" {
+		entry.Fields = append(entry.Fields, &BibField{
+			Elements: []*BibFieldElement{{Value: &BibString{Kind: BibStringLiteral, Value: builder.label}, Suffix: &BibString{}}},
+		})
+	}
+	for _, elements := range builder.fields {
+		// copy the elements, as building modifies them and the builder may be reused
+		field := &BibField{Elements: make([]*BibFieldElement, len(elements))}
+		for i, element := range elements {
+			field.Elements[i] = element.Clone()
+		}
+		if len(entry.Fields) > 0 {
+			field.Prefix.Value = DefaultFormatter.FieldSeparator
+		}
+		entry.Fields = append(entry.Fields, field)
+	}
+
+	// every field but the last one ends with a ',', the last one closes the entry
+	if len(entry.Fields) == 0 {
+		entry.Fields = []*BibField{{}}
+	}
+	for _, field := range entry.Fields {
+		field.Suffix.Value = ","
+	}
+	last := entry.Fields[len(entry.Fields)-1]
+	last.Suffix.Value = "}"
+	if n := len(last.Elements); n > 0 && (len(entry.Fields) > 1 || len(builder.fields) > 0) {
+		last.Elements[n-1].Suffix.Value = DefaultFormatter.EntrySuffix
+	}
+
+	return entry
+}
+
+// Append appends entries to the end of this file, see Insert
+func (file *BibFile) Append(entries ...*BibEntry) {
+	file.Insert(len(file.Entries), entries...)
+}
+
+// Insert inserts entries into this file, so that the first one has the given index.
+// Panics if index is out of range.
+//
+// Entries are separated by blank lines like DefaultFormatter separates them.
+// Text before the first entry, such as a comment at the beginning of the file, stays at the beginning of the file.
+// Source ranges within the file are not updated.
+func (file *BibFile) Insert(index int, entries ...*BibEntry) {
+	if index < 0 || index > len(file.Entries) {
+		panic("BibFile.Insert: index out of range")
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	for _, entry := range entries {
+		entry.Prefix.Value = DefaultFormatter.FileSeparator
+	}
+
+	switch {
+	case len(file.Entries) == 0:
+		// text of an empty file moves before the first entry
+		if text := strings.TrimRightFunc(file.Suffix.Value, unicode.IsSpace); text != "" {
+			entries[0].Prefix.Value = text + DefaultFormatter.FileSeparator
+		} else {
+			entries[0].Prefix.Value = ""
+		}
+		file.Suffix.Value = "\n"
+	case index == 0:
+		// the new first entry takes over the text at the beginning of the file
+		entries[0].Prefix.Value, file.Entries[0].Prefix.Value = file.Entries[0].Prefix.Value, DefaultFormatter.FileSeparator
+	}
+
+	file.Entries = slices.Insert(file.Entries, index, entries...)
+}
+
+// Remove removes entry from this file, and returns if it was found.
+// Text before the first entry stays at the beginning of the file, any other text before entry is removed along with it.
+// Source ranges within the file are not updated.
+func (file *BibFile) Remove(entry *BibEntry) bool {
+	for i, e := range file.Entries {
+		if e != entry {
+			continue
+		}
+
+		switch {
+		case len(file.Entries) == 1:
+			file.Suffix.Value = entry.Prefix.Value + file.Suffix.Value
+		case i == 0:
+			file.Entries[1].Prefix.Value = entry.Prefix.Value
+		}
+
+		file.Entries = slices.Delete(file.Entries, i, i+1)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package bibliography
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// clearSources clears all source ranges below node
+func clearSources(node Node) {
+	Walk(node, func(node Node) bool {
+		switch n := node.(type) {
+		case *FileNode:
+			n.File.Source = utils.ReaderRange{}
+		case *EntryNode:
+			n.Entry.Source = utils.ReaderRange{}
+		case *FieldNode:
+			n.Field.Source = utils.ReaderRange{}
+		case *StringNode:
+			n.String.Source = utils.ReaderRange{}
+		}
+		return true
+	})
+}
+
+// writeString writes a BibEntry or BibFile into a string
+func writeString(node interface{ Write(w io.Writer) error }) string {
+	var builder strings.Builder
+	node.Write(&builder)
+	return builder.String()
+}
+
+func TestEntryBuilder_Build(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *EntryBuilder
+		want    string
+	}{
+		{
+			"fields",
+			NewEntry("article", "knuth1984").Field("title", "The {\\TeX}book").Macro("month", "jan"),
+			"@article{knuth1984,\n    title = {The {\\TeX}book},\n    month = jan\n}",
+		},
+		{
+			"concatenation",
+			NewEntry("misc", "a").FieldValue("author", BibString{Kind: BibStringLiteral, Value: "me"}, BibString{Kind: BibStringQuote, Value: " and others"}),
+			"@misc{a,\n    author = me # \" and others\"\n}",
+		},
+		{"label only", NewEntry("misc", "key"), "@misc{key}"},
+		{"empty", NewEntry("misc", ""), "@misc{}"},
+		{"string", NewEntry("string", "").Field("me", "X"), "@string{me = {X}\n}"},
+		{"preamble", NewEntry("preamble", "").Value(BibString{Kind: BibStringQuote, Value: "\\newcommand"}), "@preamble{\"\\newcommand\"\n}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := tt.builder.Build()
+			if got := writeString(entry); got != tt.want {
+				t.Errorf("EntryBuilder.Build() = %q, want %q", got, tt.want)
+			}
+
+			// the built entry should be identical to the parsed one
+			file, err := NewBibFileFromString(tt.want)
+			if err != nil {
+				t.Fatalf("NewBibFileFromString() error = %v", err)
+			}
+			clearSources(file.Node())
+			if !reflect.DeepEqual(entry, file.Entries[0]) {
+				t.Errorf("EntryBuilder.Build() = %v, want %v", entry, file.Entries[0])
+			}
+		})
+	}
+}
+
+func TestEntryBuilder_Build_reuse(t *testing.T) {
+	builder := NewEntry("misc", "a").Field("title", "x")
+	first := builder.Build()
+	second := builder.Field("year", "2000").Build()
+
+	if got, want := writeString(first), "@misc{a,\n    title = {x}\n}"; got != want {
+		t.Errorf("EntryBuilder.Build() = %q, want %q", got, want)
+	}
+	if got, want := writeString(second), "@misc{a,\n    title = {x},\n    year = {2000}\n}"; got != want {
+		t.Errorf("EntryBuilder.Build() = %q, want %q", got, want)
+	}
+	if first.Fields[1].Elements[1] == second.Fields[1].Elements[1] {
+		t.Error("EntryBuilder.Build() shares elements between entries")
+	}
+}
+
+func TestEntryBuilder_Format(t *testing.T) {
+	file := &BibFile{}
+	file.Append(
+		NewEntry("article", "a").Field("title", "A").Field("year", "2000").Build(),
+		NewEntry("book", "b").Macro("month", "feb").Build(),
+	)
+
+	want := writeString(file)
+	DefaultFormatter.Format(file)
+	if got := writeString(file); got != want {
+		t.Errorf("Formatter.Format() = %q, want %q", got, want)
+	}
+}
+
+func TestBibFile_Insert(t *testing.T) {
+	a := func() *BibEntry { return NewEntry("misc", "a").Build() }
+
+	tests := []struct {
+		name  string
+		input string
+		index int
+		want  string
+	}{
+		{"empty file", "", 0, "@misc{a}\n"},
+		{"comment only", "% header\n", 0, "% header\n\n@misc{a}\n"},
+		{"beginning", "% header\n@misc{b}\n", 0, "% header\n@misc{a}\n\n@misc{b}\n"},
+		{"middle", "@misc{b}\n\n@misc{c}\n", 1, "@misc{b}\n\n@misc{a}\n\n@misc{c}\n"},
+		{"end", "@misc{b}\n% footer\n", 1, "@misc{b}\n\n@misc{a}\n% footer\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := NewBibFileFromString(tt.input)
+			if err != nil {
+				t.Fatalf("NewBibFileFromString() error = %v", err)
+			}
+
+			file.Insert(tt.index, a())
+			if got := writeString(file); got != tt.want {
+				t.Errorf("BibFile.Insert() = %q, want %q", got, tt.want)
+			}
+
+			// the modified file should be identical to the parsed one
+			want, err := NewBibFileFromString(tt.want)
+			if err != nil {
+				t.Fatalf("NewBibFileFromString() error = %v", err)
+			}
+			clearSources(file.Node())
+			clearSources(want.Node())
+			if !reflect.DeepEqual(file, want) {
+				t.Errorf("BibFile.Insert() = %v, want %v", file, want)
+			}
+		})
+	}
+}
+
+func TestBibFile_Append(t *testing.T) {
+	file := &BibFile{}
+	file.Append(NewEntry("misc", "a").Build(), NewEntry("misc", "b").Build())
+	file.Append(NewEntry("misc", "c").Build())
+
+	if got, want := writeString(file), "@misc{a}\n\n@misc{b}\n\n@misc{c}\n"; got != want {
+		t.Errorf("BibFile.Append() = %q, want %q", got, want)
+	}
+}
+
+func TestBibFile_Remove(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		index  int
+		want   string
+		wantOk bool
+	}{
+		{"only entry", "% header\n@misc{a}\n", 0, "% header\n\n", true},
+		{"first entry", "% header\n@misc{a}\n\n@misc{b}\n", 0, "% header\n@misc{b}\n", true},
+		{"other entry", "@misc{a}\n% about b\n@misc{b}\n", 1, "@misc{a}\n", true},
+		{"missing entry", "@misc{a}\n", -1, "@misc{a}\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := NewBibFileFromString(tt.input)
+			if err != nil {
+				t.Fatalf("NewBibFileFromString() error = %v", err)
+			}
+
+			entry := NewEntry("misc", "x").Build()
+			if tt.index >= 0 {
+				entry = file.Entries[tt.index]
+			}
+			if gotOk := file.Remove(entry); gotOk != tt.wantOk {
+				t.Errorf("BibFile.Remove() = %v, want %v", gotOk, tt.wantOk)
+			}
+			if got := writeString(file); got != tt.want {
+				t.Errorf("BibFile.Remove() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
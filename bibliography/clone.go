@@ -0,0 +1,82 @@
+package bibliography
+
+// Clone returns a deep copy of this BibFile
+func (file *BibFile) Clone() *BibFile {
+	if file == nil {
+		return nil
+	}
+	clone := &BibFile{
+		Suffix:     file.Suffix,
+		Source:     file.Source,
+		LineEnding: file.LineEnding,
+		Encoding:   file.Encoding,
+		BOM:        file.BOM,
+	}
+	if file.Entries != nil {
+		clone.Entries = make([]*BibEntry, len(file.Entries))
+		for i, e := range file.Entries {
+			clone.Entries[i] = e.Clone()
+		}
+	}
+	return clone
+}
+
+// Clone returns a deep copy of this BibEntry
+func (entry *BibEntry) Clone() *BibEntry {
+	if entry == nil {
+		return nil
+	}
+	clone := &BibEntry{
+		Prefix:     entry.Prefix,
+		Kind:       entry.Kind.clone(),
+		KindSuffix: entry.KindSuffix.clone(),
+		Source:     entry.Source,
+	}
+	if entry.Fields != nil {
+		clone.Fields = make([]*BibField, len(entry.Fields))
+		for i, f := range entry.Fields {
+			clone.Fields[i] = f.Clone()
+		}
+	}
+	return clone
+}
+
+// Clone returns a deep copy of this BibField
+func (field *BibField) Clone() *BibField {
+	if field == nil {
+		return nil
+	}
+	clone := &BibField{
+		Prefix: field.Prefix,
+		Suffix: field.Suffix,
+		Source: field.Source,
+	}
+	if field.Elements != nil {
+		clone.Elements = make([]*BibFieldElement, len(field.Elements))
+		for i, e := range field.Elements {
+			clone.Elements[i] = e.Clone()
+		}
+	}
+	return clone
+}
+
+// Clone returns a deep copy of this BibFieldElement
+func (element *BibFieldElement) Clone() *BibFieldElement {
+	if element == nil {
+		return nil
+	}
+	return &BibFieldElement{
+		Value:  element.Value.clone(),
+		Suffix: element.Suffix.clone(),
+		Role:   element.Role,
+	}
+}
+
+// clone returns a copy of a pointer to a BibString
+func (bs *BibString) clone() *BibString {
+	if bs == nil {
+		return nil
+	}
+	clone := *bs
+	return &clone
+}
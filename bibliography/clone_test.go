@@ -0,0 +1,34 @@
+package bibliography
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+func TestBibFile_Clone(t *testing.T) {
+	file, err := NewBibFileFromReader(utils.NewRuneReaderFromString("@string{me = {Me}}\n@misc{a, author = me # {, You}, title = {A}}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clone := file.Clone()
+	if !reflect.DeepEqual(clone, file) {
+		t.Errorf("BibFile.Clone() = %v, want %v", clone, file)
+	}
+
+	// modifying the clone should not modify the original
+	clone.Entries[1].Kind.Value = "book"
+	clone.Entries[1].Fields[1].Elements[1].Value.Value = "you"
+	clone.Entries[1].Prefix.Value = "\n\n"
+	if got := file.Entries[1].Kind.Value; got != "misc" {
+		t.Errorf("BibFile.Clone() modified Kind = %q, want %q", got, "misc")
+	}
+	if got := file.Entries[1].Fields[1].Elements[1].Value.Value; got != "me" {
+		t.Errorf("BibFile.Clone() modified value = %q, want %q", got, "me")
+	}
+	if got := file.Entries[1].Prefix.Value; got != "\n" {
+		t.Errorf("BibFile.Clone() modified Prefix = %q, want %q", got, "\n")
+	}
+}
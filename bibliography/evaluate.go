@@ -0,0 +1,111 @@
+package bibliography
+
+import (
+	"strings"
+)
+
+// DefaultMacros are the macros predefined by the standard BibTeX styles.
+// They are used by Evaluate for any macro not defined within a BibFile.
+var DefaultMacros = map[string]string{
+	"jan": "January",
+	"feb": "February",
+	"mar": "March",
+	"apr": "April",
+	"may": "May",
+	"jun": "June",
+	"jul": "July",
+	"aug": "August",
+	"sep": "September",
+	"oct": "October",
+	"nov": "November",
+	"dec": "December",
+}
+
+// Macros returns the macros defined by '@string' entries within this BibFile.
+// Macro names are normalized to lower case, as they are case-insensitive in BibTeX.
+// Macros defined in terms of other macros are evaluated in the order they are defined.
+func (file *BibFile) Macros() map[string]string {
+	macros := make(map[string]string)
+	for _, entry := range file.Entries {
+		if !entry.IsKind("string") {
+			continue
+		}
+		for _, field := range entry.Fields {
+			name := field.Name()
+			if name == "" {
+				continue
+			}
+			macros[strings.ToLower(name)] = field.Evaluate(macros)
+		}
+	}
+	return macros
+}
+
+// Evaluate evaluates the value of this BibField into a single string.
+// Quoted and braced elements are used verbatim, numeric literals are used as is and other literals are expanded using macros.
+// Literals not found in macros are looked up in DefaultMacros, and expand to the empty string otherwise.
+//
+// For fields that are not of the form 'key = value', all elements are evaluated.
+func (field *BibField) Evaluate(macros map[string]string) string {
+	elements := field.Elements
+	if field.IsKeyValue() {
+		elements = field.GetValue()
+	}
+
+	var builder strings.Builder
+	for _, e := range elements {
+		builder.WriteString(e.Evaluate(macros))
+	}
+	return builder.String()
+}
+
+// Evaluate evaluates the value of this BibFieldElement.
+// See BibField.Evaluate.
+func (element *BibFieldElement) Evaluate(macros map[string]string) string {
+	value := element.Value
+	if value.Kind != BibStringLiteral || isNumericLiteral(value.Value) {
+		return value.Value
+	}
+
+	name := strings.ToLower(value.Value)
+	if v, ok := macros[name]; ok {
+		return v
+	}
+	return DefaultMacros[name]
+}
+
+// References returns the names of the macros referenced by the value of this BibField.
+// Names are normalized to lower case and each name is returned only once.
+func (field *BibField) References() (names []string) {
+	elements := field.Elements
+	if field.IsKeyValue() {
+		elements = field.GetValue()
+	}
+
+	seen := make(map[string]struct{})
+	for _, e := range elements {
+		if e.Value.Kind != BibStringLiteral || isNumericLiteral(e.Value.Value) {
+			continue
+		}
+		name := strings.ToLower(e.Value.Value)
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	return
+}
+
+// isNumericLiteral checks if s consists only of ascii digits
+func isNumericLiteral(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
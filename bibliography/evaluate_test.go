@@ -0,0 +1,54 @@
+package bibliography
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+func TestBibFile_Macros(t *testing.T) {
+	file, err := NewBibFileFromReader(utils.NewRuneReaderFromString(`@string{a = "A"} @STRING{B = a # {b}, c = 2020} @misc{x, y = a}`))
+	if err != nil {
+		panic(err)
+	}
+
+	want := map[string]string{"a": "A", "b": "Ab", "c": "2020"}
+	if got := file.Macros(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BibFile.Macros() = %v, want %v", got, want)
+	}
+}
+
+func TestBibField_Evaluate(t *testing.T) {
+	macros := map[string]string{"me": "Bart Kiers"}
+	tests := []struct {
+		name     string
+		input    string
+		want     string
+		wantRefs []string
+	}{
+		{"empty field", ``, "", nil},
+		{"braced value", `{value}`, "value", nil},
+		{"key and quoted value", `title = "value"`, "value", nil},
+		{"number", `year = 2005`, "2005", nil},
+		{"macro", `author = me`, "Bart Kiers", []string{"me"}},
+		{"macro case", `author = ME`, "Bart Kiers", []string{"me"}},
+		{"default macro", `month = dec`, "December", []string{"dec"}},
+		{"unknown macro", `month = unknown`, "", []string{"unknown"}},
+		{"concatenation", `author = me # " and " # me # {!}`, "Bart Kiers and Bart Kiers!", []string{"me"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := &BibField{}
+			if err := field.readField(utils.NewRuneReaderFromString(tt.input + ", ")); err != nil {
+				panic(err)
+			}
+			if got := field.Evaluate(macros); got != tt.want {
+				t.Errorf("BibField.Evaluate() = %q, want %q", got, tt.want)
+			}
+			if got := field.References(); !reflect.DeepEqual(got, tt.wantRefs) {
+				t.Errorf("BibField.References() = %v, want %v", got, tt.wantRefs)
+			}
+		})
+	}
+}
@@ -22,8 +22,16 @@ type Formatter struct {
 	FileSeparator string // separator between different entries in a file
 
 	SortEntries bool // if true, sort entries by their key
+
+	LineEnding string // line break to write the file with, such as LineEndingCRLF; empty to keep the line breaks of the file
 }
 
+// line endings that can be used by a Formatter
+const (
+	LineEndingLF   = "\n"
+	LineEndingCRLF = "\r\n"
+)
+
 // EntryKindFormat represents how to format the kind of an entry
 type EntryKindFormat int
 
@@ -63,6 +71,11 @@ func (format Formatter) Format(file *BibFile) {
 	}
 
 	file.Suffix.Value = "\n" // hard-code end of file
+
+	// set the line ending if requested
+	if format.LineEnding != "" {
+		file.LineEnding = format.LineEnding
+	}
 }
 
 // entry formats the given entry
@@ -0,0 +1,201 @@
+package bibliography
+
+import (
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// TextEdit is a change to a text, replacing the bytes from Start up to (excluding) End by Text
+type TextEdit struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Text  string `json:"text"`
+}
+
+// Apply returns text with this edit applied
+func (edit TextEdit) Apply(text string) string {
+	return text[:edit.Start] + edit.Text + text[edit.End:]
+}
+
+// Reparse reads the BibFile of text after applying edit, where file was read from text.
+// Returns the new file and the edited text, which values of the new file may refer to.
+//
+// Only the entry containing the edit is read again.
+// Entries before it are re-used as is, entries behind it are re-used with their source ranges shifted.
+// This modifies the re-used entries of file, so file should no longer be used.
+// If the edit crosses the boundary between two entries, or the input can not be read incrementally, the entire edited text is read.
+//
+// The result is identical to reading the edited text using NewBibFileFromString, including any error.
+func Reparse(file *BibFile, text string, edit TextEdit) (newFile *BibFile, newText string, err error) {
+	if edit.Start < 0 || edit.Start > edit.End || edit.End > len(text) {
+		return nil, "", fmt.Errorf("invalid edit of bytes %d to %d in text of length %d", edit.Start, edit.End, len(text))
+	}
+	newText = edit.Apply(text)
+
+	newFile, ok := reparse(file, newText, edit)
+	if !ok {
+		newFile, err = NewBibFileFromString(newText)
+	}
+	return
+}
+
+// reparse implements Reparse, returning ok = false if newText has to be read entirely
+func reparse(file *BibFile, newText string, edit TextEdit) (newFile *BibFile, ok bool) {
+	// only utf-8 input can be sliced, and the byte order mark must remain intact
+	if (file.Encoding != "" && file.Encoding != utils.EncodingUTF8) || edit.Start < int(file.Source.Start.Offset) {
+		return nil, false
+	}
+
+	// find the entry containing the edit, starting after the preceding entry.
+	// when the edit is behind all entries, index is len(file.Entries) and the suffix of the file is read again.
+	index := len(file.Entries)
+	for i, entry := range file.Entries {
+		if entryEnd(entry) > edit.Start {
+			index = i
+			break
+		}
+	}
+
+	// the edit may not cross into the next entry
+	start := file.Suffix.Source.Start
+	if index < len(file.Entries) {
+		start = file.Entries[index].Prefix.Source.Start
+	}
+	end, last := len(newText), index >= len(file.Entries)-1
+	if !last {
+		next := file.Entries[index+1].Prefix.Source.Start
+		if edit.End > int(next.Offset) {
+			return nil, false
+		}
+		end = int(next.Offset) + len(edit.Text) - (edit.End - edit.Start)
+	}
+
+	if !utf8.ValidString(newText[start.Offset:end]) {
+		return nil, false
+	}
+
+	// read the entries in between
+	p := &sliceParser{src: newText, i: int(start.Offset), pos: start}
+	var entries []*BibEntry
+	var suffix BibString
+	for last || p.i < end {
+		entry := p.newEntry()
+		err := p.readEntry(entry)
+		if err == io.EOF && last {
+			suffix = entry.Prefix
+			break
+		}
+		if err != nil {
+			return nil, false
+		}
+		entries = append(entries, entry)
+	}
+	if p.i != end {
+		return nil, false
+	}
+
+	// assemble the new file
+	// Entries remains nil when there are none, like when reading the entire text
+	newFile = &BibFile{
+		Source:   utils.ReaderRange{Start: file.Source.Start, End: file.Source.Start},
+		Encoding: file.Encoding,
+		BOM:      file.BOM,
+	}
+	newFile.Entries = append(newFile.Entries, file.Entries[:index]...)
+	newFile.Entries = append(newFile.Entries, entries...)
+	if last {
+		newFile.Suffix = suffix
+	} else {
+		s := shift{from: file.Entries[index+1].Prefix.Source.Start, to: p.pos}
+		for _, entry := range file.Entries[index+1:] {
+			s.entry(entry)
+			newFile.Entries = append(newFile.Entries, entry)
+		}
+		newFile.Suffix = file.Suffix
+		s.string(&newFile.Suffix)
+	}
+
+	if n := len(newFile.Entries); n > 0 {
+		newFile.Source.End = newFile.Entries[n-1].Source.End
+	}
+	if ending := firstLineEnding(newText[file.Source.Start.Offset:]); ending != "\n" {
+		newFile.LineEnding = ending
+	}
+	return newFile, true
+}
+
+// entryEnd returns the byte offset directly behind the closing '}' of entry
+func entryEnd(entry *BibEntry) int {
+	return int(entry.Source.End.Offset) + 1
+}
+
+// firstLineEnding returns the first line break in s, see utils.RuneReader.LineEnding
+func firstLineEnding(s string) string {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\n' && c != '\r' {
+			continue
+		}
+		if i+1 < len(s) && (s[i+1] == '\n' || s[i+1] == '\r') && s[i+1] != c {
+			return s[i : i+2]
+		}
+		if c == '\n' {
+			return "\n"
+		}
+	}
+	return ""
+}
+
+// shift moves positions behind an edit.
+// Positions at or behind from are moved, so that from is moved to to.
+type shift struct {
+	from, to utils.ReaderPosition
+}
+
+// position shifts pos.
+// Positions before from, such as the zero sources of an empty entry kind, are left unchanged.
+func (s shift) position(pos *utils.ReaderPosition) {
+	if pos.Offset < s.from.Offset {
+		return
+	}
+	if pos.Line == s.from.Line {
+		pos.Column = pos.Column - s.from.Column + s.to.Column
+		pos.UTF16Column = pos.UTF16Column - s.from.UTF16Column + s.to.UTF16Column
+	}
+	pos.Line = pos.Line - s.from.Line + s.to.Line
+	pos.Offset = pos.Offset - s.from.Offset + s.to.Offset
+	pos.RuneOffset = pos.RuneOffset - s.from.RuneOffset + s.to.RuneOffset
+}
+
+// source shifts both ends of source
+func (s shift) source(source *utils.ReaderRange) {
+	s.position(&source.Start)
+	s.position(&source.End)
+}
+
+// string shifts the source of bs, if any
+func (s shift) string(bs *BibString) {
+	if bs != nil {
+		s.source(&bs.Source)
+	}
+}
+
+// entry shifts all sources within entry
+func (s shift) entry(entry *BibEntry) {
+	s.string(&entry.Prefix)
+	s.string(entry.Kind)
+	s.string(entry.KindSuffix)
+	for _, field := range entry.Fields {
+		s.string(&field.Prefix)
+		for _, element := range field.Elements {
+			s.string(element.Value)
+			s.string(element.Suffix)
+		}
+		s.string(&field.Suffix)
+		s.source(&field.Source)
+	}
+	s.source(&entry.Source)
+}
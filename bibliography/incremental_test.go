@@ -0,0 +1,154 @@
+package bibliography
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+func TestReparse(t *testing.T) {
+	const text = "@misc{a, title = {A}}\n\n@misc{b, title = {B}}  @misc{c, title = {C}}\n% end\n"
+
+	tests := []struct {
+		name  string
+		text  string
+		edit  TextEdit
+		reuse []bool // which entries of the result are re-used
+	}{
+		{"insert into first entry", text, TextEdit{Start: 18, End: 18, Text: "ä\nB"}, []bool{false, true, true}},
+		{"replace within middle entry", text, TextEdit{Start: 41, End: 42, Text: "D"}, []bool{true, false, true}},
+		{"delete prefix of middle entry", text, TextEdit{Start: 22, End: 23}, []bool{true, false, true}},
+		{"insert behind entry", text, TextEdit{Start: 21, End: 21, Text: " % comment"}, []bool{true, false, true}},
+		{"edit last entry", text, TextEdit{Start: 64, End: 65, Text: "}, x = y"}, []bool{true, true, false}},
+		{"edit suffix", text, TextEdit{Start: 68, End: 71, Text: "@misc{d}"}, []bool{true, true, true, false}},
+		{"split entry", text, TextEdit{Start: 20, End: 20, Text: "} @misc{z"}, []bool{false, false, true, true}},
+		{"remove entry", text, TextEdit{Start: 23, End: 44}, []bool{false, false}},
+		{"cross entries", text, TextEdit{Start: 20, End: 46, Text: "} "}, []bool{false, false}},
+		{"unbalance braces", text, TextEdit{Start: 43, End: 44}, []bool{false}},
+		{"introduce syntax error", text, TextEdit{Start: 42, End: 44}, nil},
+		{"windows line endings", "@misc{a}\r\n@misc{b}\r\n@misc{c}", TextEdit{Start: 16, End: 16, Text: "\r\n"}, []bool{true, false, true}},
+		{"first line break", "@misc{a} @misc{b} @misc{c}", TextEdit{Start: 13, End: 13, Text: "\r\n"}, []bool{true, false, true}},
+		{"empty file", "", TextEdit{Text: "@misc{a}"}, []bool{false}},
+		{"byte order mark", "\xef\xbb\xbf@misc{a} @misc{b}", TextEdit{Start: 4, End: 8, Text: "book"}, []bool{false, true}},
+		{"edit byte order mark", "\xef\xbb\xbf@misc{a} @misc{b}", TextEdit{Start: 0, End: 3}, []bool{false, false}},
+		{"split rune", "@misc{ä} @misc{b}", TextEdit{Start: 7, End: 8}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := NewBibFileFromString(tt.text)
+			if err != nil {
+				t.Fatalf("NewBibFileFromString() error = %v", err)
+			}
+			old := append([]*BibEntry(nil), file.Entries...)
+
+			wantText := tt.edit.Apply(tt.text)
+			wantFile, wantErr := NewBibFileFromReader(utils.NewRuneReaderFromString(wantText))
+
+			gotFile, gotText, gotErr := Reparse(file, tt.text, tt.edit)
+			if gotText != wantText {
+				t.Errorf("Reparse() text = %q, want %q", gotText, wantText)
+			}
+			if !reflect.DeepEqual(gotErr, wantErr) {
+				t.Errorf("Reparse() error = %v, want %v", gotErr, wantErr)
+			}
+			if !reflect.DeepEqual(gotFile, wantFile) {
+				t.Errorf("Reparse() = %v, want %v", gotFile, wantFile)
+			}
+			if gotErr != nil {
+				return
+			}
+
+			var gotReuse []bool
+			for _, entry := range gotFile.Entries {
+				reused := false
+				for _, o := range old {
+					reused = reused || entry == o
+				}
+				gotReuse = append(gotReuse, reused)
+			}
+			if !reflect.DeepEqual(gotReuse, tt.reuse) {
+				t.Errorf("Reparse() re-used entries = %v, want %v", gotReuse, tt.reuse)
+			}
+		})
+	}
+}
+
+func TestReparse_invalid(t *testing.T) {
+	for _, edit := range []TextEdit{{Start: -1}, {Start: 2, End: 1}, {End: 10}} {
+		if _, _, err := Reparse(&BibFile{}, "@misc{a}", edit); err == nil {
+			t.Errorf("Reparse(%v) error = nil, want non-nil", edit)
+		}
+	}
+}
+
+// TestReparse_all applies a set of edits at every position of a file, and compares the result to reading the file entirely
+func TestReparse_all(t *testing.T) {
+	texts := []string{"@misc{x, a = {b}}\r\n@misc{y, t = \"ö # p\" # q}", complicatedBibFileText}
+	inserts := []string{"x", " ", "}", "{", "\"", "\n", "\r", "@misc{z}", ",\n", "ö"}
+
+	for _, text := range texts {
+		for start := 0; start <= len(text); start++ {
+			var edits []TextEdit
+			for _, insert := range inserts {
+				edits = append(edits, TextEdit{Start: start, End: start, Text: insert})
+			}
+			for end := start + 1; end <= len(text) && end <= start+3; end++ {
+				edits = append(edits, TextEdit{Start: start, End: end}, TextEdit{Start: start, End: end, Text: "y"})
+			}
+
+			for _, edit := range edits {
+				file, err := NewBibFileFromString(text)
+				if err != nil {
+					t.Fatalf("NewBibFileFromString() error = %v", err)
+				}
+
+				wantFile, wantErr := NewBibFileFromReader(utils.NewRuneReaderFromString(edit.Apply(text)))
+				gotFile, _, gotErr := Reparse(file, text, edit)
+				if !reflect.DeepEqual(gotErr, wantErr) || !reflect.DeepEqual(gotFile, wantFile) {
+					t.Fatalf("Reparse(%q, %v) = %v, %v, want %v, %v", text, edit, gotFile, gotErr, wantFile, wantErr)
+				}
+			}
+		}
+	}
+}
+
+// TestReparse_random applies random edits to random files, and compares the result to reading the file entirely
+func TestReparse_random(t *testing.T) {
+	// entries and noise that files are made of
+	entries := []string{
+		"@misc{k, x = {v}}", "@{w,k=v}", "@book(b, t = \"q\" # m)", "@string{m = {s}}", "@comment{c}", "@preamble{\"p\"}",
+		"@misc{ö, x = 😀}", "% comment\n", " ", "\n", "\r\n",
+	}
+	noise := []string{"@", "x", "=", "{", "}", "(", ")", "\"", "#", ",", " ", "\n", "\r", "ö"}
+	inserts := append(append([]string(nil), entries...), noise...)
+	random := rand.New(rand.NewSource(1))
+	text := func(parts []string, n int) string {
+		var builder strings.Builder
+		for i := 0; i < n; i++ {
+			builder.WriteString(parts[random.Intn(len(parts))])
+		}
+		return builder.String()
+	}
+
+	for i := 0; i < 20000; i++ {
+		// start from a valid file, so that it can be edited
+		source := text(entries, random.Intn(10))
+		file, err := NewBibFileFromString(source)
+		if err != nil {
+			continue
+		}
+
+		start := random.Intn(len(source) + 1)
+		end := start + random.Intn(min(len(source)-start, 5)+1)
+		edit := TextEdit{Start: start, End: end, Text: text(inserts, random.Intn(3))}
+
+		wantFile, wantErr := NewBibFileFromString(edit.Apply(source))
+		gotFile, _, gotErr := Reparse(file, source, edit)
+		if !reflect.DeepEqual(gotErr, wantErr) || !reflect.DeepEqual(gotFile, wantFile) {
+			t.Fatalf("Reparse(%q, %v) = %v, %v, want %v, %v", source, edit, gotFile, gotErr, wantFile, wantErr)
+		}
+	}
+}
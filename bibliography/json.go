@@ -0,0 +1,119 @@
+package bibliography
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSONVersion is the version of the JSON representation of BibFile and Data.
+// It is incremented whenever the representation changes incompatibly.
+const JSONVersion = 1
+
+// JSONSchema is the JSON Schema describing the JSON representation of a BibFile
+//
+//go:embed schema/bibfile.schema.json
+var JSONSchema string
+
+// DataJSONSchema is the JSON Schema describing the JSON representation of Data
+//
+//go:embed schema/data.schema.json
+var DataJSONSchema string
+
+// bibFileJSON is BibFile without the MarshalJSON and UnmarshalJSON methods
+type bibFileJSON BibFile
+
+// MarshalJSON encodes this BibFile as JSON, including a "version" of JSONVersion
+func (file BibFile) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Version int `json:"version"`
+		*bibFileJSON
+	}{JSONVersion, (*bibFileJSON)(&file)})
+}
+
+// UnmarshalJSON decodes a BibFile from JSON, as encoded by MarshalJSON.
+// A missing version is treated as version 1, newer versions result in an error.
+func (file *BibFile) UnmarshalJSON(data []byte) error {
+	var decoded struct {
+		Version int `json:"version"`
+		*bibFileJSON
+	}
+	decoded.bibFileJSON = (*bibFileJSON)(&BibFile{})
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if err := checkJSONVersion(decoded.Version); err != nil {
+		return err
+	}
+
+	*file = BibFile(*decoded.bibFileJSON)
+	return nil
+}
+
+// checkJSONVersion checks that version can be decoded
+func checkJSONVersion(version int) error {
+	if version > JSONVersion {
+		return fmt.Errorf("unsupported JSON version %d, expected at most %d", version, JSONVersion)
+	}
+	return nil
+}
+
+// Data is a simplified view of a BibFile, containing only the data of citable entries.
+// Whitespace, comments and source positions are omitted.
+type Data struct {
+	Version int         `json:"version"` // JSONVersion
+	Entries []DataEntry `json:"entries"`
+}
+
+// DataEntry is a simplified view of a BibEntry, see Data
+type DataEntry struct {
+	Kind   string            `json:"kind"`            // kind of the entry, in lower case
+	Label  string            `json:"label,omitempty"` // label of the entry, if any
+	Fields map[string]string `json:"fields"`          // field names in lower case, mapped to their evaluated values
+}
+
+// Data returns a simplified view of this BibFile.
+// Special entries are omitted, and macros are expanded using the '@string' entries of this file.
+// When a field occurs more than once within an entry, the first value is used.
+func (file *BibFile) Data() *Data {
+	macros := file.Macros()
+
+	data := &Data{Version: JSONVersion, Entries: []DataEntry{}}
+	for _, entry := range file.Entries {
+		if entry.IsSpecial() || entry.Kind == nil {
+			continue
+		}
+
+		d := DataEntry{
+			Kind:   strings.ToLower(entry.Kind.Value),
+			Label:  entry.Label(),
+			Fields: make(map[string]string),
+		}
+		for _, field := range entry.Fields {
+			if !field.IsKeyValue() {
+				continue
+			}
+			name := strings.ToLower(field.Name())
+			if _, ok := d.Fields[name]; !ok {
+				d.Fields[name] = field.Evaluate(macros)
+			}
+		}
+		data.Entries = append(data.Entries, d)
+	}
+	return data
+}
+
+// UnmarshalJSON decodes Data from JSON, checking its version like BibFile.UnmarshalJSON
+func (data *Data) UnmarshalJSON(b []byte) error {
+	type dataJSON Data
+	var decoded dataJSON
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	if err := checkJSONVersion(decoded.Version); err != nil {
+		return err
+	}
+	*data = Data(decoded)
+	return nil
+}
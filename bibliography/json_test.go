@@ -0,0 +1,281 @@
+package bibliography
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBibFile_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"complicated.bib", complicatedBibFileText},
+		{"kwarc.bib", kwarcBibFileText},
+		{"empty", ""},
+		{"encoding and line endings", "\xef\xbb\xbf@misc{a,\r\n  title = {ö}\r\n}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := NewBibFileFromString(tt.input)
+			if err != nil {
+				t.Fatalf("NewBibFileFromString() error = %v", err)
+			}
+
+			data, err := json.Marshal(file)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if !strings.HasPrefix(string(data), `{"version":1,`) {
+				t.Errorf("json.Marshal() = %.40s..., want it to start with the version", data)
+			}
+
+			var got BibFile
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(&got, file) {
+				t.Errorf("json.Unmarshal() = %v, want %v", &got, file)
+			}
+		})
+	}
+}
+
+func TestBibFile_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantLabel string
+		wantErr   bool
+	}{
+		{"current version", `{"version":1,"entries":[{"kind":{"value":"misc"},"fields":[{"elements":[{"value":{"value":"a"}}]}]}]}`, "a", false},
+		{"missing version", `{"entries":[{"kind":{"value":"misc"},"fields":[{"elements":[{"value":{"value":"a"}}]}]}]}`, "a", false},
+		{"future version", `{"version":2,"entries":[]}`, "", true},
+		{"invalid json", `{"version":1,`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var file BibFile
+			err := json.Unmarshal([]byte(tt.data), &file)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BibFile.UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := file.Entries[0].Label(); got != tt.wantLabel {
+				t.Errorf("BibFile.UnmarshalJSON() label = %q, want %q", got, tt.wantLabel)
+			}
+		})
+	}
+}
+
+func TestBibFile_Data(t *testing.T) {
+	file, err := NewBibFileFromString(complicatedBibFileText)
+	if err != nil {
+		t.Fatalf("NewBibFileFromString() error = %v", err)
+	}
+
+	data := file.Data()
+	if data.Version != JSONVersion {
+		t.Errorf("BibFile.Data().Version = %d, want %d", data.Version, JSONVersion)
+	}
+
+	want := []DataEntry{
+		{Kind: "article", Label: "MRx05", Fields: map[string]string{
+			"author":    "Bart KiersMr. X",
+			"title":     "Something Great",
+			"publisher": "nobody",
+			"year":      "2005",
+			"x":         "{Bib}\\TeX",
+			"y":         "{Bib}\\TeX",
+			"z":         "{Bib}\\TeX",
+		}},
+		{Kind: "misc", Label: "patashnik-bibtexing", Fields: map[string]string{
+			"author": "Oren Patashnik",
+			"title":  "BIBTEXing",
+			"year":   "1988",
+		}},
+		{Kind: "techreport", Label: "presstudy2002", Fields: map[string]string{
+			"author":      "Dr. Diessen, van R. J. and Drs. Steenbergen, J. F.",
+			"title":       "Long {T}erm {P}reservation {S}tudy of the {DNEP} {P}roject",
+			"institution": "IBM, National Library of the Netherlands",
+			"year":        "2002",
+			"month":       "December",
+		}},
+	}
+	if !reflect.DeepEqual(data.Entries, want) {
+		t.Errorf("BibFile.Data().Entries = %v, want %v", data.Entries, want)
+	}
+
+	// data should round-trip through json
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded Data
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, data) {
+		t.Errorf("json.Unmarshal() = %v, want %v", &decoded, data)
+	}
+	if err := json.Unmarshal([]byte(`{"version":2,"entries":[]}`), &decoded); err == nil {
+		t.Errorf("Data.UnmarshalJSON() error = nil, want non-nil for a future version")
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	file, err := NewBibFileFromString("\xef\xbb\xbf" + complicatedBibFileText)
+	if err != nil {
+		t.Fatalf("NewBibFileFromString() error = %v", err)
+	}
+	crlf, err := NewBibFileFromString("\xef\xbb\xbf@misc{a,\r\n}")
+	if err != nil {
+		t.Fatalf("NewBibFileFromString() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		schema  string
+		value   interface{}
+		wantErr bool
+	}{
+		{"file", JSONSchema, file, false},
+		{"encoding and line endings", JSONSchema, crlf, false},
+		{"empty file", JSONSchema, &BibFile{}, false},
+		{"data", DataJSONSchema, file.Data(), false},
+		{"unknown property", JSONSchema, map[string]interface{}{"entries": nil, "suffix": BibString{}, "source": file.Source, "extra": 1}, true},
+		{"wrong type", DataJSONSchema, map[string]interface{}{"version": 1, "entries": []map[string]int{{"kind": 1}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema, instance interface{}
+			if err := json.Unmarshal([]byte(tt.schema), &schema); err != nil {
+				t.Fatalf("json.Unmarshal() schema error = %v", err)
+			}
+			data, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			json.Unmarshal(data, &instance)
+
+			if err := validate(schema.(map[string]interface{}), schema, instance, "#"); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// validate validates instance against the subset of JSON Schema used by the published schemas
+func validate(root map[string]interface{}, schema interface{}, instance interface{}, path string) error {
+	s := schema.(map[string]interface{})
+
+	if ref, ok := s["$ref"].(string); ok {
+		def := root["$defs"].(map[string]interface{})[strings.TrimPrefix(ref, "#/$defs/")]
+		return validate(root, def, instance, path)
+	}
+	if options, ok := s["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, option := range options {
+			if validate(root, option, instance, path) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fmt.Errorf("%s: matches %d options of oneOf", path, matches)
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, instance) {
+		return fmt.Errorf("%s: %v is not %v", path, instance, c)
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || reflect.DeepEqual(e, instance)
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, instance, enum)
+		}
+	}
+	if typ, ok := s["type"]; ok {
+		types, ok := typ.([]interface{})
+		if !ok {
+			types = []interface{}{typ}
+		}
+		found := false
+		for _, typ := range types {
+			found = found || jsonType(instance, typ.(string))
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not of type %v", path, instance, typ)
+		}
+	}
+	if min, ok := s["minimum"].(float64); ok && instance.(float64) < min {
+		return fmt.Errorf("%s: %v is less than %v", path, instance, min)
+	}
+
+	if items, ok := s["items"]; ok {
+		array, _ := instance.([]interface{})
+		for i, item := range array {
+			if err := validate(root, items, item, fmt.Sprintf("%s/%d", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	object, ok := instance.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	properties, _ := s["properties"].(map[string]interface{})
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, name := range required {
+			if _, ok := object[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing property %q", path, name)
+			}
+		}
+	}
+	for name, value := range object {
+		property, ok := properties[name]
+		if !ok {
+			property, ok = s["additionalProperties"]
+		}
+		if !ok {
+			continue
+		}
+		if allowed, isBool := property.(bool); isBool {
+			if !allowed {
+				return fmt.Errorf("%s: unexpected property %q", path, name)
+			}
+			continue
+		}
+		if err := validate(root, property, value, path+"/"+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonType checks if instance has the given JSON Schema type
+func jsonType(instance interface{}, typ string) bool {
+	switch v := instance.(type) {
+	case nil:
+		return typ == "null"
+	case bool:
+		return typ == "boolean"
+	case float64:
+		return typ == "number" || (typ == "integer" && v == math.Trunc(v))
+	case string:
+		return typ == "string"
+	case []interface{}:
+		return typ == "array"
+	case map[string]interface{}:
+		return typ == "object"
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package bibliography
+
+import (
+	"strings"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// Path is the path of nodes within a BibFile containing a position, see BibFile.Locate.
+// Nodes that do not contain the position are nil.
+type Path struct {
+	Entry   *BibEntry        // entry from '@' to the closing '}'
+	Field   *BibField        // field from its first element to its suffix
+	Element *BibFieldElement // element, including its suffix
+	String  *BibString       // innermost string, which may also be a prefix or suffix
+}
+
+// Locate returns the path of nodes whose source contains the character at pos.
+// Positions are compared by line and column only, see utils.ReaderRange.Contains.
+//
+// When pos is not within any entry, only String is set to the prefix of the following entry or the suffix of the file.
+// Similarly, whitespace before a field is only found as String.
+func (file *BibFile) Locate(pos utils.ReaderPosition) (path Path) {
+	// find sets String to the first candidate containing pos
+	find := func(candidates ...*BibString) bool {
+		for _, bs := range candidates {
+			if hasSource(bs) && bs.Source.Contains(pos) {
+				path.String = bs
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, entry := range file.Entries {
+		if find(&entry.Prefix) {
+			return
+		}
+		if !entry.Source.Contains(pos) {
+			continue
+		}
+		path.Entry = entry
+
+		if find(entry.Kind, entry.KindSuffix) {
+			return
+		}
+		for _, field := range entry.Fields {
+			if find(&field.Prefix) {
+				return
+			}
+			if !field.Source.Contains(pos) {
+				continue
+			}
+			path.Field = field
+
+			for _, element := range field.Elements {
+				if find(element.Value, element.Suffix) {
+					path.Element = element
+					return
+				}
+			}
+			find(&field.Suffix)
+			return
+		}
+		return
+	}
+
+	find(&file.Suffix)
+	return
+}
+
+// hasSource checks if bs is non-nil and was read from at least one character
+func hasSource(bs *BibString) bool {
+	if bs == nil {
+		return false
+	}
+	return bs.Value != "" || bs.Kind == BibStringQuote || bs.Kind == BibStringBracket
+}
+
+// LabelRange returns the source range of the first entry with the given label.
+// Labels are compared case-insensitively, and special entries are ignored.
+// If no such entry exists, returns ok = false.
+func (file *BibFile) LabelRange(label string) (rng utils.ReaderRange, ok bool) {
+	if entry := file.entryWithLabel(label); entry != nil {
+		return entry.Source, true
+	}
+	return
+}
+
+// FieldRange returns the source range of the field with the given key in the first entry with the given label.
+// See LabelRange and BibEntry.GetField.
+// If no such field exists, returns ok = false.
+func (file *BibFile) FieldRange(label, key string) (rng utils.ReaderRange, ok bool) {
+	if field := file.entryWithLabel(label).GetField(key); field != nil {
+		return field.Source, true
+	}
+	return
+}
+
+// entryWithLabel returns the first non-special entry with the given label, or nil
+func (file *BibFile) entryWithLabel(label string) *BibEntry {
+	for _, entry := range file.Entries {
+		if !entry.IsSpecial() && entry.Label() != "" && strings.EqualFold(entry.Label(), label) {
+			return entry
+		}
+	}
+	return nil
+}
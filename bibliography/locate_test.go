@@ -0,0 +1,107 @@
+package bibliography
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+const locateText = "% comment\n@misc{ab, title = \"X\" # y }\n"
+
+func TestBibFile_Locate(t *testing.T) {
+	file, err := NewBibFileFromString(locateText)
+	if err != nil {
+		t.Fatalf("NewBibFileFromString() error = %v", err)
+	}
+	entry := file.Entries[0]
+	label, title := entry.Fields[0], entry.Fields[1]
+	key, value := title.GetKey(), title.GetValue()
+
+	tests := []struct {
+		name string
+		pos  utils.ReaderPosition
+		want Path
+	}{
+		{"comment", utils.ReaderPosition{Line: 0, Column: 2}, Path{String: &entry.Prefix}},
+		{"at sign", utils.ReaderPosition{Line: 1, Column: 0}, Path{Entry: entry}},
+		{"kind", utils.ReaderPosition{Line: 1, Column: 3}, Path{Entry: entry, String: entry.Kind}},
+		{"opening brace", utils.ReaderPosition{Line: 1, Column: 5}, Path{Entry: entry}},
+		{"label", utils.ReaderPosition{Line: 1, Column: 7}, Path{Entry: entry, Field: label, Element: label.Elements[0], String: label.Elements[0].Value}},
+		{"comma", utils.ReaderPosition{Line: 1, Column: 8}, Path{Entry: entry, Field: label, String: &label.Suffix}},
+		{"field prefix", utils.ReaderPosition{Line: 1, Column: 9}, Path{Entry: entry, String: &title.Prefix}},
+		{"key", utils.ReaderPosition{Line: 1, Column: 12}, Path{Entry: entry, Field: title, Element: key, String: key.Value}},
+		{"equals sign", utils.ReaderPosition{Line: 1, Column: 16}, Path{Entry: entry, Field: title, Element: key, String: key.Suffix}},
+		{"quoted value", utils.ReaderPosition{Line: 1, Column: 19}, Path{Entry: entry, Field: title, Element: value[0], String: value[0].Value}},
+		{"concatenation", utils.ReaderPosition{Line: 1, Column: 22}, Path{Entry: entry, Field: title, Element: value[0], String: value[0].Suffix}},
+		{"literal value", utils.ReaderPosition{Line: 1, Column: 24}, Path{Entry: entry, Field: title, Element: value[1], String: value[1].Value}},
+		{"closing brace", utils.ReaderPosition{Line: 1, Column: 26}, Path{Entry: entry, Field: title, String: &title.Suffix}},
+		{"suffix", utils.ReaderPosition{Line: 1, Column: 27}, Path{String: &file.Suffix}},
+		{"end of input", utils.ReaderPosition{Line: 2, Column: 0}, Path{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := file.Locate(tt.pos); got != tt.want {
+				t.Errorf("BibFile.Locate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBibFile_LabelRange(t *testing.T) {
+	file, err := NewBibFileFromString(locateText)
+	if err != nil {
+		t.Fatalf("NewBibFileFromString() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		label   string
+		wantRng utils.ReaderRange
+		wantOk  bool
+	}{
+		{"existing label", "AB", utils.ReaderRange{
+			Start: utils.ReaderPosition{Line: 1, Column: 0, UTF16Column: 0, Offset: 10, RuneOffset: 10},
+			End:   utils.ReaderPosition{Line: 1, Column: 26, UTF16Column: 26, Offset: 36, RuneOffset: 36},
+		}, true},
+		{"missing label", "cd", utils.ReaderRange{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRng, gotOk := file.LabelRange(tt.label)
+			if !reflect.DeepEqual(gotRng, tt.wantRng) || gotOk != tt.wantOk {
+				t.Errorf("BibFile.LabelRange() = %v, %v, want %v, %v", gotRng, gotOk, tt.wantRng, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestBibFile_FieldRange(t *testing.T) {
+	file, err := NewBibFileFromString(locateText)
+	if err != nil {
+		t.Fatalf("NewBibFileFromString() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		label   string
+		key     string
+		wantRng utils.ReaderRange
+		wantOk  bool
+	}{
+		{"existing field", "ab", "TITLE", utils.ReaderRange{
+			Start: utils.ReaderPosition{Line: 1, Column: 10, UTF16Column: 10, Offset: 20, RuneOffset: 20},
+			End:   utils.ReaderPosition{Line: 1, Column: 26, UTF16Column: 26, Offset: 36, RuneOffset: 36},
+		}, true},
+		{"missing field", "ab", "year", utils.ReaderRange{}, false},
+		{"missing label", "cd", "title", utils.ReaderRange{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRng, gotOk := file.FieldRange(tt.label, tt.key)
+			if !reflect.DeepEqual(gotRng, tt.wantRng) || gotOk != tt.wantOk {
+				t.Errorf("BibFile.FieldRange() = %v, %v, want %v, %v", gotRng, gotOk, tt.wantRng, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package bibliography
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Name represents a single name of a person within a BibTeX name list, e.g. in an 'author' field.
+// Each part is kept in its original LaTeX encoding.
+type Name struct {
+	First string `json:"first,omitempty"` // first names, e.g. 'Donald E.'
+	Von   string `json:"von,omitempty"`   // von part, e.g. 'van der'
+	Last  string `json:"last,omitempty"`  // last names, e.g. 'Knuth'
+	Jr    string `json:"jr,omitempty"`    // jr part, e.g. 'Jr.'
+}
+
+// IsOthers checks if this name is the special name 'others' used to abbreviate a name list
+func (name Name) IsOthers() bool {
+	return name.First == "" && name.Von == "" && name.Jr == "" && name.Last == "others"
+}
+
+// String turns this name back into a string of the form 'von Last, Jr, First'
+func (name Name) String() string {
+	s := name.Last
+	if name.Von != "" {
+		s = name.Von + " " + s
+	}
+	if name.Jr != "" {
+		s += ", " + name.Jr
+	}
+	if name.First != "" || name.Jr != "" {
+		s += ", " + name.First
+	}
+	return s
+}
+
+// ParseNames parses a list of names separated by 'and' following the rules of BibTeX.
+// The value should already be evaluated, see BibField.Evaluate.
+//
+// Each name may be given as 'First von Last', 'von Last, First' or 'von Last, Jr, First'.
+// Words are split at spaces and ties ('~') outside of braces, and words starting with a lowercase letter form the von part.
+func ParseNames(value string) (names []Name) {
+	for _, raw := range splitNames(value) {
+		if name, ok := parseName(raw); ok {
+			names = append(names, name)
+		}
+	}
+	return
+}
+
+// splitNames splits value at every 'and' surrounded by spaces outside of braces
+func splitNames(value string) (names []string) {
+	words := splitWords(value, false)
+
+	var current []string
+	for _, w := range words {
+		if strings.EqualFold(w, "and") {
+			names = append(names, strings.Join(current, " "))
+			current = nil
+			continue
+		}
+		current = append(current, w)
+	}
+	return append(names, strings.Join(current, " "))
+}
+
+// parseName parses a single name.
+// Returns ok = false if the name is empty.
+func parseName(raw string) (name Name, ok bool) {
+	// split the name into comma-separated parts
+	var parts [][]string
+	for _, part := range splitCommas(raw) {
+		parts = append(parts, splitWords(part, true))
+	}
+	if len(parts) == 0 || (len(parts) == 1 && len(parts[0]) == 0) {
+		return name, false
+	}
+
+	switch len(parts) {
+	case 1:
+		// First von Last
+		words := parts[0]
+		vonStart, vonEnd := -1, -1
+		for i, w := range words[:len(words)-1] {
+			if isLowerWord(w) {
+				if vonStart == -1 {
+					vonStart = i
+				}
+				vonEnd = i + 1
+			}
+		}
+		if vonStart == -1 {
+			name.First = strings.Join(words[:len(words)-1], " ")
+			name.Last = words[len(words)-1]
+		} else {
+			name.First = strings.Join(words[:vonStart], " ")
+			name.Von = strings.Join(words[vonStart:vonEnd], " ")
+			name.Last = strings.Join(words[vonEnd:], " ")
+		}
+	default:
+		// von Last, [Jr,] First
+		name.Von, name.Last = splitVonLast(parts[0])
+		if len(parts) == 2 {
+			name.First = strings.Join(parts[1], " ")
+		} else {
+			name.Jr = strings.Join(parts[1], " ")
+			name.First = strings.Join(parts[2], " ")
+		}
+	}
+
+	return name, true
+}
+
+// splitVonLast splits words into a von and a last part.
+// The von part consists of all words up to the last lowercase word, but the last part is never empty.
+func splitVonLast(words []string) (von, last string) {
+	if len(words) == 0 {
+		return
+	}
+	vonEnd := 0
+	for i, w := range words[:len(words)-1] {
+		if isLowerWord(w) {
+			vonEnd = i + 1
+		}
+	}
+	return strings.Join(words[:vonEnd], " "), strings.Join(words[vonEnd:], " ")
+}
+
+// splitCommas splits s at commas outside of braces, trimming spaces from each part
+func splitCommas(s string) (parts []string) {
+	level := 0
+	start := 0
+	for i, r := range s {
+		switch r {
+		case '{':
+			level++
+		case '}':
+			if level > 0 {
+				level--
+			}
+		case ',':
+			if level == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// splitWords splits s into words at spaces outside of braces.
+// If ties is true, also splits at '~'.
+func splitWords(s string, ties bool) (words []string) {
+	level := 0
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			words = append(words, current.String())
+			current.Reset()
+		}
+	}
+	for _, r := range s {
+		switch {
+		case r == '{':
+			level++
+		case r == '}':
+			if level > 0 {
+				level--
+			}
+		case level == 0 && (unicode.IsSpace(r) || (ties && r == '~')):
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+	return
+}
+
+// isLowerWord checks if the first letter of w outside of braces is lowercase.
+// Braces starting with a command, such as '{\"u}', count as the letter they contain.
+// Words without any such letter are not lowercase.
+func isLowerWord(w string) bool {
+	runes := []rune(w)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '{' && i+1 < len(runes) && runes[i+1] == '\\':
+			// special character: find the first letter after the command name
+			j := i + 2
+			for j < len(runes) && unicode.IsLetter(runes[j]) {
+				j++
+			}
+			for ; j < len(runes); j++ {
+				if unicode.IsLetter(runes[j]) {
+					return unicode.IsLower(runes[j])
+				}
+			}
+			return false
+		case r == '{':
+			// skip over the group
+			level := 0
+			for ; i < len(runes); i++ {
+				if runes[i] == '{' {
+					level++
+				} else if runes[i] == '}' {
+					level--
+					if level == 0 {
+						break
+					}
+				}
+			}
+		case unicode.IsLetter(r):
+			return unicode.IsLower(r)
+		}
+	}
+	return false
+}
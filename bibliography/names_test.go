@@ -0,0 +1,54 @@
+package bibliography
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []Name
+	}{
+		{"empty", ``, nil},
+		{"last only", `Knuth`, []Name{{Last: "Knuth"}}},
+		{"first last", `Donald E. Knuth`, []Name{{First: "Donald E.", Last: "Knuth"}}},
+		{"first von last", `Ludwig van der Beethoven`, []Name{{First: "Ludwig", Von: "van der", Last: "Beethoven"}}},
+		{"von last comma first", `van der Beethoven, Ludwig`, []Name{{First: "Ludwig", Von: "van der", Last: "Beethoven"}}},
+		{"jr", `King, Jr., Martin Luther`, []Name{{First: "Martin Luther", Last: "King", Jr: "Jr."}}},
+		{"braced last", `{Barnes and Noble, Inc.}`, []Name{{Last: "{Barnes and Noble, Inc.}"}}},
+		{"tie", `Charles~Louis Xavier~Joseph de~la Vall{\'e}e~Poussin`, []Name{{First: "Charles Louis Xavier Joseph", Von: "de la", Last: "Vall{\\'e}e Poussin"}}},
+		{"special lowercase", `Jean {\"u}ber Name`, []Name{{First: "Jean", Von: "{\\\"u}ber", Last: "Name"}}},
+		{
+			"several names",
+			"Thilo Breitsprecher and\n Schr{\\\"{o}}der, Lutz AND others",
+			[]Name{{First: "Thilo", Last: "Breitsprecher"}, {First: "Lutz", Last: "Schr{\\\"{o}}der"}, {Last: "others"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseNames(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseNames() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestName_String(t *testing.T) {
+	tests := []struct {
+		name Name
+		want string
+	}{
+		{Name{Last: "Knuth"}, "Knuth"},
+		{Name{First: "Ludwig", Von: "van", Last: "Beethoven"}, "van Beethoven, Ludwig"},
+		{Name{First: "Martin", Last: "King", Jr: "Jr."}, "King, Jr., Martin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.name.String(); got != tt.want {
+				t.Errorf("Name.String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package bibliography
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// minChunkSize is the minimal number of bytes parsed by a single goroutine
+const minChunkSize = 64 * 1024
+
+// NewBibFileParallel reads a BibFile from data, using up to workers goroutines.
+// If workers <= 0, uses runtime.GOMAXPROCS(0) goroutines.
+//
+// Data is split into chunks between entries, which are parsed concurrently and then joined.
+// The result is identical to reading data using NewBibFileFromReader, including any error.
+// Data that can not be split safely is read sequentially.
+func NewBibFileParallel(data []byte, workers int) (file *BibFile, err error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return newBibFileParallel(data, workers, minChunkSize)
+}
+
+// newBibFileParallel implements NewBibFileParallel, using chunks of at least chunkSize bytes
+func newBibFileParallel(data []byte, workers int, chunkSize int) (file *BibFile, err error) {
+	sequential := func() (*BibFile, error) {
+		return NewBibFileFromReader(utils.NewRuneReaderFromReader(bytes.NewReader(data)))
+	}
+
+	// only utf-8 input can be split
+	bom := 0
+	switch {
+	case bytes.HasPrefix(data, utils.EncodingUTF8.BOM()):
+		bom = len(utils.EncodingUTF8.BOM())
+	case bytes.HasPrefix(data, utils.EncodingUTF16LE.BOM()), bytes.HasPrefix(data, utils.EncodingUTF16BE.BOM()):
+		return sequential()
+	}
+
+	if size := len(data) / workers; size > chunkSize {
+		chunkSize = size
+	}
+	boundaries := findBoundaries(data, bom, chunkSize)
+	if len(boundaries) == 0 {
+		return sequential()
+	}
+
+	// parse all chunks concurrently
+	chunks := make([]chunk, len(boundaries)+1)
+	for i, b := range boundaries {
+		chunks[i+1].start = b
+		chunks[i].end = b.offset
+	}
+	chunks[len(chunks)-1].end = len(data)
+
+	var wg sync.WaitGroup
+	for i := range chunks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			chunks[i].parse(data, i == 0)
+		}(i)
+	}
+	wg.Wait()
+
+	// join the chunks, and fall back to reading sequentially if a chunk did not end exactly at the next boundary
+	file = &BibFile{}
+	ending := ""
+	for i, c := range chunks {
+		last := i == len(chunks)-1
+		if c.err != nil || (!last && c.file.Suffix.Value != "") {
+			return sequential()
+		}
+		file.Entries = append(file.Entries, c.file.Entries...)
+		if ending == "" {
+			ending = c.lineEnding
+		}
+	}
+	if ending != "\n" {
+		file.LineEnding = ending
+	}
+
+	first, last := chunks[0].file, chunks[len(chunks)-1].file
+	file.Suffix = last.Suffix
+	file.Encoding, file.BOM = first.Encoding, first.BOM
+	file.Source.Start = first.Source.Start
+	file.Source.End = file.Source.Start
+	if n := len(file.Entries); n > 0 {
+		file.Source.End = file.Entries[n-1].Source.End
+	}
+	return file, nil
+}
+
+// chunk is a part of an input that is parsed on its own
+type chunk struct {
+	start boundary // where the chunk starts
+	end   int      // byte offset where the chunk ends
+
+	file       *BibFile
+	lineEnding string // first line break within the chunk
+	err        error
+}
+
+// parse parses this chunk of data.
+// The first chunk is read like an entire input, including any byte order mark.
+func (c *chunk) parse(data []byte, first bool) {
+	input := bytes.NewReader(data[c.start.offset:c.end])
+
+	var reader *utils.RuneReader
+	if first {
+		reader = utils.NewRuneReaderFromReader(input)
+	} else {
+		reader = utils.NewRuneReaderAt(input, c.start.position)
+	}
+
+	c.file, c.err = NewBibFileFromReader(reader)
+	c.lineEnding = reader.LineEnding()
+}
+
+// boundary is a position at which an entry may start
+type boundary struct {
+	offset   int                  // byte offset within the input
+	position utils.ReaderPosition // position as reported by a RuneReader
+}
+
+// findBoundaries finds positions within data that are likely to be between two entries, and at least chunkSize bytes apart.
+// Data is scanned starting at the given byte offset.
+//
+// A boundary is directly behind a '}' that closes all open braces, and is followed only by spaces and an '@'.
+// Boundaries are not guaranteed to be between entries, for example because of unbalanced braces in comments.
+func findBoundaries(data []byte, offset int, chunkSize int) (boundaries []boundary) {
+	var position utils.ReaderPosition
+	position.Offset = uint(offset)
+
+	next := offset + chunkSize // offset of the next boundary to look for
+	depth := 0
+	var candidate *boundary // boundary found since the last closing brace
+
+	for i := offset; i < len(data); {
+		r, size := rune(data[i]), 1
+		if r >= utf8.RuneSelf {
+			r, size = utf8.DecodeRune(data[i:])
+		}
+
+		switch {
+		case r == '{':
+			depth++
+			candidate = nil
+		case r == '}':
+			if depth > 0 {
+				depth--
+			}
+			candidate = nil
+		case r == '@' && candidate != nil:
+			boundaries = append(boundaries, *candidate)
+			next = candidate.offset + chunkSize
+			candidate = nil
+		case !unicode.IsSpace(r):
+			candidate = nil
+		}
+
+		// move behind the current rune, handling line breaks like RuneReader
+		position.Column++
+		position.UTF16Column += uint(utils.UTF16Len(r))
+		position.Offset += uint(size)
+		position.RuneOffset++
+		if r == '\n' || r == '\r' {
+			if i+1 < len(data) && (data[i+1] == '\n' || data[i+1] == '\r') && data[i+1] != data[i] {
+				size++
+				position.Offset++
+				position.RuneOffset++
+				r = '\n'
+			}
+			if r == '\n' {
+				position.Line++
+				position.Column = 0
+				position.UTF16Column = 0
+			}
+		}
+		i += size
+
+		if r == '}' && depth == 0 && i >= next {
+			candidate = &boundary{offset: i, position: position}
+		}
+	}
+	return
+}
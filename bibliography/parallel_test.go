@@ -0,0 +1,79 @@
+package bibliography
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+func Test_newBibFileParallel(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantChunks bool // if the input should be split into more than one chunk
+	}{
+		{"complicated.bib", complicatedBibFileText, true},
+		{"kwarc.bib", kwarcBibFileText, true},
+		{"empty", "", false},
+		{"single entry", "@misc{a}", false},
+		{"windows line endings", "@misc{a,\r\n  title = {é}\r\n}\r\n\r\n@misc{b}\r\n @misc{c} ", true},
+		{"mixed line endings", "@misc{a}\n\r@misc{b}\r\n@misc{c}\n", true},
+		{"byte order mark", "\xef\xbb\xbf@misc{a,title={😀}}\n@misc{b}", true},
+		{"utf-16", "\xff\xfe@\x00m\x00i\x00s\x00c\x00{\x00a\x00}\x00\n\x00@\x00m\x00i\x00s\x00c\x00{\x00b\x00}\x00", false},
+		{"entry directly after entry", "@misc{a}@misc{b}", true},
+		{"comments between entries", "@misc{a} this is @misc{b} text \n@misc{c}", false},
+		{"unbalanced brace in comment", "@misc{a}\n} @misc{b}\n{ @misc{c}\n@misc{d}", true},
+		{"at sign within value", "@misc{a, title = {x}}\n@misc{b, title = \"} @misc{c}\"}\n@misc{d}", true},
+		{"error in first chunk", "@misc{a, title = x y}\n@misc{b}", true},
+		{"error in last chunk", "@misc{a}\n@misc{b}\n@misc{c,", true},
+		{"invalid utf-8", "@misc{a}\n@misc{b, title = {caf\xe9}}\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantFile, wantErr := NewBibFileFromReader(utils.NewRuneReaderFromReader(bytes.NewReader([]byte(tt.input))))
+
+			data := []byte(tt.input)
+			if got := len(findBoundaries(data, 0, 1)) > 0; got != tt.wantChunks {
+				t.Errorf("findBoundaries() found boundaries = %v, want %v", got, tt.wantChunks)
+			}
+
+			gotFile, gotErr := newBibFileParallel(data, 4, 1)
+			if !reflect.DeepEqual(gotErr, wantErr) {
+				t.Errorf("newBibFileParallel() error = %v, want %v", gotErr, wantErr)
+			}
+			if !reflect.DeepEqual(gotFile, wantFile) {
+				t.Errorf("newBibFileParallel() = %v, want %v", gotFile, wantFile)
+			}
+		})
+	}
+}
+
+func TestNewBibFileParallel(t *testing.T) {
+	want, err := NewBibFileFromReader(utils.NewRuneReaderFromString(kwarcBibFileText))
+	if err != nil {
+		t.Fatalf("NewBibFileFromReader() error = %v", err)
+	}
+	got, err := NewBibFileParallel([]byte(kwarcBibFileText), 0)
+	if err != nil {
+		t.Fatalf("NewBibFileParallel() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewBibFileParallel() = %v, want %v", got, want)
+	}
+}
+
+func Benchmark_ReadFile_Kwarc_Sequential(b *testing.B) {
+	data := []byte(kwarcBibFileText)
+	for n := 0; n < b.N; n++ {
+		NewBibFileFromReader(utils.NewRuneReaderFromReader(bytes.NewReader(data)))
+	}
+}
+
+func Benchmark_ReadFile_Kwarc_Parallel(b *testing.B) {
+	data := []byte(kwarcBibFileText)
+	for n := 0; n < b.N; n++ {
+		NewBibFileParallel(data, 0)
+	}
+}
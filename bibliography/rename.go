@@ -0,0 +1,206 @@
+package bibliography
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SetLabel sets the label used for citing this BibEntry.
+// If the entry has no label, it is left unchanged and false is returned.
+func (entry *BibEntry) SetLabel(label string) bool {
+	if entry == nil || len(entry.Fields) == 0 {
+		return false
+	}
+
+	elements := entry.Fields[0].Elements
+	if len(elements) == 0 || elements[0].Role != NormalElementRole {
+		return false
+	}
+	elements[0].Value.Value = label
+	return true
+}
+
+// IsValidLabel checks if label can be used as the label of a BibEntry.
+// A valid label is non-empty and contains neither spaces nor any of the characters '{', '}', ',', '=', '#' and '"'.
+func IsValidLabel(label string) bool {
+	if label == "" {
+		return false
+	}
+	for _, r := range label {
+		if unicode.IsSpace(r) || !isNotSpecialLiteral(r) || r == '"' {
+			return false
+		}
+	}
+	return true
+}
+
+// RenameLabels renames the labels of entries within this BibFile.
+// renames maps old labels to new labels; all renames are performed simultaneously.
+// Old labels are compared case-insensitively.
+//
+// Alongside labels, values of 'crossref' fields that refer to a renamed label are updated.
+// Only crossref values consisting of a single quoted or braced string are updated.
+//
+// Returns the number of labels and crossref values changed.
+// If a new label is invalid, would clash with the label of an entry not being renamed, or would be used by more than one entry, returns an error and leaves the file unchanged.
+func (file *BibFile) RenameLabels(renames map[string]string) (count int, err error) {
+	// normalize the old labels
+	normalized := make(map[string]string, len(renames))
+	for old, new := range renames {
+		if !IsValidLabel(new) {
+			return 0, fmt.Errorf("invalid label %q", new)
+		}
+		normalized[strings.ToLower(old)] = new
+	}
+
+	// find the entries to rename
+	labels := make(map[*BibEntry]string)
+	for _, entry := range file.Entries {
+		if entry.IsSpecial() {
+			continue
+		}
+		if new, ok := normalized[strings.ToLower(entry.Label())]; ok {
+			labels[entry] = new
+		}
+	}
+
+	return file.relabel(labels, normalized)
+}
+
+// RenameEntries sets the labels of entries within this BibFile.
+// labels maps entries of this file to their new labels; all entries are relabelled simultaneously.
+// Unlike RenameLabels, this can give different labels to entries that share a label.
+//
+// Crossref values referring to the old label of a relabelled entry are updated as in RenameLabels, unless several entries share that label.
+// Entries without a label are left unchanged, see BibEntry.SetLabel.
+//
+// Returns the number of labels and crossref values changed.
+// If a new label is invalid, would clash with the label of an entry not being relabelled, or would be used by more than one entry, returns an error and leaves the file unchanged.
+func (file *BibFile) RenameEntries(labels map[*BibEntry]string) (count int, err error) {
+	counts := make(map[string]int)
+	for _, entry := range file.Entries {
+		if !entry.IsSpecial() {
+			counts[strings.ToLower(entry.Label())]++
+		}
+	}
+
+	// only labels that refer to a single entry are updated in crossrefs
+	crossrefs := make(map[string]string)
+	for entry, new := range labels {
+		old := strings.ToLower(entry.Label())
+		if old != "" && counts[old] == 1 {
+			crossrefs[old] = new
+		}
+	}
+
+	return file.relabel(labels, crossrefs)
+}
+
+// relabel implements RenameLabels and RenameEntries.
+// labels maps entries to their new labels, and crossrefs maps lower case labels to the new values of crossrefs referring to them.
+func (file *BibFile) relabel(labels map[*BibEntry]string, crossrefs map[string]string) (count int, err error) {
+	// check that new labels are valid and unique
+	added := make(map[string]string)
+	for _, entry := range file.Entries {
+		new, ok := labels[entry]
+		if !ok {
+			continue
+		}
+		if !IsValidLabel(new) {
+			return 0, fmt.Errorf("invalid label %q", new)
+		}
+		if _, ok := added[strings.ToLower(new)]; ok {
+			return 0, fmt.Errorf("label %q would be used more than once", new)
+		}
+		added[strings.ToLower(new)] = new
+	}
+
+	// check that they do not clash with the remaining labels
+	for _, entry := range file.Entries {
+		label := entry.Label()
+		if _, ok := labels[entry]; ok || entry.IsSpecial() || label == "" {
+			continue
+		}
+		if new, ok := added[strings.ToLower(label)]; ok {
+			return 0, fmt.Errorf("label %q would clash with existing label %q", new, label)
+		}
+	}
+
+	// and perform the actual renames
+	for _, entry := range file.Entries {
+		if entry.IsSpecial() {
+			continue
+		}
+		if new, ok := labels[entry]; ok && entry.SetLabel(new) {
+			count++
+		}
+
+		for _, field := range entry.Fields {
+			if !strings.EqualFold(field.Name(), "crossref") {
+				continue
+			}
+			value := field.GetValue()
+			if len(value) != 1 || (value[0].Value.Kind != BibStringQuote && value[0].Value.Kind != BibStringBracket) {
+				continue
+			}
+			if new, ok := crossrefs[strings.ToLower(value[0].Value.Value)]; ok {
+				value[0].Value.Value = new
+				count++
+			}
+		}
+	}
+
+	return
+}
+
+// CheckRenames checks that renames can be applied to each of files using RenameLabels, such as when the files are used together.
+// Every old label has to be the label of an entry within one of files.
+// New labels have to be valid, and may clash neither with labels that are not renamed in any of files nor with each other.
+// Old labels are compared case-insensitively.
+func CheckRenames(files []*BibFile, renames map[string]string) error {
+	olds := make([]string, 0, len(renames))
+	normalized := make(map[string]string, len(renames))
+	for old, new := range renames {
+		if !IsValidLabel(new) {
+			return fmt.Errorf("invalid label %q", new)
+		}
+		olds = append(olds, old)
+		normalized[strings.ToLower(old)] = new
+	}
+	sort.Strings(olds)
+
+	// collect the labels that are renamed, and those that are not
+	renamed := make(map[string]struct{})
+	remaining := make(map[string]string)
+	for _, file := range files {
+		for _, entry := range file.Entries {
+			label := entry.Label()
+			if entry.IsSpecial() || label == "" {
+				continue
+			}
+			if _, ok := normalized[strings.ToLower(label)]; ok {
+				renamed[strings.ToLower(label)] = struct{}{}
+				continue
+			}
+			remaining[strings.ToLower(label)] = label
+		}
+	}
+
+	added := make(map[string]string)
+	for _, old := range olds {
+		new := renames[old]
+		if _, ok := renamed[strings.ToLower(old)]; !ok {
+			return fmt.Errorf("label %q not found", old)
+		}
+		if other, ok := remaining[strings.ToLower(new)]; ok {
+			return fmt.Errorf("label %q would clash with existing label %q", new, other)
+		}
+		if other, ok := added[strings.ToLower(new)]; ok {
+			return fmt.Errorf("label %q would be used for both %q and %q", new, other, old)
+		}
+		added[strings.ToLower(new)] = old
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package bibliography
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+func TestBibFile_RenameLabels(t *testing.T) {
+	const input = "@book{parent, title = {T}}\n@inproceedings{child, crossref = {Parent}}\n@string{parent = {P}}\n@misc{other, note = parent}\n"
+	tests := []struct {
+		name      string
+		renames   map[string]string
+		want      string
+		wantCount int
+		wantErr   bool
+	}{
+		{"nothing", map[string]string{}, input, 0, false},
+		{"rename with crossref", map[string]string{"parent": "mother"}, "@book{mother, title = {T}}\n@inproceedings{child, crossref = {mother}}\n@string{parent = {P}}\n@misc{other, note = parent}\n", 2, false},
+		{"swap", map[string]string{"child": "other", "other": "child"}, "@book{parent, title = {T}}\n@inproceedings{other, crossref = {Parent}}\n@string{parent = {P}}\n@misc{child, note = parent}\n", 2, false},
+		{"clash", map[string]string{"child": "OTHER"}, input, 0, true},
+		{"invalid label", map[string]string{"child": "with space"}, input, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := NewBibFileFromReader(utils.NewRuneReaderFromString(input))
+			if err != nil {
+				panic(err)
+			}
+
+			gotCount, err := file.RenameLabels(tt.renames)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BibFile.RenameLabels() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotCount != tt.wantCount {
+				t.Errorf("BibFile.RenameLabels() = %v, want %v", gotCount, tt.wantCount)
+			}
+
+			var buffer bytes.Buffer
+			file.Write(&buffer)
+			if got := buffer.String(); got != tt.want {
+				t.Errorf("BibFile.RenameLabels() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBibFile_RenameLabels_duplicates(t *testing.T) {
+	const input = "@misc{a, note = {1}}\n@misc{A, note = {2}}\n@misc{b, crossref = {a}}\n"
+	tests := []struct {
+		name    string
+		renames map[string]string
+		want    string
+		wantErr bool
+	}{
+		{"rename other entry", map[string]string{"b": "c"}, "@misc{a, note = {1}}\n@misc{A, note = {2}}\n@misc{c, crossref = {a}}\n", false},
+		{"clash with duplicate", map[string]string{"b": "a"}, input, true},
+		{"rename duplicate", map[string]string{"a": "x"}, input, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := NewBibFileFromReader(utils.NewRuneReaderFromString(input))
+			if err != nil {
+				panic(err)
+			}
+
+			if _, err := file.RenameLabels(tt.renames); (err != nil) != tt.wantErr {
+				t.Errorf("BibFile.RenameLabels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var buffer bytes.Buffer
+			file.Write(&buffer)
+			if got := buffer.String(); got != tt.want {
+				t.Errorf("BibFile.RenameLabels() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBibFile_RenameEntries(t *testing.T) {
+	const input = "@misc{a}\n@misc{A}\n@misc{b, crossref = {a}}\n@misc{c, crossref = {d}}\n@misc{d}\n"
+	tests := []struct {
+		name      string
+		labels    map[int]string // new labels by index of entry
+		want      string
+		wantCount int
+		wantErr   bool
+	}{
+		{"disambiguate", map[int]string{0: "a1", 1: "a2"}, "@misc{a1}\n@misc{a2}\n@misc{b, crossref = {a}}\n@misc{c, crossref = {d}}\n@misc{d}\n", 2, false},
+		{"with crossref", map[int]string{4: "e"}, "@misc{a}\n@misc{A}\n@misc{b, crossref = {a}}\n@misc{c, crossref = {e}}\n@misc{e}\n", 2, false},
+		{"vacate shared label", map[int]string{0: "x", 1: "y", 2: "a"}, "@misc{x}\n@misc{y}\n@misc{a, crossref = {a}}\n@misc{c, crossref = {d}}\n@misc{d}\n", 3, false},
+		{"clash", map[int]string{0: "x", 1: "D"}, input, 0, true},
+		{"used twice", map[int]string{0: "x", 1: "X"}, input, 0, true},
+		{"invalid label", map[int]string{0: "x,y"}, input, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := NewBibFileFromString(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			labels := make(map[*BibEntry]string, len(tt.labels))
+			for i, label := range tt.labels {
+				labels[file.Entries[i]] = label
+			}
+
+			gotCount, err := file.RenameEntries(labels)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BibFile.RenameEntries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotCount != tt.wantCount {
+				t.Errorf("BibFile.RenameEntries() = %v, want %v", gotCount, tt.wantCount)
+			}
+
+			var buffer bytes.Buffer
+			file.Write(&buffer)
+			if got := buffer.String(); got != tt.want {
+				t.Errorf("BibFile.RenameEntries() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRenames(t *testing.T) {
+	var files []*BibFile
+	for _, source := range []string{"@book{a, title = {A}}\n@misc{b, crossref = {a}}", "@misc{c}\n@string{s = {x}}"} {
+		file, err := NewBibFileFromString(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+
+	tests := []struct {
+		name    string
+		renames map[string]string
+		wantErr bool
+	}{
+		{"nothing", map[string]string{}, false},
+		{"rename in several files", map[string]string{"A": "x", "c": "y"}, false},
+		{"swap across files", map[string]string{"a": "c", "c": "a"}, false},
+		{"clash with other file", map[string]string{"a": "C"}, true},
+		{"clash with each other", map[string]string{"a": "x", "c": "X"}, true},
+		{"missing label", map[string]string{"s": "x"}, true},
+		{"invalid label", map[string]string{"a": "x y"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckRenames(files, tt.renames); (err != nil) != tt.wantErr {
+				t.Errorf("CheckRenames() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,536 @@
+package bibliography
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// NewBibFileFromBytes reads a BibFile from data, see NewBibFileFromString.
+// Data is copied once, values refer to the copy.
+func NewBibFileFromBytes(data []byte) (file *BibFile, err error) {
+	return NewBibFileFromString(string(data))
+}
+
+// NewBibFileFromString reads a BibFile from source.
+//
+// The result is identical to reading source using NewBibFileFromReader, including any error.
+// Unlike NewBibFileFromReader, values of BibStrings are sliced from source instead of being copied into new strings.
+// Only values containing '\r' characters, which have to be normalized, are copied.
+//
+// Source that is not UTF-8, or that can not be read, is read using NewBibFileFromReader.
+func NewBibFileFromString(source string) (file *BibFile, err error) {
+	file, err = newSliceParser(source).readFile()
+	if err == errSliceFallback {
+		return NewBibFileFromReader(utils.NewRuneReaderFromString(source))
+	}
+	return
+}
+
+// errSliceFallback indicates that the input should be read using a RuneReader instead.
+// It is returned by sliceParser when the input can not be read, so that the exact error can be produced.
+var errSliceFallback = errors.New("input can not be read by sliceParser")
+
+// sliceParser reads a BibFile from a string, slicing values from it.
+// It mirrors the readXXX methods operating on a utils.RuneReader, and has to be kept in sync with them.
+type sliceParser struct {
+	src string
+
+	i   int                  // byte offset of the next rune within src
+	pos utils.ReaderPosition // position of the next rune
+
+	bom        bool   // did src start with a byte order mark?
+	lineEnding string // first line break read, see utils.RuneReader.LineEnding
+
+	// slabs that new objects are allocated from
+	strings  []BibString
+	elements []BibFieldElement
+	fields   []BibField
+	entries  []BibEntry
+}
+
+// slabSize is the number of objects allocated at once by sliceParser
+const slabSize = 256
+
+// newSliceParser creates a new sliceParser for src
+func newSliceParser(src string) *sliceParser {
+	parser := &sliceParser{src: src}
+	if bom := string(utils.EncodingUTF8.BOM()); strings.HasPrefix(src, bom) {
+		parser.bom = true
+		parser.i = len(bom)
+		parser.pos.Offset = uint(len(bom))
+	}
+	return parser
+}
+
+// newString allocates a new BibString
+func (p *sliceParser) newString() *BibString {
+	if len(p.strings) == 0 {
+		p.strings = make([]BibString, slabSize)
+	}
+	bs := &p.strings[0]
+	p.strings = p.strings[1:]
+	return bs
+}
+
+// newElement allocates a new BibFieldElement
+func (p *sliceParser) newElement() *BibFieldElement {
+	if len(p.elements) == 0 {
+		p.elements = make([]BibFieldElement, slabSize)
+	}
+	element := &p.elements[0]
+	p.elements = p.elements[1:]
+	return element
+}
+
+// newField allocates a new BibField
+func (p *sliceParser) newField() *BibField {
+	if len(p.fields) == 0 {
+		p.fields = make([]BibField, slabSize)
+	}
+	field := &p.fields[0]
+	p.fields = p.fields[1:]
+	return field
+}
+
+// newEntry allocates a new BibEntry
+func (p *sliceParser) newEntry() *BibEntry {
+	if len(p.entries) == 0 {
+		p.entries = make([]BibEntry, slabSize)
+	}
+	entry := &p.entries[0]
+	p.entries = p.entries[1:]
+	return entry
+}
+
+// read reads the next rune, treating '\r\n' and '\n\r' as a single '\n' like utils.RuneReader.
+// Returns the position of the rune, and ok = false at the end of the input.
+func (p *sliceParser) read() (r rune, pos utils.ReaderPosition, ok bool) {
+	pos = p.pos
+	if p.i >= len(p.src) {
+		pos.EOF = true
+		return 0, pos, false
+	}
+
+	r, size := rune(p.src[p.i]), 1
+	if r >= utf8.RuneSelf {
+		r, size = utf8.DecodeRuneInString(p.src[p.i:])
+	}
+	p.advance(r, size)
+
+	if r == '\n' || r == '\r' {
+		ending := "\n"
+		if p.i < len(p.src) {
+			if next := p.src[p.i]; (next == '\n' || next == '\r') && rune(next) != r {
+				ending = p.src[p.i-1 : p.i+1]
+				p.advance(rune(next), 1)
+				r = '\n'
+			}
+		}
+		if r == '\n' {
+			if p.lineEnding == "" {
+				p.lineEnding = ending
+			}
+			p.pos.Line++
+			p.pos.Column = 0
+			p.pos.UTF16Column = 0
+		}
+	}
+	return r, pos, true
+}
+
+// advance moves behind a rune of the given size
+func (p *sliceParser) advance(r rune, size int) {
+	p.i += size
+	p.pos.Column++
+	p.pos.UTF16Column += uint(utils.UTF16Len(r))
+	p.pos.Offset += uint(size)
+	p.pos.RuneOffset++
+}
+
+// peek returns the next rune and its position, without reading it
+func (p *sliceParser) peek() (r rune, pos utils.ReaderPosition, ok bool) {
+	i, position, ending := p.i, p.pos, p.lineEnding
+	r, pos, ok = p.read()
+	p.i, p.pos, p.lineEnding = i, position, ending
+	return
+}
+
+// readWhile reads runes as long as f returns true, see utils.RuneReader.ReadWhile.
+// Returns the byte offsets of the read runes.
+func (p *sliceParser) readWhile(f func(r rune) bool) (start, end int, loc utils.ReaderRange) {
+	start = p.i
+	loc.Start = p.pos
+	loc.End = p.pos
+	for {
+		i, position := p.i, p.pos
+		r, pos, ok := p.read()
+		if !ok {
+			loc.End = pos
+			return start, p.i, loc
+		}
+		if !f(r) {
+			p.i, p.pos = i, position
+			return start, p.i, loc
+		}
+		loc.End = pos
+	}
+}
+
+// readPrefix reads the prefix of an entry, up to an '@' preceded by a space or the beginning of the prefix.
+// See readWhile and BibEntry.readEntry.
+func (p *sliceParser) readPrefix() (start, end int, loc utils.ReaderRange) {
+	start = p.i
+	loc.Start = p.pos
+	loc.End = p.pos
+
+	hasPrevSpace := true
+	for {
+		i, position := p.i, p.pos
+		r, pos, ok := p.read()
+		if !ok {
+			loc.End = pos
+			return start, p.i, loc
+		}
+		if hasPrevSpace && r == '@' {
+			p.i, p.pos = i, position
+			return start, p.i, loc
+		}
+		hasPrevSpace = unicode.IsSpace(r)
+		loc.End = pos
+	}
+}
+
+// slice returns the source between the given byte offsets, with line breaks normalized to '\n'
+func (p *sliceParser) slice(start, end int) string {
+	s := p.src[start:end]
+	if strings.IndexByte(s, '\r') < 0 {
+		return s
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c == '\n' || c == '\r') && i+1 < len(s) && (s[i+1] == '\n' || s[i+1] == '\r') && s[i+1] != c {
+			c = '\n'
+			i++
+		}
+		builder.WriteByte(c)
+	}
+	return builder.String()
+}
+
+// readFile reads a BibFile, see BibFile.readFile
+func (p *sliceParser) readFile() (file *BibFile, err error) {
+	// invalid input, including utf-16, produces an error
+	if !utf8.ValidString(p.src[p.i:]) {
+		return nil, errSliceFallback
+	}
+
+	file = &BibFile{}
+	file.Source.Start = p.pos
+	file.Source.End = file.Source.Start
+
+	for {
+		entry := p.newEntry()
+		err = p.readEntry(entry)
+		if err == io.EOF {
+			file.Suffix = entry.Prefix
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		file.Entries = append(file.Entries, entry)
+		file.Source.End = entry.Source.End
+	}
+
+	if p.lineEnding != "\n" {
+		file.LineEnding = p.lineEnding
+	}
+	if p.bom {
+		file.Encoding, file.BOM = utils.EncodingUTF8, true
+	}
+	return file, nil
+}
+
+// readEntry reads a BibEntry, see BibEntry.readEntry
+func (p *sliceParser) readEntry(entry *BibEntry) (err error) {
+	start, end, loc := p.readPrefix()
+	entry.Prefix.Value, entry.Prefix.Source = p.slice(start, end), loc
+
+	char, pos, ok := p.read()
+	if !ok {
+		return io.EOF
+	}
+	if char != '@' {
+		return errSliceFallback
+	}
+	entry.Source.Start = pos
+
+	entry.Kind = p.newString()
+	if entry.KindSuffix, _, err = p.readLiteral(entry.Kind); err != nil {
+		return
+	}
+
+	char, _, ok = p.read()
+	if !ok || char != '{' {
+		return errSliceFallback
+	}
+
+	for {
+		field := p.newField()
+		if err = p.readField(field); err != nil {
+			return
+		}
+		entry.Fields = append(entry.Fields, field)
+
+		if field.Suffix.Value == "}" {
+			break
+		}
+	}
+
+	entry.Source.End = entry.Fields[len(entry.Fields)-1].Suffix.Source.End
+	return
+}
+
+// readField reads a BibField, see BibField.readField
+func (p *sliceParser) readField(field *BibField) (err error) {
+	start, end, loc := p.readWhile(unicode.IsSpace)
+	field.Prefix.Value, field.Prefix.Source = p.slice(start, end), loc
+
+	r, pos, ok := p.peek()
+	if !ok {
+		return errSliceFallback
+	}
+	field.Source.Start = pos
+
+	hadEqualSign := false
+	shouldAppendSuffix := false
+
+	mayStringNext := true
+	mayConcatNext := false
+	mayEqualNext := false
+
+	// the suffix of the last element spans the bytes from suffixStart to suffixEnd
+	var last *BibFieldElement
+	var suffixStart, suffixEnd int
+
+	for r != ',' && r != '}' {
+		switch r {
+		case '=', '#':
+			if (r == '=' && !mayEqualNext) || (r == '#' && !mayConcatNext) {
+				return errSliceFallback
+			}
+			p.read()
+
+			if r == '=' {
+				hadEqualSign = true
+				last.Role = KeyElementRole
+			} else {
+				last.Role = TermElementRole
+			}
+
+			suffixEnd = p.i
+			last.Suffix.Source.End = pos
+			shouldAppendSuffix = true
+
+			mayStringNext = true
+			mayConcatNext = false
+			mayEqualNext = false
+		case '"', '{':
+			if !mayStringNext {
+				return errSliceFallback
+			}
+
+			if last != nil {
+				last.Suffix.Value = p.slice(suffixStart, suffixEnd)
+			}
+			last = p.newElement()
+			last.Value = p.newString()
+			last.Suffix = p.newString()
+			field.Elements = append(field.Elements, last)
+
+			if r == '"' {
+				err = p.readQuote(last.Value)
+				mayEqualNext = false
+			} else {
+				err = p.readBrace(last.Value)
+				mayEqualNext = !hadEqualSign
+			}
+			if err != nil {
+				return
+			}
+			shouldAppendSuffix = false
+
+			mayStringNext = false
+			mayConcatNext = r == '"'
+		default:
+			if !mayStringNext {
+				return errSliceFallback
+			}
+
+			if last != nil {
+				last.Suffix.Value = p.slice(suffixStart, suffixEnd)
+			}
+			last = p.newElement()
+			last.Value = p.newString()
+			field.Elements = append(field.Elements, last)
+
+			if last.Suffix, suffixStart, err = p.readLiteral(last.Value); err != nil {
+				return
+			}
+			suffixEnd = p.i
+			shouldAppendSuffix = true
+
+			mayStringNext = false
+			mayConcatNext = true
+			mayEqualNext = !hadEqualSign
+		}
+
+		start, end, loc := p.readWhile(unicode.IsSpace)
+		if shouldAppendSuffix {
+			if start != end {
+				suffixEnd = end
+				last.Suffix.Source.End = loc.End
+			}
+		} else {
+			suffixStart, suffixEnd = start, end
+			last.Suffix.Source = loc
+		}
+
+		r, pos, ok = p.peek()
+		if !ok {
+			return errSliceFallback
+		}
+	}
+	if last != nil {
+		last.Suffix.Value = p.slice(suffixStart, suffixEnd)
+	}
+
+	field.Source.End = pos
+
+	p.read()
+	field.Suffix.Value = p.src[p.i-1 : p.i]
+	field.Suffix.Source.Start = pos
+	field.Suffix.Source.End = pos
+	return
+}
+
+// readLiteral reads a literal into bs, and returns the spaces following it, see BibString.readLiteral.
+// The spaces start at the byte offset spaceStart, and end at the current offset.
+func (p *sliceParser) readLiteral(bs *BibString) (space *BibString, spaceStart int, err error) {
+	start := p.i
+	i, position := p.i, p.pos // state before the last read character
+	char, pos, ok := p.read()
+	if !ok {
+		return nil, 0, errSliceFallback
+	}
+	bs.Source.Start = pos
+	bs.Source.End = pos
+
+	end := start
+	var litSource, spaceSource utils.ReaderRange
+	spaceStart = start
+	for isNotSpecialLiteral(char) {
+		var litStart int
+		litStart, end, litSource = p.readWhile(isNotSpecialSpaceLiteral)
+		if litStart == end {
+			litSource.End = pos
+		}
+
+		spaceStart, _, spaceSource = p.readWhile(unicode.IsSpace)
+
+		i, position = p.i, p.pos
+		char, pos, ok = p.read()
+		if !ok {
+			return nil, 0, errSliceFallback
+		}
+	}
+
+	// unread the last character
+	p.i, p.pos = i, position
+
+	bs.Kind = BibStringLiteral
+	bs.Value = p.slice(start, end)
+	bs.Source.End = litSource.End
+
+	space = p.newString()
+	space.Kind = BibStringOther
+	space.Value = p.slice(spaceStart, p.i)
+	space.Source = spaceSource
+	return
+}
+
+// readBrace reads a braced string into bs, see BibString.readBrace
+func (p *sliceParser) readBrace(bs *BibString) error {
+	char, pos, ok := p.read()
+	if !ok || char != '{' {
+		return errSliceFallback
+	}
+	bs.Source.Start = pos
+
+	start, end := p.i, p.i
+	level := 1
+	for {
+		end = p.i
+		char, pos, ok = p.read()
+		if !ok {
+			return errSliceFallback
+		}
+
+		if char == '{' {
+			level++
+		} else if char == '}' {
+			level--
+		}
+		if level == 0 {
+			break
+		}
+	}
+
+	bs.Kind = BibStringBracket
+	bs.Value = p.slice(start, end)
+	bs.Source.End = pos
+	return nil
+}
+
+// readQuote reads a quoted string into bs, see BibString.readQuote
+func (p *sliceParser) readQuote(bs *BibString) error {
+	char, pos, ok := p.read()
+	if !ok || char != '"' {
+		return errSliceFallback
+	}
+	bs.Source.Start = pos
+
+	start, end := p.i, p.i
+	level := 0
+	for {
+		end = p.i
+		char, pos, ok = p.read()
+		if !ok {
+			return errSliceFallback
+		}
+
+		if char == '"' {
+			if level == 0 {
+				break
+			}
+		} else if char == '{' {
+			level++
+		} else if char == '}' && level > 0 {
+			level--
+		}
+	}
+
+	bs.Kind = BibStringQuote
+	bs.Value = p.slice(start, end)
+	bs.Source.End = pos
+	return nil
+}
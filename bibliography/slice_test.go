@@ -0,0 +1,107 @@
+package bibliography
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+func TestNewBibFileFromString(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"complicated.bib", complicatedBibFileText},
+		{"kwarc.bib", kwarcBibFileText},
+		{"empty", ""},
+		{"only text", "hello world"},
+		{"empty entry", "@misc{}"},
+		{"empty kind", "@{a}"},
+		{"empty fields", "@misc{a,,, }"},
+		{"spaces everywhere", " \t@ misc \n { a b , title\n=\n{x} # \"y\" #  z  ,\n}  \n"},
+		{"literal key", "@misc{a, title = foo bar # baz}"},
+		{"braced key", "@misc{a, {title} = {x}, {y}}"},
+		{"nested braces", "@misc{a, title = {a {b {c}} d}, note = \"a {\"} b\"}"},
+		{"unbalanced quote brace", "@misc{a, note = \"a } b\"}"},
+		{"multi-byte runes", "@misc{ä😀, title = {café 😀}}\n@misc{b}"},
+		{"windows line endings", "@misc{a,\r\n  title = {a\r\nb},\r\n  note = x\r\n  # y\r\n}\r\n"},
+		{"mixed line endings", "@misc{a,\n\r title = {a\r\n\rb\r}}\r"},
+		{"byte order mark", "\xef\xbb\xbf@misc{a, title = {x}}"},
+		{"utf-16", "\xff\xfe@\x00m\x00i\x00s\x00c\x00{\x00a\x00}\x00"},
+		{"invalid utf-8", "@misc{a, title = {caf\xe9}}"},
+		{"comments", "text @ not an entry\n@comment{x} more text@misc{a}"},
+		{"missing brace", "@misc a}"},
+		{"unexpected equals", "@misc{a = = b}"},
+		{"unexpected concat", "@misc{a, b = # c}"},
+		{"unterminated brace", "@misc{a, title = {x"},
+		{"unterminated quote", "@misc{a, title = \"x"},
+		{"unterminated entry", "@misc{a, title = x"},
+		{"end after kind", "@misc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantFile, wantErr := NewBibFileFromReader(utils.NewRuneReaderFromString(tt.input))
+
+			gotFile, gotErr := NewBibFileFromString(tt.input)
+			if !reflect.DeepEqual(gotErr, wantErr) {
+				t.Errorf("NewBibFileFromString() error = %v, want %v", gotErr, wantErr)
+			}
+			if !reflect.DeepEqual(gotFile, wantFile) {
+				t.Errorf("NewBibFileFromString() = %v, want %v", gotFile, wantFile)
+			}
+		})
+	}
+}
+
+func TestNewBibFileFromBytes(t *testing.T) {
+	want, err := NewBibFileFromReader(utils.NewRuneReaderFromString(complicatedBibFileText))
+	if err != nil {
+		t.Fatalf("NewBibFileFromReader() error = %v", err)
+	}
+	got, err := NewBibFileFromBytes([]byte(complicatedBibFileText))
+	if err != nil {
+		t.Fatalf("NewBibFileFromBytes() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewBibFileFromBytes() = %v, want %v", got, want)
+	}
+}
+
+// benchmarkCorpus benchmarks read on all files in testdata/bibfile_read
+func benchmarkCorpus(b *testing.B, read func(data []byte) (*BibFile, error)) {
+	names, err := filepath.Glob(filepath.Join("testdata", "bibfile_read", "*.bib"))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	var corpus [][]byte
+	var size int64
+	for _, name := range names {
+		data := []byte(utils.ReadFileOrPanic(name))
+		corpus = append(corpus, data)
+		size += int64(len(data))
+	}
+
+	b.SetBytes(size)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, data := range corpus {
+			if _, err := read(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func Benchmark_ReadCorpus_Reader(b *testing.B) {
+	benchmarkCorpus(b, func(data []byte) (*BibFile, error) {
+		return NewBibFileFromReader(utils.NewRuneReaderFromString(string(data)))
+	})
+}
+
+func Benchmark_ReadCorpus_Bytes(b *testing.B) {
+	benchmarkCorpus(b, NewBibFileFromBytes)
+}
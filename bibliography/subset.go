@@ -0,0 +1,118 @@
+package bibliography
+
+import (
+	"strings"
+)
+
+// SubsetAll is a label that selects all citable entries when passed to Subset.
+// It corresponds to '\nocite{*}' in LaTeX.
+const SubsetAll = "*"
+
+// Subset returns a new BibFile containing only the entries with the given labels.
+// Labels are compared case-insensitively, and SubsetAll selects every entry.
+//
+// Alongside the requested entries, the subset contains all entries referenced via 'crossref', all '@string' entries defining macros used by any included entry, and all '@preamble' entries.
+// Entries are returned in their original order, and are shared with file.
+//
+// missing contains those requested labels that could not be found within file.
+func (file *BibFile) Subset(labels []string) (subset *BibFile, missing []string) {
+	macros := file.Macros()
+
+	// index the citable entries and macro definitions
+	byLabel := make(map[string]*BibEntry)
+	byMacro := make(map[string]*BibEntry)
+	for _, entry := range file.Entries {
+		switch {
+		case entry.IsKind("string"):
+			for _, field := range entry.Fields {
+				if name := field.Name(); name != "" {
+					byMacro[strings.ToLower(name)] = entry
+				}
+			}
+		case !entry.IsSpecial():
+			label := strings.ToLower(entry.Label())
+			if _, ok := byLabel[label]; !ok {
+				byLabel[label] = entry
+			}
+		}
+	}
+
+	included := make(map[*BibEntry]struct{})
+	var queue []*BibEntry
+
+	// include adds entry to the set of included entries
+	include := func(entry *BibEntry) {
+		if _, ok := included[entry]; ok {
+			return
+		}
+		included[entry] = struct{}{}
+		queue = append(queue, entry)
+	}
+
+	// include all the requested labels
+	for _, label := range labels {
+		if label == SubsetAll {
+			for _, entry := range file.Entries {
+				if !entry.IsSpecial() {
+					include(entry)
+				}
+			}
+			continue
+		}
+
+		entry, ok := byLabel[strings.ToLower(label)]
+		if !ok {
+			missing = append(missing, label)
+			continue
+		}
+		include(entry)
+	}
+
+	// include all the preambles
+	for _, entry := range file.Entries {
+		if entry.IsKind("preamble") {
+			include(entry)
+		}
+	}
+
+	// resolve dependencies until there are no more
+	for len(queue) > 0 {
+		var entry *BibEntry
+		entry, queue = queue[0], queue[1:]
+
+		for _, field := range entry.Fields {
+			// the label of a regular entry is not a macro
+			if !field.IsKeyValue() && !entry.IsKind("preamble") {
+				continue
+			}
+
+			for _, name := range field.References() {
+				if def, ok := byMacro[name]; ok {
+					include(def)
+				}
+			}
+
+			if entry.IsSpecial() || !strings.EqualFold(field.Name(), "crossref") {
+				continue
+			}
+			if parent, ok := byLabel[strings.ToLower(field.Evaluate(macros))]; ok {
+				include(parent)
+			}
+		}
+	}
+
+	// build the subset in the original order
+	subset = &BibFile{
+		Suffix:     file.Suffix,
+		Source:     file.Source,
+		LineEnding: file.LineEnding,
+		Encoding:   file.Encoding,
+		BOM:        file.BOM,
+	}
+	for _, entry := range file.Entries {
+		if _, ok := included[entry]; ok {
+			subset.Entries = append(subset.Entries, entry)
+		}
+	}
+	return
+}
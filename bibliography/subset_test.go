@@ -0,0 +1,63 @@
+package bibliography
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+const subsetTestText = `@preamble{"\newcommand{\noop}[1]{}"}
+@string{me = "Bart Kiers"}
+@string{nob = "nob" # ody}
+@string{ody = "ody"}
+@comment{nothing to see here}
+@book{parent, editor = me, title = {Proceedings}}
+@inproceedings{child, author = nob, crossref = {parent}}
+@misc{other, author = "Someone", month = jan}
+`
+
+func TestBibFile_Subset(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      []string
+		wantLabels  []string
+		wantMissing []string
+	}{
+		{"nothing", nil, []string{"preamble"}, nil},
+		{"simple entry", []string{"other"}, []string{"preamble", "other"}, nil},
+		{"with macro", []string{"parent"}, []string{"preamble", "string:me", "parent"}, nil},
+		{"with crossref", []string{"CHILD"}, []string{"preamble", "string:me", "string:nob", "string:ody", "parent", "child"}, nil},
+		{"everything", []string{SubsetAll}, []string{"preamble", "string:me", "string:nob", "string:ody", "parent", "child", "other"}, nil},
+		{"missing", []string{"other", "unknown"}, []string{"preamble", "other"}, []string{"unknown"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := NewBibFileFromReader(utils.NewRuneReaderFromString(subsetTestText))
+			if err != nil {
+				panic(err)
+			}
+
+			gotSubset, gotMissing := file.Subset(tt.labels)
+
+			var gotLabels []string
+			for _, entry := range gotSubset.Entries {
+				switch {
+				case entry.IsKind("preamble"):
+					gotLabels = append(gotLabels, "preamble")
+				case entry.IsKind("string"):
+					gotLabels = append(gotLabels, "string:"+entry.Fields[0].Name())
+				default:
+					gotLabels = append(gotLabels, entry.Label())
+				}
+			}
+
+			if !reflect.DeepEqual(gotLabels, tt.wantLabels) {
+				t.Errorf("BibFile.Subset() labels = %v, want %v", gotLabels, tt.wantLabels)
+			}
+			if !reflect.DeepEqual(gotMissing, tt.wantMissing) {
+				t.Errorf("BibFile.Subset() missing = %v, want %v", gotMissing, tt.wantMissing)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package bibliography
+
+import "github.com/tkw1536/gotexml/utils"
+
+// Node is a node within the syntax tree of a BibFile.
+// It wraps one of BibFile, BibEntry, BibField, BibFieldElement or BibString, along with a link to its parent.
+type Node interface {
+	Source() utils.ReaderRange // source range of the wrapped object
+	Parent() Node              // the parent node, nil for the root of a tree
+
+	children() []Node
+}
+
+// FileNode is a Node wrapping a BibFile.
+// Its children are the entries, followed by the suffix of the file.
+type FileNode struct {
+	File *BibFile
+}
+
+// EntryNode is a Node wrapping a BibEntry.
+// Its children are the prefix, kind and kind suffix of the entry, followed by the fields.
+type EntryNode struct {
+	Entry  *BibEntry
+	parent Node
+}
+
+// FieldNode is a Node wrapping a BibField.
+// Its children are the prefix, the elements and the suffix of the field.
+type FieldNode struct {
+	Field  *BibField
+	parent Node
+}
+
+// ElementNode is a Node wrapping a BibFieldElement.
+// Its children are the value and the suffix of the element.
+type ElementNode struct {
+	Element *BibFieldElement
+	parent  Node
+}
+
+// StringNode is a Node wrapping a BibString.
+// It has no children.
+type StringNode struct {
+	String *BibString
+	parent Node
+}
+
+// Node returns a root node wrapping this BibFile
+func (file *BibFile) Node() *FileNode { return &FileNode{File: file} }
+
+// Node returns a root node wrapping this BibEntry
+func (entry *BibEntry) Node() *EntryNode { return &EntryNode{Entry: entry} }
+
+// Node returns a root node wrapping this BibField
+func (field *BibField) Node() *FieldNode { return &FieldNode{Field: field} }
+
+// Node returns a root node wrapping this BibFieldElement
+func (element *BibFieldElement) Node() *ElementNode { return &ElementNode{Element: element} }
+
+// Node returns a root node wrapping this BibString
+func (bs *BibString) Node() *StringNode { return &StringNode{String: bs} }
+
+// Source returns the source of the file
+func (n *FileNode) Source() utils.ReaderRange { return n.File.Source }
+
+// Source returns the source of the entry, from '@' to the closing '}'
+func (n *EntryNode) Source() utils.ReaderRange { return n.Entry.Source }
+
+// Source returns the source of the field, from the first element to the suffix
+func (n *FieldNode) Source() utils.ReaderRange { return n.Field.Source }
+
+// Source returns the source of the element, from the start of the value to the end of the suffix
+func (n *ElementNode) Source() (source utils.ReaderRange) {
+	source = n.Element.Value.Source
+	if hasSource(n.Element.Suffix) {
+		source.End = n.Element.Suffix.Source.End
+	}
+	return
+}
+
+// Source returns the source of the string
+func (n *StringNode) Source() utils.ReaderRange { return n.String.Source }
+
+// Parent returns nil, as files are always the root of a tree
+func (n *FileNode) Parent() Node { return nil }
+
+// Parent returns the parent of this node
+func (n *EntryNode) Parent() Node { return n.parent }
+
+// Parent returns the parent of this node
+func (n *FieldNode) Parent() Node { return n.parent }
+
+// Parent returns the parent of this node
+func (n *ElementNode) Parent() Node { return n.parent }
+
+// Parent returns the parent of this node
+func (n *StringNode) Parent() Node { return n.parent }
+
+func (n *FileNode) children() (children []Node) {
+	for _, entry := range n.File.Entries {
+		children = append(children, &EntryNode{Entry: entry, parent: n})
+	}
+	return append(children, &StringNode{String: &n.File.Suffix, parent: n})
+}
+
+func (n *EntryNode) children() (children []Node) {
+	children = appendStrings(children, n, &n.Entry.Prefix, n.Entry.Kind, n.Entry.KindSuffix)
+	for _, field := range n.Entry.Fields {
+		children = append(children, &FieldNode{Field: field, parent: n})
+	}
+	return
+}
+
+func (n *FieldNode) children() (children []Node) {
+	children = appendStrings(children, n, &n.Field.Prefix)
+	for _, element := range n.Field.Elements {
+		children = append(children, &ElementNode{Element: element, parent: n})
+	}
+	return appendStrings(children, n, &n.Field.Suffix)
+}
+
+func (n *ElementNode) children() []Node {
+	return appendStrings(nil, n, n.Element.Value, n.Element.Suffix)
+}
+
+func (n *StringNode) children() []Node { return nil }
+
+// appendStrings appends nodes for all non-nil strings to children
+func appendStrings(children []Node, parent Node, strings ...*BibString) []Node {
+	for _, bs := range strings {
+		if bs != nil {
+			children = append(children, &StringNode{String: bs, parent: parent})
+		}
+	}
+	return children
+}
+
+// Walk traverses the tree below node in depth-first order, visiting nodes in the order they occur in the source.
+// It calls f for each node, starting with node itself.
+// If f returns false, the children of that node are not visited.
+func Walk(node Node, f func(Node) bool) {
+	if !f(node) {
+		return
+	}
+	for _, child := range node.children() {
+		Walk(child, f)
+	}
+}
+
+// Rewrite rewrites the tree below node bottom-up, modifying it in place.
+// After the children of a node have been rewritten, f is called with the node and returns its replacement.
+// The replacement must be nil or a node of the same type, such as the node itself.
+//
+// Returning nil removes entries, fields and elements from their parent.
+// When the last field of an entry is removed, the closing brace of the entry is moved to the new last field.
+// Strings can not be removed; returning nil replaces them with an empty BibString.
+// Rewrite returns the replacement of node.
+func Rewrite(node Node, f func(Node) Node) Node {
+	switch n := node.(type) {
+	case *FileNode:
+		entries := n.File.Entries[:0]
+		for _, entry := range n.File.Entries {
+			if r := Rewrite(&EntryNode{Entry: entry, parent: n}, f); r != nil {
+				entries = append(entries, r.(*EntryNode).Entry)
+			}
+		}
+		n.File.Entries = entries
+		n.File.Suffix = *rewriteString(&n.File.Suffix, n, f)
+	case *EntryNode:
+		n.Entry.Prefix = *rewriteString(&n.Entry.Prefix, n, f)
+		n.Entry.Kind = rewriteString(n.Entry.Kind, n, f)
+		n.Entry.KindSuffix = rewriteString(n.Entry.KindSuffix, n, f)
+
+		fields := n.Entry.Fields[:0]
+		var closing *BibString // suffix of the last field if it was removed
+		for i, field := range n.Entry.Fields {
+			r := Rewrite(&FieldNode{Field: field, parent: n}, f)
+			if r != nil {
+				fields = append(fields, r.(*FieldNode).Field)
+			} else if i == len(n.Entry.Fields)-1 {
+				closing = &field.Suffix
+			}
+		}
+
+		// the suffix of the last field closes the entry, so it has to be kept
+		if closing != nil {
+			if len(fields) == 0 {
+				fields = append(fields, &BibField{})
+			}
+			fields[len(fields)-1].Suffix = *closing
+		}
+		n.Entry.Fields = fields
+	case *FieldNode:
+		n.Field.Prefix = *rewriteString(&n.Field.Prefix, n, f)
+
+		elements := n.Field.Elements[:0]
+		for _, element := range n.Field.Elements {
+			if r := Rewrite(&ElementNode{Element: element, parent: n}, f); r != nil {
+				elements = append(elements, r.(*ElementNode).Element)
+			}
+		}
+		n.Field.Elements = elements
+		n.Field.Suffix = *rewriteString(&n.Field.Suffix, n, f)
+	case *ElementNode:
+		n.Element.Value = rewriteString(n.Element.Value, n, f)
+		n.Element.Suffix = rewriteString(n.Element.Suffix, n, f)
+	}
+
+	return f(node)
+}
+
+// rewriteString rewrites bs using f, see Rewrite.
+// If bs is nil, returns nil without calling f.
+func rewriteString(bs *BibString, parent Node, f func(Node) Node) *BibString {
+	if bs == nil {
+		return nil
+	}
+	r := f(&StringNode{String: bs, parent: parent})
+	if r == nil {
+		return &BibString{}
+	}
+	return r.(*StringNode).String
+}
@@ -0,0 +1,253 @@
+package bibliography
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// describe returns a short description of a node for testing
+func describe(node Node) string {
+	switch n := node.(type) {
+	case *FileNode:
+		return "file"
+	case *EntryNode:
+		return "entry " + n.Entry.Kind.Value
+	case *FieldNode:
+		return "field " + n.Field.Name()
+	case *ElementNode:
+		return fmt.Sprintf("element %q", n.Element.Value.Value)
+	case *StringNode:
+		return fmt.Sprintf("string %q", n.String.Value)
+	}
+	return ""
+}
+
+func TestWalk(t *testing.T) {
+	file, err := NewBibFileFromString("@misc{a, title = x}\n")
+	if err != nil {
+		t.Fatalf("NewBibFileFromString() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		node Node
+		skip string // description of nodes whose children are skipped
+		want []string
+	}{
+		{"file", file.Node(), "", []string{
+			"file",
+			"entry misc", `string ""`, `string "misc"`, `string ""`,
+			"field ", `string ""`, `element "a"`, `string "a"`, `string ""`, `string ","`,
+			"field title", `string " "`, `element "title"`, `string "title"`, `string " = "`, `element "x"`, `string "x"`, `string ""`, `string "}"`,
+			`string "\n"`,
+		}},
+		{"skip fields", file.Node(), "field title", []string{
+			"file",
+			"entry misc", `string ""`, `string "misc"`, `string ""`,
+			"field ", `string ""`, `element "a"`, `string "a"`, `string ""`, `string ","`,
+			"field title",
+			`string "\n"`,
+		}},
+		{"field", file.Entries[0].Fields[1].Node(), "", []string{
+			"field title", `string " "`, `element "title"`, `string "title"`, `string " = "`, `element "x"`, `string "x"`, `string ""`, `string "}"`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			Walk(tt.node, func(node Node) bool {
+				got = append(got, describe(node))
+				return describe(node) != tt.skip
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Walk() visited %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWalk_parents(t *testing.T) {
+	file, err := NewBibFileFromString(complicatedBibFileText)
+	if err != nil {
+		t.Fatalf("NewBibFileFromString() error = %v", err)
+	}
+
+	root := file.Node()
+	Walk(root, func(node Node) bool {
+		for _, child := range node.children() {
+			if child.Parent() != node {
+				t.Errorf("%s.Parent() = %s, want %s", describe(child), describe(child.Parent()), describe(node))
+			}
+		}
+
+		top := node
+		for top.Parent() != nil {
+			top = top.Parent()
+		}
+		if top != root {
+			t.Errorf("root of %s = %s, want file", describe(node), describe(top))
+		}
+		return true
+	})
+}
+
+func TestNode_Source(t *testing.T) {
+	file, err := NewBibFileFromString("@misc{a, title = \"x\" # y }")
+	if err != nil {
+		t.Fatalf("NewBibFileFromString() error = %v", err)
+	}
+	entry := file.Entries[0]
+	title := entry.Fields[1]
+	value := title.GetValue()
+
+	tests := []struct {
+		name      string
+		node      Node
+		wantStart uint
+		wantEnd   uint
+	}{
+		{"file", file.Node(), 0, 25},
+		{"entry", entry.Node(), 0, 25},
+		{"field", title.Node(), 9, 25},
+		{"element with concatenation", value[0].Node(), 17, 22},
+		{"element with space", value[1].Node(), 23, 24},
+		{"string", value[1].Value.Node(), 23, 23},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.node.Source()
+			if got.Start.Column != tt.wantStart || got.End.Column != tt.wantEnd {
+				t.Errorf("Node.Source() = %v, want columns %d to %d", got, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		f     func(Node) Node
+		want  string
+	}{
+		{
+			"identity",
+			complicatedBibFileText,
+			func(node Node) Node { return node },
+			complicatedBibFileText,
+		},
+		{
+			"remove comments",
+			"@comment{x} @misc{a} @COMMENT{y}\n",
+			func(node Node) Node {
+				if n, ok := node.(*EntryNode); ok && n.Entry.IsKind("comment") {
+					return nil
+				}
+				return node
+			},
+			" @misc{a}\n",
+		},
+		{
+			"remove fields",
+			"@misc{a, note = x, year = 2000}",
+			func(node Node) Node {
+				if n, ok := node.(*FieldNode); ok && n.Field.Name() == "note" {
+					return nil
+				}
+				return node
+			},
+			"@misc{a, year = 2000}",
+		},
+		{
+			"remove last field",
+			"@misc{a, title = {x}, note = y}\n@misc{b, note = {z},}",
+			func(node Node) Node {
+				if n, ok := node.(*FieldNode); ok && (n.Field.Name() == "note" || n.Field.Empty()) {
+					return nil
+				}
+				return node
+			},
+			"@misc{a, title = {x}}\n@misc{b}",
+		},
+		{
+			"remove all fields",
+			"@misc{a, note = y} @misc{b}",
+			func(node Node) Node {
+				if _, ok := node.(*FieldNode); ok {
+					return nil
+				}
+				return node
+			},
+			"@misc{} @misc{}",
+		},
+		{
+			"replace values of a field",
+			"@misc{a, month = jan, note = jan # feb}",
+			func(node Node) Node {
+				n, ok := node.(*StringNode)
+				if !ok || n.String.Kind != BibStringLiteral {
+					return node
+				}
+				element, ok := n.Parent().(*ElementNode)
+				if !ok || element.Element.Value != n.String || element.Parent().(*FieldNode).Field.Name() != "month" || element.Element.Role != NormalElementRole {
+					return node
+				}
+				return &StringNode{String: &BibString{Kind: BibStringBracket, Value: strings.ToUpper(n.String.Value)}}
+			},
+			"@misc{a, month = {JAN}, note = jan # feb}",
+		},
+		{
+			"remove strings",
+			"@misc { a }",
+			func(node Node) Node {
+				if n, ok := node.(*StringNode); ok && strings.TrimSpace(n.String.Value) == "" {
+					return nil
+				}
+				return node
+			},
+			"@misc{a}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := NewBibFileFromString(tt.input)
+			if err != nil {
+				t.Fatalf("NewBibFileFromString() error = %v", err)
+			}
+
+			Rewrite(file.Node(), tt.f)
+
+			var builder strings.Builder
+			file.Write(&builder)
+			if got := builder.String(); got != tt.want {
+				t.Errorf("Rewrite() = %q, want %q", got, tt.want)
+			}
+			if _, err := NewBibFileFromString(builder.String()); err != nil {
+				t.Errorf("Rewrite() wrote a file that can not be read: %v", err)
+			}
+		})
+	}
+}
+
+func TestRewrite_root(t *testing.T) {
+	file, err := NewBibFileFromString("@misc{a}")
+	if err != nil {
+		t.Fatalf("NewBibFileFromString() error = %v", err)
+	}
+
+	replacement := &EntryNode{Entry: &BibEntry{}}
+	replace := func(node Node) Node {
+		if _, ok := node.(*EntryNode); ok {
+			return replacement
+		}
+		return node
+	}
+	if got := Rewrite(file.Entries[0].Node(), replace); got != replacement {
+		t.Errorf("Rewrite() = %v, want %v", got, replacement)
+	}
+	if got := Rewrite(file.Node(), func(Node) Node { return nil }); got != nil {
+		t.Errorf("Rewrite() = %v, want nil", got)
+	}
+}
@@ -0,0 +1,110 @@
+// Command bibconvert converts bibliographies between .bib files, YAML, TOML and Pandoc's YAML references.
+//
+// Usage:
+//
+//	bibconvert [-from format] [-to format] [-format text|json|sarif] [input [output]]
+//
+// Formats are 'bib', 'yaml', 'toml' and 'pandoc', and default to converting a .bib file into YAML.
+// Reads from standard input and writes to standard output unless files are given.
+// Errors reading the input are reported in the format given by -format, see package lint.
+// Exits with status 2 if an error occurs.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/frontend"
+	"github.com/tkw1536/gotexml/lint"
+)
+
+// readers read a BibFile from a source in each format
+var readers = map[string]func(source string) (*bibliography.BibFile, error){
+	"bib":    bibliography.NewBibFileFromString,
+	"yaml":   frontend.FromYAML,
+	"toml":   frontend.FromTOML,
+	"pandoc": frontend.FromPandoc,
+}
+
+// writers write a BibFile in each format
+var writers = map[string]func(file *bibliography.BibFile) (string, error){
+	"bib": func(file *bibliography.BibFile) (string, error) {
+		var buffer bytes.Buffer
+		err := file.Write(&buffer)
+		return buffer.String(), err
+	},
+	"yaml": frontend.ToYAML,
+	"toml": frontend.ToTOML,
+	"pandoc": func(file *bibliography.BibFile) (string, error) {
+		return frontend.ToPandoc(file), nil
+	},
+}
+
+func main() {
+	from := flag.String("from", "bib", "format to read: bib, yaml, toml or pandoc")
+	to := flag.String("to", "yaml", "format to write: bib, yaml, toml or pandoc")
+	format := lint.FormatText
+	flag.Var(&format, "format", "format of errors reading the input: 'text', 'json' or 'sarif'")
+	flag.Parse()
+
+	read, ok := readers[*from]
+	if !ok {
+		fail(fmt.Errorf("unknown input format %q", *from))
+	}
+	write, ok := writers[*to]
+	if !ok {
+		fail(fmt.Errorf("unknown output format %q", *to))
+	}
+	if flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// read from the first argument or stdin
+	var source []byte
+	var err error
+	if flag.NArg() > 0 {
+		source, err = os.ReadFile(flag.Arg(0))
+	} else {
+		source, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		fail(err)
+	}
+
+	file, err := read(string(source))
+	if err != nil {
+		filename := "<stdin>"
+		if flag.NArg() > 0 {
+			filename = flag.Arg(0)
+		}
+		if err := lint.ReportError(os.Stderr, format, filename, string(source), err); err != nil {
+			fail(err)
+		}
+		os.Exit(2)
+	}
+	result, err := write(file)
+	if err != nil {
+		fail(err)
+	}
+
+	// write to the second argument or stdout
+	if flag.NArg() > 1 {
+		err = os.WriteFile(flag.Arg(1), []byte(result), 0644)
+	} else {
+		_, err = io.WriteString(os.Stdout, result)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+// fail prints err and exits the program
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
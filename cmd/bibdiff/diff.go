@@ -0,0 +1,57 @@
+// Command bibdiff prints the semantic differences between two .bib files.
+//
+// Usage:
+//
+//	bibdiff [-doi] [-json] old.bib new.bib
+//
+// Exits with status 1 if the files differ, and 2 if an error occurs.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/diff"
+)
+
+func main() {
+	matchDOI := flag.Bool("doi", false, "match entries with different labels by doi")
+	asJSON := flag.Bool("json", false, "print the differences as json")
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	old, err := bibliography.NewBibFileFromFile(flag.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+	new, err := bibliography.NewBibFileFromFile(flag.Arg(1))
+	if err != nil {
+		fail(err)
+	}
+
+	report := diff.Compare(old, new, diff.Options{MatchDOI: *matchDOI})
+	if *asJSON {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fail(err)
+	}
+
+	if !report.Empty() {
+		os.Exit(1)
+	}
+}
+
+// fail prints err and exits the program
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
@@ -1,19 +1,27 @@
 package main
 
 import (
+	"bytes"
+	"flag"
 	"io"
 	"os"
 
 	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/lint"
 	"github.com/tkw1536/gotexml/utils"
 )
 
 func main() {
-	argc := len(os.Args) - 1
-	argv := os.Args[1:]
+	format := lint.FormatText
+	flag.Var(&format, "format", "format of parse errors: 'text', 'json' or 'sarif'")
+	flag.Parse()
+
+	argc := flag.NArg()
+	argv := flag.Args()
 
 	// read from the argv[0] or stdin
 	var inReader io.Reader
+	filename := "<stdin>"
 	if argc == 0 {
 		inReader = os.Stdin
 	} else {
@@ -23,6 +31,7 @@ func main() {
 			panic(err)
 		}
 		inReader = f
+		filename = argv[0]
 		defer f.Close()
 	}
 
@@ -41,11 +50,18 @@ func main() {
 	}
 
 	// and start reading
-	reader := utils.NewRuneReaderFromReader(inReader)
-	file, err := bibliography.NewBibFileFromReader(reader)
+	source, err := io.ReadAll(inReader)
 	if err != nil {
 		panic(err)
 	}
+	reader := utils.NewRuneReaderFromReader(bytes.NewReader(source))
+	file, err := bibliography.NewBibFileFromReader(reader)
+	if err != nil {
+		if err := lint.ReportError(os.Stderr, format, filename, string(source), err); err != nil {
+			panic(err)
+		}
+		os.Exit(2)
+	}
 
 	// format the file using the default formatter
 	bibliography.DefaultFormatter.Format(file)
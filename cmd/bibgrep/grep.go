@@ -0,0 +1,107 @@
+// Command bibgrep prints the entries of .bib files matching a query.
+//
+// Usage:
+//
+//	bibgrep [-l] [-format text|json|sarif] query [file.bib...]
+//
+// If no files are given, reads from standard input.
+// Files that can not be parsed are reported in the format given by -format, see package lint.
+// Matching entries are printed verbatim in UTF-8, separated by blank lines, or only their labels if -l is given.
+// Text between entries, such as comments, is not printed.
+// See package query for the syntax of queries.
+//
+// Exits with status 1 if no entries match, and 2 if an error occurs.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/lint"
+	"github.com/tkw1536/gotexml/query"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+func main() {
+	labels := flag.Bool("l", false, "print only the labels of matching entries")
+	format := lint.FormatText
+	flag.Var(&format, "format", "format of parse errors: 'text', 'json' or 'sarif'")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	q, err := query.Parse(flag.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+
+	var files []*bibliography.BibFile
+	if flag.NArg() == 1 {
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fail(err)
+		}
+		files = append(files, readBib("<stdin>", source, format))
+	}
+	for _, name := range flag.Args()[1:] {
+		source, err := os.ReadFile(name)
+		if err != nil {
+			fail(err)
+		}
+		files = append(files, readBib(name, source, format))
+	}
+
+	matched := false
+	for _, file := range files {
+		for _, entry := range q.Entries(file) {
+			if *labels {
+				fmt.Println(entry.Label())
+			} else if err := writeEntry(os.Stdout, entry, matched); err != nil {
+				fail(err)
+			}
+			matched = true
+		}
+	}
+	if matched && !*labels {
+		fmt.Println()
+	}
+
+	if !matched {
+		os.Exit(1)
+	}
+}
+
+// readBib reads a bibliography from source, and reports it in the given format if it can not be parsed
+func readBib(filename string, source []byte, format lint.Format) *bibliography.BibFile {
+	file, err := bibliography.NewBibFileFromReader(utils.NewRuneReaderFromReader(bytes.NewReader(source)))
+	if err != nil {
+		if err := lint.ReportError(os.Stderr, format, filename, string(source), err); err != nil {
+			fail(err)
+		}
+		os.Exit(2)
+	}
+	return file
+}
+
+// writeEntry writes entry without the text before it, separated from a previous entry like DefaultFormatter separates entries
+func writeEntry(w io.Writer, entry *bibliography.BibEntry, separate bool) error {
+	entry = entry.Clone()
+	entry.Prefix.Value = ""
+	if separate {
+		entry.Prefix.Value = bibliography.DefaultFormatter.FileSeparator
+	}
+	return entry.Write(w)
+}
+
+// fail prints err and exits the program
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
@@ -0,0 +1,126 @@
+// Command biblint checks .bib files for syntax errors and common problems.
+//
+// Usage:
+//
+//	biblint [-format text|json|sarif] [-color] [-fix] [file.bib...]
+//
+// If no files are given, reads from standard input.
+// With -fix, fixable findings are fixed and the fixed files are written back, or to standard output when reading standard input.
+// Files mixing different line endings are not fixed.
+// Only the remaining findings are reported.
+//
+// Findings are printed as human-readable diagnostics, as one JSON object per line, or as a SARIF 2.1.0 log.
+// See package lint for the checks performed.
+//
+// Exits with status 1 if any problems are found, and 2 if an error occurs.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/lint"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+func main() {
+	format := lint.FormatText
+	flag.Var(&format, "format", "output format: 'text', 'json' or 'sarif'")
+	color := flag.Bool("color", false, "color text output")
+	fix := flag.Bool("fix", false, "apply fixes and write back fixed files")
+	flag.Parse()
+
+	// when fixing standard input, the fixed file is written to standard output
+	report := io.Writer(os.Stdout)
+	if *fix && flag.NArg() == 0 {
+		report = os.Stderr
+	}
+	reporter := lint.NewReporter(report, format, *color)
+
+	found := false
+
+	// check reports the findings of data, and returns the fixed data when fixing
+	check := func(filename string, data []byte) []byte {
+		var findings []lint.Finding
+		file, err := bibliography.NewBibFileFromReader(utils.NewRuneReaderFromReader(bytes.NewReader(data)))
+		switch {
+		case err != nil:
+			findings = []lint.Finding{lint.NewFindingFromError(err)}
+		case *fix:
+			file, data = fixFile(filename, file, data)
+			fallthrough
+		default:
+			findings = lint.Lint(file)
+		}
+		found = found || len(findings) > 0
+
+		if err := reporter.Report(filename, string(data), findings); err != nil {
+			fail(err)
+		}
+		return data
+	}
+
+	if flag.NArg() == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fail(err)
+		}
+		fixed := check("<stdin>", data)
+		if *fix {
+			os.Stdout.Write(fixed)
+		}
+	}
+	for _, name := range flag.Args() {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			fail(err)
+		}
+		fixed := check(name, data)
+		if *fix && !bytes.Equal(fixed, data) {
+			if err := os.WriteFile(name, fixed, 0644); err != nil {
+				fail(err)
+			}
+		}
+	}
+
+	if err := reporter.Close(); err != nil {
+		fail(err)
+	}
+	if found {
+		os.Exit(1)
+	}
+}
+
+// fixFile applies all fixes to file.
+// Returns the fixed file and its data, or file and data if nothing was fixed.
+// Files with mixed line endings are left unchanged, with a warning.
+func fixFile(filename string, file *bibliography.BibFile, data []byte) (*bibliography.BibFile, []byte) {
+	fixed, applied, err := lint.FixFile(file, lint.Lint(file))
+	if errors.Is(err, lint.ErrMixedLineEndings) {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+		return file, data
+	}
+	if err != nil {
+		fail(err)
+	}
+	if applied == 0 {
+		return file, data
+	}
+
+	var buffer bytes.Buffer
+	if err := fixed.Write(&buffer); err != nil {
+		fail(err)
+	}
+	return fixed, buffer.Bytes()
+}
+
+// fail prints err and exits the program
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
@@ -0,0 +1,23 @@
+// Command biblsp is a Language Server Protocol server for .bib files.
+//
+// Usage:
+//
+//	biblsp
+//
+// The server communicates with the editor over standard input and output.
+// See package lsp for supported features.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tkw1536/gotexml/lsp"
+)
+
+func main() {
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
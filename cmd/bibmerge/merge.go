@@ -0,0 +1,76 @@
+// Command bibmerge performs a three-way merge of .bib files.
+//
+// Usage:
+//
+//	bibmerge [-marker-size n] [-ours label] [-theirs label] base.bib ours.bib theirs.bib
+//
+// The merged result is written back to ours.bib.
+// Exits with status 1 if there are conflicts, and 2 if an error occurs, in which case ours.bib is left unchanged.
+//
+// bibmerge can be used as a git merge driver.
+// To do so, add the following to the git configuration:
+//
+//	[merge "bibtex"]
+//		name = bibtex merge driver
+//		driver = bibmerge -marker-size %L %O %A %B
+//
+// and the following to .gitattributes:
+//
+//	*.bib merge=bibtex
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/merge"
+)
+
+func main() {
+	markerSize := flag.Int("marker-size", 7, "length of conflict markers")
+	oursLabel := flag.String("ours", "ours", "label for the current version in conflict markers")
+	theirsLabel := flag.String("theirs", "theirs", "label for the other version in conflict markers")
+	flag.Parse()
+
+	if flag.NArg() != 3 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	files := make([]*bibliography.BibFile, 3)
+	for i, name := range flag.Args() {
+		file, err := bibliography.NewBibFileFromFile(name)
+		if err != nil {
+			fail(err)
+		}
+		files[i] = file
+	}
+
+	result, conflicts := merge.Merge(files[0], files[1], files[2], merge.Options{
+		OursLabel:   *oursLabel,
+		TheirsLabel: *theirsLabel,
+		MarkerSize:  *markerSize,
+	})
+
+	var buffer bytes.Buffer
+	if err := result.Write(&buffer); err != nil {
+		fail(err)
+	}
+	if err := os.WriteFile(flag.Arg(1), buffer.Bytes(), 0666); err != nil {
+		fail(err)
+	}
+
+	if conflicts > 0 {
+		fmt.Fprintf(os.Stderr, "%d conflict(s) in %s\n", conflicts, flag.Arg(1))
+		os.Exit(1)
+	}
+}
+
+// fail prints err and exits the program
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
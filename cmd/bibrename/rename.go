@@ -0,0 +1,157 @@
+// Command bibrename renames citation keys in .bib files and the LaTeX sources citing them.
+//
+// Usage:
+//
+//	bibrename [-n] [-format text|json|sarif] -bib refs.bib [-bib more.bib] -r old=new [-r old2=new2] [file.tex ...]
+//
+// Old keys are compared case-insensitively, both in .bib files and in LaTeX sources, as BibTeX does.
+// Nothing is changed if an old key is not found in any .bib file, or a new key clashes with a key in any of them.
+// With -n, no files are changed and a unified diff of the changes is printed instead.
+// .bib files that can not be parsed are reported in the format given by -format, see package lint.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/latex"
+	"github.com/tkw1536/gotexml/lint"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// listFlag is a flag that may be given multiple times
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func main() {
+	var bibFiles, renameArgs listFlag
+	flag.Var(&bibFiles, "bib", "bibliography file to update (may be repeated)")
+	flag.Var(&renameArgs, "r", "rename of the form 'old=new' (may be repeated)")
+	dryRun := flag.Bool("n", false, "dry run: print a diff instead of writing files")
+	format := lint.FormatText
+	flag.Var(&format, "format", "format of parse errors: 'text', 'json' or 'sarif'")
+	flag.Parse()
+
+	renames := make(map[string]string, len(renameArgs))
+	for _, arg := range renameArgs {
+		old, new, ok := strings.Cut(arg, "=")
+		if !ok || old == "" || new == "" {
+			fail(fmt.Errorf("invalid rename %q: expected 'old=new'", arg))
+		}
+		renames[old] = new
+	}
+	if len(renames) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// read all bibliographies, and check the renames across all of them
+	files := make([]*bibliography.BibFile, len(bibFiles))
+	originals := make([]string, len(bibFiles))
+	for i, name := range bibFiles {
+		var err error
+		if originals[i], files[i], err = readBib(name); err != nil {
+			failParse(name, originals[i], err, format)
+		}
+	}
+	if err := bibliography.CheckRenames(files, renames); err != nil {
+		fail(err)
+	}
+
+	// compute all the changes before writing anything
+	changes := make(map[string][2]string)
+	var order []string
+	for i, name := range bibFiles {
+		updated, err := renameBib(files[i], renames)
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", name, err))
+		}
+		changes[name] = [2]string{originals[i], updated}
+		order = append(order, name)
+	}
+	for _, name := range flag.Args() {
+		original, err := os.ReadFile(name)
+		if err != nil {
+			fail(err)
+		}
+		updated, _ := latex.RenameCitations(string(original), renames)
+		changes[name] = [2]string{string(original), updated}
+		order = append(order, name)
+	}
+
+	for _, name := range order {
+		original, updated := changes[name][0], changes[name][1]
+		if original == updated {
+			continue
+		}
+		if *dryRun {
+			fmt.Print(utils.UnifiedDiff("a/"+name, "b/"+name, original, updated))
+			continue
+		}
+		if err := os.WriteFile(name, []byte(updated), 0644); err != nil {
+			fail(err)
+		}
+	}
+}
+
+// readBib reads the named bibliography file.
+// Returns the original content of the file, and the file read from it.
+// If the file can not be parsed, its original content is returned along with the error.
+func readBib(name string) (original string, file *bibliography.BibFile, err error) {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return "", nil, err
+	}
+	original = string(content)
+
+	file, err = bibliography.NewBibFileFromReader(utils.NewRuneReaderFromString(original))
+	if err != nil {
+		return original, nil, err
+	}
+	return original, file, nil
+}
+
+// renameBib renames labels in file, and returns its updated content
+func renameBib(file *bibliography.BibFile, renames map[string]string) (updated string, err error) {
+	if _, err = file.RenameLabels(renames); err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	if err = file.Write(&buffer); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// failParse reports an error reading the named bibliography in the given format and exits the program
+func failParse(name, source string, err error, format lint.Format) {
+	// errors reading the file itself are not parse errors
+	var perr *os.PathError
+	if errors.As(err, &perr) {
+		fail(err)
+	}
+	if err := lint.ReportError(os.Stderr, format, name, source, err); err != nil {
+		fail(err)
+	}
+	os.Exit(1)
+}
+
+// fail prints err and exits the program
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
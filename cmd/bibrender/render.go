@@ -0,0 +1,73 @@
+// Command bibrender renders a .bib file as HTML or Markdown, such as a publication list for a website.
+//
+// Usage:
+//
+//	bibrender [-template name] [file.bib]
+//	bibrender -file template.tmpl [-format html|markdown] [file.bib]
+//
+// The default templates are 'html', 'html-kind', 'markdown' and 'markdown-kind'; see package render for writing custom templates.
+// Reads from standard input unless a file is given, and writes to standard output.
+// Exits with status 2 if an error occurs.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/render"
+)
+
+func main() {
+	name := flag.String("template", "html", "name of a default template")
+	custom := flag.String("file", "", "custom template to use instead of a default template")
+	format := flag.String("format", "html", "format of the custom template: html or markdown")
+	flag.Parse()
+
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var template *render.Template
+	var err error
+	if *custom != "" {
+		var source []byte
+		if source, err = os.ReadFile(*custom); err != nil {
+			fail(err)
+		}
+		template, err = render.NewTemplate(render.Format(*format), string(source))
+	} else {
+		template, err = render.NewDefaultTemplate(*name)
+	}
+	if err != nil {
+		fail(err)
+	}
+
+	// read from the argument or stdin
+	var source []byte
+	if flag.NArg() > 0 {
+		source, err = os.ReadFile(flag.Arg(0))
+	} else {
+		source, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		fail(err)
+	}
+	file, err := bibliography.NewBibFileFromString(string(source))
+	if err != nil {
+		fail(err)
+	}
+
+	if err := template.Render(os.Stdout, file); err != nil {
+		fail(err)
+	}
+}
+
+// fail prints err and exits the program
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
@@ -0,0 +1,51 @@
+// Command bibserve serves .bib files using an HTTP/JSON API.
+//
+// Usage:
+//
+//	bibserve [-addr address] [-poll interval] file.bib...
+//
+// Files are watched for changes and reloaded automatically.
+// See package server for the supported endpoints.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/tkw1536/gotexml/server"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	poll := flag.Duration("poll", 2*time.Second, "interval to check files for changes")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	s, err := server.New(flag.Args()...)
+	if err != nil {
+		fail(err)
+	}
+	go s.Watch(context.Background(), *poll, func(err error) {
+		log.Printf("reload failed: %s", err)
+	})
+
+	log.Printf("listening on http://%s", *addr)
+	if err := http.ListenAndServe(*addr, s); err != nil {
+		fail(err)
+	}
+}
+
+// fail prints err and exits the program
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
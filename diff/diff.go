@@ -0,0 +1,241 @@
+// Package diff computes semantic differences between two bibliographies.
+package diff
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/latex"
+)
+
+// Options control how two bibliographies are compared
+type Options struct {
+	// MatchDOI additionally matches entries whose labels differ, but that have the same DOI.
+	MatchDOI bool
+
+	// Normalize normalizes evaluated field values before they are compared.
+	// If nil, Normalize is used.
+	Normalize func(value string) string
+}
+
+// Report is the result of comparing two bibliographies
+type Report struct {
+	Added   []Entry       `json:"added,omitempty"`   // entries only in the new bibliography
+	Removed []Entry       `json:"removed,omitempty"` // entries only in the old bibliography
+	Changed []EntryChange `json:"changed,omitempty"` // entries in both bibliographies that differ
+}
+
+// Entry identifies a single entry
+type Entry struct {
+	Label string `json:"label"`
+	Kind  string `json:"kind"`
+}
+
+// EntryChange describes how a single entry changed
+type EntryChange struct {
+	Label    string `json:"label"`              // label in the old bibliography
+	NewLabel string `json:"newLabel,omitempty"` // label in the new bibliography, if different
+
+	OldKind string `json:"oldKind,omitempty"` // kind in the old bibliography, if different
+	NewKind string `json:"newKind,omitempty"` // kind in the new bibliography, if different
+
+	Fields []FieldChange `json:"fields,omitempty"` // changed fields
+}
+
+// FieldChange describes how a single field changed
+type FieldChange struct {
+	Field string     `json:"field"`
+	Type  ChangeType `json:"type"`
+	Old   string     `json:"old,omitempty"` // normalized old value, unless the field was added
+	New   string     `json:"new,omitempty"` // normalized new value, unless the field was removed
+}
+
+// ChangeType is the type of change of a field
+type ChangeType string
+
+// types of changes
+const (
+	FieldAdded   ChangeType = "added"
+	FieldRemoved ChangeType = "removed"
+	FieldChanged ChangeType = "changed"
+)
+
+// Empty checks if this report contains no changes
+func (report *Report) Empty() bool {
+	return len(report.Added) == 0 && len(report.Removed) == 0 && len(report.Changed) == 0
+}
+
+// Normalize normalizes a field value for comparison.
+// It decodes LaTeX into unicode and collapses all consecutive whitespace into a single space.
+func Normalize(value string) string {
+	return strings.Join(strings.FieldsFunc(latex.Decode(value), unicode.IsSpace), " ")
+}
+
+// Compare compares two bibliographies.
+// Entries are matched by label (case-insensitively), and, if requested, by DOI.
+// Entries sharing a label are matched in the order they occur, so the second entry with a label in the old bibliography is matched with the second one in the new bibliography.
+// Special entries, such as '@string' and '@preamble', are not compared themselves, but the macros they define are used to evaluate field values.
+//
+// Added and changed entries are reported in the order of the new bibliography, removed entries in the order of the old one.
+func Compare(old, new *bibliography.BibFile, options Options) *Report {
+	normalize := options.Normalize
+	if normalize == nil {
+		normalize = Normalize
+	}
+
+	before := newSide(old, normalize)
+	after := newSide(new, normalize)
+
+	// match entries by label, and duplicated labels by occurrence
+	matches := make(map[*bibliography.BibEntry]*bibliography.BibEntry) // new => old
+	matched := make(map[*bibliography.BibEntry]struct{})               // old entries matched
+	occurrences := make(map[string]int)                                // entries with each label in the new bibliography so far
+	for _, entry := range after.entries {
+		label := strings.ToLower(entry.Label())
+		if olds := before.byLabel[label]; occurrences[label] < len(olds) {
+			o := olds[occurrences[label]]
+			matches[entry] = o
+			matched[o] = struct{}{}
+		}
+		occurrences[label]++
+	}
+
+	// match the remaining entries by doi
+	if options.MatchDOI {
+		for _, entry := range after.entries {
+			if _, ok := matches[entry]; ok {
+				continue
+			}
+			doi := after.doi(entry)
+			if doi == "" {
+				continue
+			}
+			for _, o := range before.entries {
+				if _, ok := matched[o]; ok || before.doi(o) != doi {
+					continue
+				}
+				matches[entry] = o
+				matched[o] = struct{}{}
+				break
+			}
+		}
+	}
+
+	report := &Report{}
+	for _, entry := range after.entries {
+		o, ok := matches[entry]
+		if !ok {
+			report.Added = append(report.Added, Entry{Label: entry.Label(), Kind: entry.Kind.Value})
+			continue
+		}
+		if change, ok := compareEntries(o, entry, before, after); ok {
+			report.Changed = append(report.Changed, change)
+		}
+	}
+	for _, entry := range before.entries {
+		if _, ok := matched[entry]; !ok {
+			report.Removed = append(report.Removed, Entry{Label: entry.Label(), Kind: entry.Kind.Value})
+		}
+	}
+	return report
+}
+
+// compareEntries compares two matched entries.
+// Returns ok = false if they do not differ.
+func compareEntries(old, new *bibliography.BibEntry, before, after *side) (change EntryChange, ok bool) {
+	change.Label = old.Label()
+	if label := new.Label(); label != change.Label {
+		change.NewLabel = label
+		ok = true
+	}
+
+	if !strings.EqualFold(old.Kind.Value, new.Kind.Value) {
+		change.OldKind = old.Kind.Value
+		change.NewKind = new.Kind.Value
+		ok = true
+	}
+
+	oldFields, oldOrder := before.fields(old)
+	newFields, newOrder := after.fields(new)
+
+	for _, name := range newOrder {
+		newValue := newFields[name]
+		oldValue, exists := oldFields[name]
+		switch {
+		case !exists:
+			change.Fields = append(change.Fields, FieldChange{Field: name, Type: FieldAdded, New: newValue})
+		case oldValue != newValue:
+			change.Fields = append(change.Fields, FieldChange{Field: name, Type: FieldChanged, Old: oldValue, New: newValue})
+		}
+	}
+	for _, name := range oldOrder {
+		if _, exists := newFields[name]; !exists {
+			change.Fields = append(change.Fields, FieldChange{Field: name, Type: FieldRemoved, Old: oldFields[name]})
+		}
+	}
+
+	ok = ok || len(change.Fields) > 0
+	return
+}
+
+// side holds one of the bibliographies being compared
+type side struct {
+	entries   []*bibliography.BibEntry
+	byLabel   map[string][]*bibliography.BibEntry // entries by lower case label, in order
+	macros    map[string]string
+	normalize func(string) string
+}
+
+// newSide prepares a bibliography for comparison
+func newSide(file *bibliography.BibFile, normalize func(string) string) *side {
+	s := &side{
+		byLabel:   make(map[string][]*bibliography.BibEntry),
+		macros:    file.Macros(),
+		normalize: normalize,
+	}
+	for _, entry := range file.Entries {
+		if entry.IsSpecial() {
+			continue
+		}
+		s.entries = append(s.entries, entry)
+
+		label := strings.ToLower(entry.Label())
+		s.byLabel[label] = append(s.byLabel[label], entry)
+	}
+	return s
+}
+
+// fields returns the normalized values of the fields of entry, keyed by lower case field name.
+// For duplicated fields, only the first occurrence is used.
+func (s *side) fields(entry *bibliography.BibEntry) (values map[string]string, order []string) {
+	values = make(map[string]string)
+	for _, field := range entry.Fields {
+		name := strings.ToLower(field.Name())
+		if name == "" {
+			continue
+		}
+		if _, ok := values[name]; ok {
+			continue
+		}
+		values[name] = s.normalize(field.Evaluate(s.macros))
+		order = append(order, name)
+	}
+	return
+}
+
+// doiPrefixes are prefixes stripped from DOIs before comparing them
+var doiPrefixes = []string{"https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/", "doi:"}
+
+// doi returns the normalized doi of an entry, or the empty string
+func (s *side) doi(entry *bibliography.BibEntry) string {
+	field := entry.GetField("doi")
+	if field == nil {
+		return ""
+	}
+	doi := strings.ToLower(strings.TrimSpace(field.Evaluate(s.macros)))
+	for _, prefix := range doiPrefixes {
+		doi = strings.TrimPrefix(doi, prefix)
+	}
+	return doi
+}
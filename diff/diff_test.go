@@ -0,0 +1,136 @@
+package diff
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+const diffTestOld = `@string{me = "Bart Kiers"}
+@article{MRx05,
+  author = me,
+  title = {Something   Great},
+  note = {old note},
+}
+@misc{gone, title = {Gone}}
+@misc{renamed, doi = {10.1000/XYZ}, title = {Same}}
+@misc{same, title = {Sch{\"o}n}}
+`
+
+const diffTestNew = `@string{me = "Bart Kiers"}
+@ARTICLE{mrx05,
+  author = {Bart Kiers},
+  title = {Something Great!},
+  year = 2005
+}
+@book{added, title = {New}}
+@misc{other, doi = {https://doi.org/10.1000/xyz}, title = {Same}}
+@book{same, title = {Schön}}
+`
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		matchDOI bool
+		want     *Report
+	}{
+		{
+			"by label",
+			false,
+			&Report{
+				Added:   []Entry{{"added", "book"}, {"other", "misc"}},
+				Removed: []Entry{{"gone", "misc"}, {"renamed", "misc"}},
+				Changed: []EntryChange{
+					{Label: "MRx05", NewLabel: "mrx05", Fields: []FieldChange{
+						{Field: "title", Type: FieldChanged, Old: "Something Great", New: "Something Great!"},
+						{Field: "year", Type: FieldAdded, New: "2005"},
+						{Field: "note", Type: FieldRemoved, Old: "old note"},
+					}},
+					{Label: "same", OldKind: "misc", NewKind: "book"},
+				},
+			},
+		},
+		{
+			"by doi",
+			true,
+			&Report{
+				Added:   []Entry{{"added", "book"}},
+				Removed: []Entry{{"gone", "misc"}},
+				Changed: []EntryChange{
+					{Label: "MRx05", NewLabel: "mrx05", Fields: []FieldChange{
+						{Field: "title", Type: FieldChanged, Old: "Something Great", New: "Something Great!"},
+						{Field: "year", Type: FieldAdded, New: "2005"},
+						{Field: "note", Type: FieldRemoved, Old: "old note"},
+					}},
+					{Label: "renamed", NewLabel: "other", Fields: []FieldChange{
+						{Field: "doi", Type: FieldChanged, Old: "10.1000/XYZ", New: "https://doi.org/10.1000/xyz"},
+					}},
+					{Label: "same", OldKind: "misc", NewKind: "book"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := mustParse(diffTestOld)
+			new := mustParse(diffTestNew)
+
+			if got := Compare(old, new, Options{MatchDOI: tt.matchDOI}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare_duplicates(t *testing.T) {
+	old := mustParse("@misc{a, title = {First}}\n@misc{b}\n@misc{A, title = {Second}}\n@misc{a, title = {Third}}\n")
+	new := mustParse("@misc{a, title = {First}}\n@misc{a, title = {Second!}}\n@misc{b}\n")
+
+	want := &Report{
+		Removed: []Entry{{"a", "misc"}},
+		Changed: []EntryChange{
+			{Label: "A", NewLabel: "a", Fields: []FieldChange{
+				{Field: "title", Type: FieldChanged, Old: "Second", New: "Second!"},
+			}},
+		},
+	}
+	if got := Compare(old, new, Options{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Compare() = %#v, want %#v", got, want)
+	}
+}
+
+func TestReport_WriteText(t *testing.T) {
+	report := Compare(mustParse(diffTestOld), mustParse(diffTestNew), Options{})
+
+	want := `+ @book{added}
++ @misc{other}
+- @misc{gone}
+- @misc{renamed}
+~ {MRx05}
+    label: MRx05 -> mrx05
+    ~ title: "Something Great" -> "Something Great!"
+    + year = "2005"
+    - note = "old note"
+~ {same}
+    kind: misc -> book
+`
+
+	var buffer bytes.Buffer
+	if err := report.WriteText(&buffer); err != nil {
+		t.Errorf("Report.WriteText() error = %v", err)
+	}
+	if got := buffer.String(); got != want {
+		t.Errorf("Report.WriteText() = %q, want %q", got, want)
+	}
+}
+
+func mustParse(s string) *bibliography.BibFile {
+	file, err := bibliography.NewBibFileFromReader(utils.NewRuneReaderFromString(s))
+	if err != nil {
+		panic(err)
+	}
+	return file
+}
@@ -0,0 +1,51 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteText writes a human-readable version of this report to writer.
+//
+// Each added entry is prefixed by '+', each removed entry by '-' and each changed entry by '~'.
+// Changes of a single entry are listed below it, indented by four spaces.
+func (report *Report) WriteText(writer io.Writer) error {
+	var builder strings.Builder
+	for _, e := range report.Added {
+		fmt.Fprintf(&builder, "+ @%s{%s}\n", e.Kind, e.Label)
+	}
+	for _, e := range report.Removed {
+		fmt.Fprintf(&builder, "- @%s{%s}\n", e.Kind, e.Label)
+	}
+	for _, c := range report.Changed {
+		fmt.Fprintf(&builder, "~ {%s}\n", c.Label)
+		if c.NewLabel != "" {
+			fmt.Fprintf(&builder, "    label: %s -> %s\n", c.Label, c.NewLabel)
+		}
+		if c.OldKind != "" || c.NewKind != "" {
+			fmt.Fprintf(&builder, "    kind: %s -> %s\n", c.OldKind, c.NewKind)
+		}
+		for _, f := range c.Fields {
+			switch f.Type {
+			case FieldAdded:
+				fmt.Fprintf(&builder, "    + %s = %q\n", f.Field, f.New)
+			case FieldRemoved:
+				fmt.Fprintf(&builder, "    - %s = %q\n", f.Field, f.Old)
+			case FieldChanged:
+				fmt.Fprintf(&builder, "    ~ %s: %q -> %q\n", f.Field, f.Old, f.New)
+			}
+		}
+	}
+
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+// WriteJSON writes this report as indented JSON to writer
+func (report *Report) WriteJSON(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(report)
+}
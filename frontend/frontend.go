@@ -0,0 +1,109 @@
+// Package frontend converts bibliographies between BibFiles and data formats used by other tools.
+//
+// Supported are a YAML and a TOML representation, where each entry is keyed by its label, and the YAML 'references' used by Pandoc.
+// Conversions only keep the data of citable entries, see bibliography.BibFile.Data.
+package frontend
+
+import (
+	"strings"
+
+	"github.com/tkw1536/gotexml/bibliography"
+)
+
+// record holds the data of a single entry, keeping the order of its fields
+type record struct {
+	Kind   string
+	Label  string
+	Fields []recordField
+}
+
+// recordField is a single field of a record
+type recordField struct {
+	Name  string
+	Value string
+}
+
+// get returns the value of the field with the given name, or the empty string
+func (r *record) get(name string) string {
+	for _, field := range r.Fields {
+		if field.Name == name {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// add adds a field to this record, unless value is empty or the field already exists
+func (r *record) add(name, value string) {
+	if value == "" {
+		return
+	}
+	for _, field := range r.Fields {
+		if field.Name == name {
+			return
+		}
+	}
+	r.Fields = append(r.Fields, recordField{Name: name, Value: value})
+}
+
+// records returns the records of the citable entries in file.
+// Like bibliography.BibFile.Data, kinds and field names are normalized to lower case, values are evaluated and only the first of duplicate fields is kept.
+func records(file *bibliography.BibFile) (records []record) {
+	macros := file.Macros()
+	for _, entry := range file.Entries {
+		if entry.IsSpecial() || entry.Kind == nil {
+			continue
+		}
+
+		r := record{Kind: strings.ToLower(entry.Kind.Value), Label: entry.Label()}
+		seen := make(map[string]bool)
+		for _, field := range entry.Fields {
+			if !field.IsKeyValue() {
+				continue
+			}
+			name := strings.ToLower(field.Name())
+			if !seen[name] {
+				seen[name] = true
+				r.Fields = append(r.Fields, recordField{Name: name, Value: field.Evaluate(macros)})
+			}
+		}
+		records = append(records, r)
+	}
+	return
+}
+
+// newFile creates a new BibFile from records, formatted like bibliography.DefaultFormatter formats files.
+// All values are written in braces.
+func newFile(records []record) *bibliography.BibFile {
+	file := &bibliography.BibFile{}
+	for _, r := range records {
+		builder := bibliography.NewEntry(r.Kind, r.Label)
+		for _, field := range r.Fields {
+			builder.Field(field.Name, field.Value)
+		}
+		file.Append(builder.Build())
+	}
+	return file
+}
+
+// joinNames joins a list of names or other values into a single BibTeX value
+func joinNames(values []string) string {
+	return strings.Join(values, " and ")
+}
+
+// balanced checks if the braces in value are balanced, so that it can be written in braces
+func balanced(value string) bool {
+	level := 0
+	for _, r := range value {
+		switch r {
+		case '{':
+			level++
+		case '}':
+			level--
+			if level < 0 {
+				return false
+			}
+		}
+	}
+	return level == 0
+}
@@ -0,0 +1,193 @@
+package frontend
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tkw1536/gotexml/bibliography"
+)
+
+// KindKey is the key holding the kind of an entry in the YAML and TOML representations.
+// Fields with this name can not be represented.
+const KindKey = "kind"
+
+// TypeKey holds the kind of entries without a KindKey, as in files written for Hayagriva
+const TypeKey = "type"
+
+// ToYAML returns a YAML representation of the citable entries in file.
+//
+// The representation is a mapping from labels to entries.
+// Each entry is a mapping holding its kind under KindKey, followed by its fields in order, such as:
+//
+//	knuth1984:
+//	  kind: book
+//	  author: Donald E. Knuth
+//	  title: The {\TeX}book
+//
+// Values are evaluated and remain LaTeX-encoded, see bibliography.BibFile.Data.
+// Returns an error if file contains duplicate labels, or fields named KindKey.
+func ToYAML(file *bibliography.BibFile) (string, error) {
+	node, err := nativeNode(records(file))
+	if err != nil {
+		return "", err
+	}
+
+	if len(node.items) == 0 {
+		return "{}\n", nil
+	}
+
+	var builder strings.Builder
+	writeYAML(&builder, node)
+	return builder.String(), nil
+}
+
+// FromYAML reads a BibFile from the YAML representation returned by ToYAML.
+// The document may be enclosed in '---' markers, as in the metadata block of a Markdown file.
+//
+// Entries without a KindKey take their kind from TypeKey, as in the format used by Hayagriva, and otherwise become 'misc' entries.
+// Lists of values, such as lists of authors, are joined using 'and'.
+// All values are written in braces; returns an error if a label is invalid or a value contains unbalanced braces.
+func FromYAML(source string) (*bibliography.BibFile, error) {
+	node, err := parseYAML(source)
+	if err != nil {
+		return nil, err
+	}
+	records, err := nativeRecords(node, func(line int, message string) error {
+		return &yamlError{Line: line, Message: message}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newFile(records), nil
+}
+
+// ToTOML returns a TOML representation of the citable entries in file.
+//
+// Each entry is a table named after its label, holding the kind of the entry under KindKey followed by its fields, such as:
+//
+//	[knuth1984]
+//	kind = "book"
+//	author = "Donald E. Knuth"
+//	title = "The {\\TeX}book"
+//
+// See ToYAML for details.
+func ToTOML(file *bibliography.BibFile) (string, error) {
+	node, err := nativeNode(records(file))
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	writeTOML(&builder, node)
+	return builder.String(), nil
+}
+
+// FromTOML reads a BibFile from the TOML representation returned by ToTOML, see FromYAML.
+func FromTOML(source string) (*bibliography.BibFile, error) {
+	node, err := parseTOML(source)
+	if err != nil {
+		return nil, err
+	}
+	records, err := nativeRecords(node, func(line int, message string) error {
+		return &tomlError{Line: line, Message: message}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newFile(records), nil
+}
+
+// nativeNode turns records into a mapping from labels to entries, see ToYAML
+func nativeNode(records []record) (*dataNode, error) {
+	root := &dataNode{kind: mappingNode}
+	for _, r := range records {
+		if root.get(r.Label) != nil {
+			return nil, fmt.Errorf("duplicate label %q", r.Label)
+		}
+
+		entry := &dataNode{kind: mappingNode}
+		entry.set(KindKey, newScalar(r.Kind))
+		for _, field := range r.Fields {
+			if field.Name == KindKey {
+				return nil, fmt.Errorf("entry %q: field %q can not be represented", r.Label, KindKey)
+			}
+			entry.set(field.Name, newScalar(field.Value))
+		}
+		root.set(r.Label, entry)
+	}
+	return root, nil
+}
+
+// nativeRecords reads records from a mapping from labels to entries, see FromYAML.
+// Errors are created using fail.
+func nativeRecords(root *dataNode, fail func(line int, message string) error) (records []record, err error) {
+	if root.kind == scalarNode && root.value == "" {
+		return nil, nil // an empty document
+	}
+	if root.kind != mappingNode {
+		return nil, fail(root.line, "expected a mapping from labels to entries")
+	}
+
+	for i, label := range root.keys {
+		entry := root.items[i]
+		if entry.kind != mappingNode {
+			return nil, fail(entry.line, fmt.Sprintf("entry %q: expected a mapping", label))
+		}
+
+		if !bibliography.IsValidLabel(label) {
+			return nil, fail(entry.line, fmt.Sprintf("invalid label %q", label))
+		}
+
+		r := record{Label: label}
+		hasKind := false
+		for j, key := range entry.keys {
+			value, ok := nativeValue(entry.items[j])
+			if !ok {
+				return nil, fail(entry.items[j].line, fmt.Sprintf("entry %q: field %q: expected a value or a list of values", label, key))
+			}
+			if !balanced(value) {
+				return nil, fail(entry.items[j].line, fmt.Sprintf("entry %q: field %q: unbalanced braces", label, key))
+			}
+			if key == KindKey {
+				r.Kind, hasKind = value, true
+				continue
+			}
+			r.Fields = append(r.Fields, recordField{Name: strings.ToLower(key), Value: value})
+		}
+
+		// without a kind, the 'type' field holds it
+		if !hasKind {
+			for k, field := range r.Fields {
+				if field.Name == TypeKey {
+					r.Kind = field.Value
+					r.Fields = append(r.Fields[:k], r.Fields[k+1:]...)
+					break
+				}
+			}
+		}
+		r.Kind = strings.ToLower(r.Kind)
+		if r.Kind == "" {
+			r.Kind = "misc"
+		}
+		records = append(records, r)
+	}
+	return
+}
+
+// nativeValue returns the value of a field, joining lists of values using 'and'
+func nativeValue(node *dataNode) (value string, ok bool) {
+	switch node.kind {
+	case scalarNode:
+		return node.value, true
+	case sequenceNode:
+		values := make([]string, len(node.items))
+		for i, item := range node.items {
+			if item.kind != scalarNode {
+				return "", false
+			}
+			values[i] = item.value
+		}
+		return joinNames(values), true
+	}
+	return "", false
+}
@@ -0,0 +1,187 @@
+package frontend
+
+import (
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// readLibrary reads testdata/library.bib
+func readLibrary() *bibliography.BibFile {
+	file, err := bibliography.NewBibFileFromString(utils.ReadFileOrPanic(path.Join("testdata", "library.bib")))
+	if err != nil {
+		panic(err)
+	}
+	return file
+}
+
+// writeFile writes file into a string
+func writeFile(file *bibliography.BibFile) string {
+	var builder strings.Builder
+	if err := file.Write(&builder); err != nil {
+		panic(err)
+	}
+	return builder.String()
+}
+
+func TestToYAML(t *testing.T) {
+	want := utils.ReadFileOrPanic(path.Join("testdata", "library.yaml"))
+	got, err := ToYAML(readLibrary())
+	if err != nil {
+		t.Errorf("ToYAML() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("ToYAML() = %s, want %s", got, want)
+	}
+}
+
+func TestToTOML(t *testing.T) {
+	want := utils.ReadFileOrPanic(path.Join("testdata", "library.toml"))
+	got, err := ToTOML(readLibrary())
+	if err != nil {
+		t.Errorf("ToTOML() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("ToTOML() = %s, want %s", got, want)
+	}
+}
+
+func TestFromYAML_roundtrip(t *testing.T) {
+	file := readLibrary()
+	text, err := ToYAML(file)
+	if err != nil {
+		t.Fatalf("ToYAML() error = %v", err)
+	}
+	got, err := FromYAML(text)
+	if err != nil {
+		t.Fatalf("FromYAML() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Data(), file.Data()) {
+		t.Errorf("FromYAML(ToYAML()).Data() = %v, want %v", got.Data(), file.Data())
+	}
+}
+
+func TestFromTOML_roundtrip(t *testing.T) {
+	file := readLibrary()
+	text, err := ToTOML(file)
+	if err != nil {
+		t.Fatalf("ToTOML() error = %v", err)
+	}
+	got, err := FromTOML(text)
+	if err != nil {
+		t.Fatalf("FromTOML() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Data(), file.Data()) {
+		t.Errorf("FromTOML(ToTOML()).Data() = %v, want %v", got.Data(), file.Data())
+	}
+}
+
+func TestFromYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    string
+		wantErr bool
+	}{
+		{"empty", "", "", false},
+		{"empty mapping", "{}\n", "", false},
+		{
+			"entries",
+			"a:\n  kind: Article\n  Title: The {\\TeX}book\n  year: 1984\nb:\n  author: [A, B]\n",
+			"@article{a,\n    title = {The {\\TeX}book},\n    year = {1984}\n}\n\n@misc{b,\n    author = {A and B}\n}\n",
+			false,
+		},
+		{"not a mapping", "- a\n", "", true},
+		{"entry not a mapping", "a: 1\n", "", true},
+		{"nested value", "a:\n  title:\n    x: 1\n", "", true},
+		{"nested list", "a:\n  author: [[A]]\n", "", true},
+		{
+			"type instead of kind",
+			"a:\n  type: Article\n  title: T\nb:\n  kind: techreport\n  type: Research Note\n",
+			"@article{a,\n    title = {T}\n}\n\n@techreport{b,\n    type = {Research Note}\n}\n",
+			false,
+		},
+		{"invalid yaml", "a: [\n", "", true},
+		{"unbalanced closing brace", "x:\n  title: a } b\n", "", true},
+		{"unbalanced opening brace", "x:\n  title: \"50% {off\"\n", "", true},
+		{"unbalanced kind", "x:\n  kind: a{\n", "", true},
+		{"invalid label", "a b:\n  title: T\n", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromYAML(tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FromYAML() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if s := writeFile(got); s != tt.want {
+				t.Errorf("FromYAML() = %q, want %q", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromTOML(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    string
+		wantErr bool
+	}{
+		{"empty", "", "", false},
+		{
+			"entries",
+			"[a]\nkind = \"Article\"\nTitle = 'The {\\TeX}book'\nyear = 1984\n\n[b]\nauthor = [\"A\", \"B\"]\n",
+			"@article{a,\n    title = {The {\\TeX}book},\n    year = {1984}\n}\n\n@misc{b,\n    author = {A and B}\n}\n",
+			false,
+		},
+		{"invalid toml", "a = 1\n", "", true},
+		{"unbalanced braces", "[a]\ntitle = \"}{\"\n", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromTOML(tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FromTOML() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if s := writeFile(got); s != tt.want {
+				t.Errorf("FromTOML() = %q, want %q", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestToYAML_invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"duplicate label", "@misc{a, title = {x}}\n@book{a, title = {y}}\n"},
+		{"kind field", "@misc{a, kind = {x}}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := bibliography.NewBibFileFromString(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ToYAML(file); err == nil {
+				t.Error("ToYAML() error = nil, want an error")
+			}
+			if _, err := ToTOML(file); err == nil {
+				t.Error("ToTOML() error = nil, want an error")
+			}
+		})
+	}
+}
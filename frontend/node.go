@@ -0,0 +1,45 @@
+package frontend
+
+// nodeKind is the kind of a dataNode
+type nodeKind int
+
+// kinds of dataNodes
+const (
+	scalarNode nodeKind = iota
+	mappingNode
+	sequenceNode
+)
+
+// dataNode is a node of a YAML or TOML document.
+// Scalars are always represented as strings, an empty scalar represents null.
+type dataNode struct {
+	kind  nodeKind
+	value string      // value of a scalar
+	keys  []string    // keys of a mapping, in order
+	items []*dataNode // values of a mapping, or items of a sequence
+	line  int         // one-based line number the node starts on, if known
+}
+
+// newScalar creates a new scalar node
+func newScalar(value string) *dataNode {
+	return &dataNode{kind: scalarNode, value: value}
+}
+
+// get returns the value of key within a mapping, or nil
+func (node *dataNode) get(key string) *dataNode {
+	if node == nil || node.kind != mappingNode {
+		return nil
+	}
+	for i, k := range node.keys {
+		if k == key {
+			return node.items[i]
+		}
+	}
+	return nil
+}
+
+// set appends key with the given value to a mapping
+func (node *dataNode) set(key string, value *dataNode) {
+	node.keys = append(node.keys, key)
+	node.items = append(node.items, value)
+}
@@ -0,0 +1,439 @@
+package frontend
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/latex"
+)
+
+// pandocTypes maps BibTeX entry kinds to CSL item types
+var pandocTypes = map[string]string{
+	"article":       "article-journal",
+	"book":          "book",
+	"booklet":       "pamphlet",
+	"conference":    "paper-conference",
+	"inbook":        "chapter",
+	"incollection":  "chapter",
+	"inproceedings": "paper-conference",
+	"manual":        "report",
+	"mastersthesis": "thesis",
+	"misc":          "document",
+	"phdthesis":     "thesis",
+	"proceedings":   "book",
+	"techreport":    "report",
+	"unpublished":   "manuscript",
+}
+
+// bibtexKinds maps CSL item types to BibTeX entry kinds.
+// Types not found in this map become 'misc' entries.
+var bibtexKinds = map[string]string{
+	"article":           "article",
+	"article-journal":   "article",
+	"article-magazine":  "article",
+	"article-newspaper": "article",
+	"book":              "book",
+	"chapter":           "incollection",
+	"manuscript":        "unpublished",
+	"pamphlet":          "booklet",
+	"paper-conference":  "inproceedings",
+	"report":            "techreport",
+	"thesis":            "phdthesis",
+}
+
+// pandocVariables maps BibTeX fields to CSL variables holding text.
+// Names, dates and fields depending on the kind of entry are handled separately.
+var pandocVariables = map[string]string{
+	"abstract":  "abstract",
+	"chapter":   "chapter-number",
+	"doi":       "DOI",
+	"edition":   "edition",
+	"isbn":      "ISBN",
+	"issn":      "ISSN",
+	"keywords":  "keyword",
+	"note":      "note",
+	"pages":     "page",
+	"publisher": "publisher",
+	"address":   "publisher-place",
+	"series":    "collection-title",
+	"title":     "title",
+	"type":      "genre",
+	"url":       "URL",
+	"volume":    "volume",
+}
+
+// pandocNames lists the BibTeX fields holding lists of names, along with their CSL variables
+var pandocNames = map[string]string{
+	"author": "author",
+	"editor": "editor",
+}
+
+// ToPandoc returns the citable entries in file as YAML metadata with a 'references' list, as used by Pandoc and citeproc.
+// The metadata is enclosed in '---' markers, so that it can be used as a Markdown metadata block or a bibliography file.
+//
+// Entries are converted into CSL items.
+// Values are decoded from LaTeX, names are split into their parts and the year and month become the 'issued' date.
+// Fields that have no CSL equivalent are omitted.
+func ToPandoc(file *bibliography.BibFile) string {
+	references := &dataNode{kind: sequenceNode}
+	for _, r := range records(file) {
+		references.items = append(references.items, pandocItem(r))
+	}
+
+	root := &dataNode{kind: mappingNode}
+	root.set("references", references)
+
+	var builder strings.Builder
+	builder.WriteString("---\n")
+	writeYAML(&builder, root)
+	builder.WriteString("---\n")
+	return builder.String()
+}
+
+// pandocItem turns a record into a CSL item
+func pandocItem(r record) *dataNode {
+	item := &dataNode{kind: mappingNode}
+	item.set("id", newScalar(r.Label))
+
+	kind, ok := pandocTypes[r.Kind]
+	if !ok {
+		kind = "document"
+	}
+	item.set("type", newScalar(kind))
+	if r.Kind == "mastersthesis" && r.get("type") == "" {
+		item.set("genre", newScalar("Master's thesis"))
+	}
+
+	for _, field := range r.Fields {
+		name := field.Name
+		if names, ok := pandocNames[name]; ok {
+			if list := pandocNameList(field.Value); len(list.items) > 0 {
+				item.set(names, list)
+			}
+			continue
+		}
+
+		switch name {
+		case "journal", "booktitle":
+			name = "container-title"
+		case "number":
+			if r.Kind == "article" {
+				name = "issue"
+			}
+		case "school", "institution", "organization":
+			if r.get("publisher") != "" || item.get("publisher") != nil {
+				continue
+			}
+			name = "publisher"
+		case "year":
+			if date := pandocDate(field.Value, r.get("month")); date != nil {
+				item.set("issued", date)
+			}
+			continue
+		default:
+			name = pandocVariables[name]
+		}
+
+		value := strings.TrimSpace(latex.Decode(field.Value))
+		if name == "" || value == "" || item.get(name) != nil {
+			continue
+		}
+		item.set(name, newScalar(value))
+	}
+	return item
+}
+
+// pandocNameList turns a BibTeX list of names into a list of CSL names.
+// The special name 'others' is omitted.
+func pandocNameList(value string) *dataNode {
+	list := &dataNode{kind: sequenceNode}
+	for _, name := range bibliography.ParseNames(value) {
+		if name.IsOthers() {
+			continue
+		}
+
+		node := &dataNode{kind: mappingNode}
+		if name.First == "" && name.Von == "" && name.Jr == "" && strings.HasPrefix(name.Last, "{") && strings.HasSuffix(name.Last, "}") {
+			// a name in braces, such as '{World Health Organization}'
+			node.set("literal", newScalar(latex.Decode(name.Last)))
+		} else {
+			for _, part := range []struct{ key, value string }{
+				{"family", name.Last},
+				{"given", name.First},
+				{"non-dropping-particle", name.Von},
+				{"suffix", name.Jr},
+			} {
+				if value := latex.Decode(part.value); value != "" {
+					node.set(part.key, newScalar(value))
+				}
+			}
+		}
+		list.items = append(list.items, node)
+	}
+	return list
+}
+
+// months lists the names of months, used to turn months into numbers
+var months = []string{"january", "february", "march", "april", "may", "june", "july", "august", "september", "october", "november", "december"}
+
+// pandocDate turns a year and month into a CSL date.
+// Years that are not numeric become literal dates, and months are omitted unless they can be turned into a number.
+func pandocDate(year, month string) *dataNode {
+	year = strings.TrimSpace(latex.Decode(year))
+	if year == "" {
+		return nil
+	}
+
+	date := &dataNode{kind: mappingNode}
+	if _, err := strconv.Atoi(year); err != nil {
+		date.set("literal", newScalar(year))
+		return date
+	}
+
+	parts := &dataNode{kind: sequenceNode, items: []*dataNode{newScalar(year)}}
+	if m := monthNumber(latex.Decode(month)); m != 0 {
+		parts.items = append(parts.items, newScalar(strconv.Itoa(m)))
+	}
+	date.set("date-parts", &dataNode{kind: sequenceNode, items: []*dataNode{parts}})
+	return date
+}
+
+// monthNumber returns the number of a month given as a number, name or abbreviated name, or 0
+func monthNumber(month string) int {
+	month = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(month), "."))
+	if n, err := strconv.Atoi(month); err == nil && 1 <= n && n <= 12 {
+		return n
+	}
+	if len(month) < 3 {
+		return 0
+	}
+	for i, name := range months {
+		if strings.HasPrefix(name, month) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// FromPandoc reads a BibFile from YAML metadata with a 'references' list, as returned by ToPandoc.
+// The metadata may be enclosed in '---' markers, as in a Markdown file.
+//
+// CSL items are converted into entries, and values are encoded for LaTeX by escaping special characters.
+// Variables that have no BibTeX equivalent are omitted, and ids that are not valid labels are rejected.
+func FromPandoc(source string) (*bibliography.BibFile, error) {
+	root, err := parseYAML(source)
+	if err != nil {
+		return nil, err
+	}
+
+	references := root.get("references")
+	if references == nil {
+		return nil, &yamlError{Line: root.line, Message: "missing 'references'"}
+	}
+	if references.kind == scalarNode && references.value == "" {
+		return newFile(nil), nil
+	}
+	if references.kind != sequenceNode {
+		return nil, &yamlError{Line: references.line, Message: "'references' must be a list"}
+	}
+
+	records := make([]record, 0, len(references.items))
+	for i, item := range references.items {
+		r, err := pandocRecord(item)
+		if err != nil {
+			return nil, &yamlError{Line: item.line, Message: fmt.Sprintf("reference %d: %s", i+1, err)}
+		}
+		records = append(records, r)
+	}
+	return newFile(records), nil
+}
+
+// pandocRecord turns a CSL item into a record
+func pandocRecord(item *dataNode) (r record, err error) {
+	if item.kind != mappingNode {
+		return r, fmt.Errorf("expected a mapping")
+	}
+
+	// variables are case-insensitive
+	variables := &dataNode{kind: mappingNode}
+	for i, key := range item.keys {
+		variables.set(strings.ToLower(key), item.items[i])
+	}
+	text := func(name string) string {
+		node := variables.get(name)
+		if node == nil || node.kind != scalarNode {
+			return ""
+		}
+		return strings.TrimSpace(node.value)
+	}
+
+	r.Label = text("id")
+	if r.Label == "" {
+		return r, fmt.Errorf("missing id")
+	}
+	if !bibliography.IsValidLabel(r.Label) {
+		return r, fmt.Errorf("invalid id %q", r.Label)
+	}
+
+	kind := text("type")
+	r.Kind = bibtexKinds[kind]
+	if r.Kind == "" {
+		r.Kind = "misc"
+	}
+	if kind == "thesis" && strings.Contains(strings.ToLower(text("genre")), "master") {
+		r.Kind = "mastersthesis"
+	}
+
+	for _, key := range variables.keys {
+		node := variables.get(key)
+		switch key {
+		case "author", "editor":
+			names, err := bibtexNames(node)
+			if err != nil {
+				return r, fmt.Errorf("%s: %s", key, err)
+			}
+			r.add(key, names)
+		case "issued":
+			year, month := bibtexDate(node)
+			r.add("year", year)
+			r.add("month", month)
+		case "container-title":
+			if r.Kind == "article" {
+				r.add("journal", escapeLaTeX(text(key)))
+			} else {
+				r.add("booktitle", escapeLaTeX(text(key)))
+			}
+		case "issue", "number":
+			r.add("number", escapeLaTeX(text(key)))
+		case "publisher":
+			switch r.Kind {
+			case "phdthesis", "mastersthesis":
+				r.add("school", escapeLaTeX(text(key)))
+			case "techreport":
+				r.add("institution", escapeLaTeX(text(key)))
+			default:
+				r.add("publisher", escapeLaTeX(text(key)))
+			}
+		case "page":
+			r.add("pages", pageRange.ReplaceAllString(escapeLaTeX(text(key)), "$1--$2"))
+		default:
+			for field, variable := range pandocVariables {
+				if strings.ToLower(variable) == key {
+					r.add(field, escapeLaTeX(text(key)))
+				}
+			}
+		}
+	}
+	return r, nil
+}
+
+// pageRange matches a range of pages separated by a single hyphen or dash
+var pageRange = regexp.MustCompile(`([0-9A-Za-z])\s*[-–—]\s*([0-9A-Za-z])`)
+
+// bibtexNames turns a list of CSL names into a BibTeX list of names
+func bibtexNames(node *dataNode) (string, error) {
+	if node.kind == scalarNode {
+		return escapeLaTeX(node.value), nil
+	}
+	if node.kind != sequenceNode {
+		return "", fmt.Errorf("expected a list of names")
+	}
+
+	names := make([]string, 0, len(node.items))
+	for _, item := range node.items {
+		switch {
+		case item.kind == scalarNode:
+			names = append(names, escapeLaTeX(item.value))
+		case item.kind != mappingNode:
+			return "", fmt.Errorf("expected a name")
+		case item.get("literal") != nil:
+			names = append(names, "{"+escapeLaTeX(item.get("literal").value)+"}")
+		default:
+			part := func(key string) string {
+				if node := item.get(key); node != nil {
+					return escapeLaTeX(strings.TrimSpace(node.value))
+				}
+				return ""
+			}
+			name := bibliography.Name{
+				First: part("given"),
+				Von:   strings.TrimSpace(part("dropping-particle") + " " + part("non-dropping-particle")),
+				Last:  part("family"),
+				Jr:    part("suffix"),
+			}
+			if name.Last == "" {
+				name.Last, name.First = name.First, ""
+			}
+			if strings.Contains(name.Last, " ") && name.Von == "" && name.First != "" {
+				name.Last = "{" + name.Last + "}" // keep multi-word family names together
+			}
+			names = append(names, name.String())
+		}
+	}
+	return joinNames(names), nil
+}
+
+// bibtexDate turns a CSL date into a year and month
+func bibtexDate(node *dataNode) (year, month string) {
+	if node.kind == scalarNode {
+		// an EDTF date, such as '2002-12-31'
+		parts := strings.Split(strings.TrimSpace(node.value), "-")
+		year = parts[0]
+		if len(parts) > 1 {
+			month = monthName(parts[1])
+		}
+		return escapeLaTeX(year), month
+	}
+
+	if literal := node.get("literal"); literal != nil {
+		return escapeLaTeX(literal.value), ""
+	}
+	if raw := node.get("raw"); raw != nil {
+		return bibtexDate(raw)
+	}
+
+	parts := node.get("date-parts")
+	if parts == nil || parts.kind != sequenceNode || len(parts.items) == 0 {
+		return "", ""
+	}
+	first := parts.items[0]
+	if first.kind != sequenceNode || len(first.items) == 0 {
+		return "", ""
+	}
+	year = escapeLaTeX(first.items[0].value)
+	if len(first.items) > 1 {
+		month = monthName(first.items[1].value)
+	}
+	return
+}
+
+// monthName returns the capitalized name of the month with the given number, or the empty string
+func monthName(number string) string {
+	n, err := strconv.Atoi(strings.TrimSpace(number))
+	if err != nil || n < 1 || n > 12 {
+		return ""
+	}
+	return strings.ToUpper(months[n-1][:1]) + months[n-1][1:]
+}
+
+// latexEscapes replaces characters that are special in LaTeX
+var latexEscapes = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+)
+
+// escapeLaTeX encodes plain text for use within a LaTeX document
+func escapeLaTeX(s string) string {
+	return latexEscapes.Replace(s)
+}
@@ -0,0 +1,115 @@
+package frontend
+
+import (
+	"path"
+	"testing"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+func TestToPandoc(t *testing.T) {
+	want := utils.ReadFileOrPanic(path.Join("testdata", "library.pandoc.yaml"))
+	if got := ToPandoc(readLibrary()); got != want {
+		t.Errorf("ToPandoc() = %s, want %s", got, want)
+	}
+}
+
+func TestFromPandoc(t *testing.T) {
+	source := utils.ReadFileOrPanic(path.Join("testdata", "pandoc.md"))
+	want := utils.ReadFileOrPanic(path.Join("testdata", "pandoc.bib"))
+
+	got, err := FromPandoc(source)
+	if err != nil {
+		t.Fatalf("FromPandoc() error = %v", err)
+	}
+	if s := writeFile(got); s != want {
+		t.Errorf("FromPandoc() = %s, want %s", s, want)
+	}
+}
+
+func TestFromPandoc_roundtrip(t *testing.T) {
+	// converting to CSL loses information, but converting back and forth again should not
+	for _, name := range []string{"library.bib", "pandoc.bib"} {
+		t.Run(name, func(t *testing.T) {
+			file, err := bibliography.NewBibFileFromString(utils.ReadFileOrPanic(path.Join("testdata", name)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := ToPandoc(file)
+			got, err := FromPandoc(want)
+			if err != nil {
+				t.Fatalf("FromPandoc() error = %v", err)
+			}
+			if s := ToPandoc(got); s != want {
+				t.Errorf("ToPandoc(FromPandoc()) = %s, want %s", s, want)
+			}
+		})
+	}
+}
+
+func TestFromPandoc_invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"invalid yaml", "references: [\n"},
+		{"missing references", "title: x\n"},
+		{"references not a list", "references: x\n"},
+		{"reference not a mapping", "references:\n- x\n"},
+		{"missing id", "references:\n- type: book\n"},
+		{"id with a field", "references:\n- id: a,title={x}\n"},
+		{"id with a space", "references:\n- id: has space\n"},
+		{"invalid names", "references:\n- id: a\n  author: {family: x}\n"},
+		{"invalid name", "references:\n- id: a\n  author:\n  - [x]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FromPandoc(tt.source); err == nil {
+				t.Error("FromPandoc() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func Test_monthNumber(t *testing.T) {
+	tests := []struct {
+		month string
+		want  int
+	}{
+		{"January", 1},
+		{"feb", 2},
+		{"Sept.", 9},
+		{"12", 12},
+		{"13", 0},
+		{"ma", 0},
+		{"", 0},
+		{"spring", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.month, func(t *testing.T) {
+			if got := monthNumber(tt.month); got != tt.want {
+				t.Errorf("monthNumber() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_escapeLaTeX(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"plain", "plain"},
+		{"R & D: 100% of $5 #1 a_b", `R \& D: 100\% of \$5 \#1 a\_b`},
+		{`{x} \ ~ ^`, `\{x\} \textbackslash{} \textasciitilde{} \textasciicircum{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if got := escapeLaTeX(tt.s); got != tt.want {
+				t.Errorf("escapeLaTeX() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
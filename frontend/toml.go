@@ -0,0 +1,398 @@
+package frontend
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// This file implements the subset of TOML needed to read and write bibliographies.
+// A document consists of tables, which contain strings, other scalars and arrays of them.
+// Keys outside of tables, dotted keys, inline tables and arrays of tables are not supported.
+
+// tomlParser parses a TOML document
+type tomlParser struct {
+	src  string
+	pos  int
+	line int // one-based line of pos
+}
+
+// tomlError is an error within a TOML document
+type tomlError struct {
+	Line    int
+	Message string
+}
+
+func (err *tomlError) Error() string {
+	return fmt.Sprintf("toml: line %d: %s", err.Line, err.Message)
+}
+
+// parseTOML parses a TOML document into a mapping from table names to tables.
+// Scalars other than strings, such as integers, are kept as written.
+func parseTOML(source string) (*dataNode, error) {
+	p := &tomlParser{src: strings.TrimPrefix(strings.ReplaceAll(source, "\r\n", "\n"), "\ufeff"), line: 1}
+	root := &dataNode{kind: mappingNode, line: 1}
+
+	var table *dataNode
+	for {
+		p.skipBlank(true)
+		if p.pos >= len(p.src) {
+			return root, nil
+		}
+
+		if p.src[p.pos] == '[' {
+			line := p.line
+			p.pos++
+			if p.peek('[') {
+				return nil, p.errorf("arrays of tables are not supported")
+			}
+			name, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			if !p.peek(']') {
+				return nil, p.errorf("expected ']'")
+			}
+			p.pos++
+			if root.get(name) != nil {
+				return nil, &tomlError{Line: line, Message: fmt.Sprintf("duplicate table %q", name)}
+			}
+			table = &dataNode{kind: mappingNode, line: line}
+			root.set(name, table)
+		} else {
+			line := p.line
+			key, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			if table == nil {
+				return nil, p.errorf("key %q outside of a table", key)
+			}
+			if table.get(key) != nil {
+				return nil, p.errorf("duplicate key %q", key)
+			}
+			if !p.peek('=') {
+				return nil, p.errorf("expected '='")
+			}
+			p.pos++
+			p.skipBlank(false)
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			value.line = line
+			table.set(key, value)
+		}
+
+		// the rest of the line may only hold a comment
+		p.skipBlank(false)
+		if p.pos < len(p.src) && p.src[p.pos] != '\n' {
+			return nil, p.errorf("unexpected %q", p.rest())
+		}
+	}
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	return &tomlError{Line: p.line, Message: fmt.Sprintf(format, args...)}
+}
+
+// rest returns the remainder of the current line
+func (p *tomlParser) rest() string {
+	rest, _, _ := strings.Cut(p.src[p.pos:], "\n")
+	return rest
+}
+
+// peek skips spaces and checks if the next character is c
+func (p *tomlParser) peek(c byte) bool {
+	p.skipBlank(false)
+	return p.pos < len(p.src) && p.src[p.pos] == c
+}
+
+// skipBlank skips spaces and comments, and line breaks if newlines is true
+func (p *tomlParser) skipBlank(newlines bool) {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t':
+			p.pos++
+		case '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case '\n':
+			if !newlines {
+				return
+			}
+			p.pos++
+			p.line++
+		default:
+			return
+		}
+	}
+}
+
+// parseKey parses a bare or quoted key
+func (p *tomlParser) parseKey() (key string, err error) {
+	p.skipBlank(false)
+	if p.pos < len(p.src) && (p.src[p.pos] == '"' || p.src[p.pos] == '\'') {
+		node, err := p.parseValue()
+		if err != nil {
+			return "", err
+		}
+		key = node.value
+	} else {
+		start := p.pos
+		for p.pos < len(p.src) && isBareTOMLKey(p.src[p.pos]) {
+			p.pos++
+		}
+		if p.pos == start {
+			return "", p.errorf("expected a key")
+		}
+		key = p.src[start:p.pos]
+	}
+
+	if p.peek('.') {
+		return "", p.errorf("dotted keys are not supported")
+	}
+	return key, nil
+}
+
+// isBareTOMLKey checks if c may occur in a bare key
+func isBareTOMLKey(c byte) bool {
+	return c == '_' || c == '-' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// parseValue parses a string, another scalar, or an array
+func (p *tomlParser) parseValue() (*dataNode, error) {
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("expected a value")
+	}
+
+	switch rest := p.src[p.pos:]; {
+	case strings.HasPrefix(rest, `"""`), strings.HasPrefix(rest, "'''"):
+		return p.parseMultilineString(rest[:3])
+	case rest[0] == '"' || rest[0] == '\'':
+		quote := rest[0]
+		end := 1
+		for end < len(rest) && rest[end] != quote && rest[end] != '\n' {
+			if quote == '"' && rest[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(rest) || rest[end] != quote {
+			return nil, p.errorf("unterminated string")
+		}
+		p.pos += end + 1
+		if quote == '\'' {
+			return newScalar(rest[1:end]), nil
+		}
+		value, err := unescapeTOML(rest[1:end])
+		if err != nil {
+			return nil, p.errorf("%s", err)
+		}
+		return newScalar(value), nil
+	case rest[0] == '[':
+		p.pos++
+		node := &dataNode{kind: sequenceNode}
+		for {
+			p.skipBlank(true)
+			if p.pos < len(p.src) && p.src[p.pos] == ']' {
+				p.pos++
+				return node, nil
+			}
+			item, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			if item.kind != scalarNode {
+				return nil, p.errorf("nested arrays are not supported")
+			}
+			node.items = append(node.items, item)
+
+			p.skipBlank(true)
+			switch {
+			case p.pos < len(p.src) && p.src[p.pos] == ',':
+				p.pos++
+			case p.pos < len(p.src) && p.src[p.pos] == ']':
+			default:
+				return nil, p.errorf("expected ',' or ']'")
+			}
+		}
+	case rest[0] == '{':
+		return nil, p.errorf("inline tables are not supported")
+	}
+
+	// integers, floats, booleans and dates
+	start := p.pos
+	for p.pos < len(p.src) && !strings.ContainsRune(" \t\n#,]", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.errorf("expected a value")
+	}
+	return newScalar(p.src[start:p.pos]), nil
+}
+
+// parseMultilineString parses a multi-line string starting with the given delimiter
+func (p *tomlParser) parseMultilineString(delimiter string) (*dataNode, error) {
+	start := p.pos + 3
+	end := strings.Index(p.src[start:], delimiter)
+	if end < 0 {
+		return nil, p.errorf("unterminated string")
+	}
+	end += start
+	for end+3 < len(p.src) && p.src[end+3] == delimiter[0] {
+		end++ // up to two quotes may directly precede the delimiter
+	}
+
+	body := strings.TrimPrefix(p.src[start:end], "\n")
+	p.line += strings.Count(p.src[p.pos:end], "\n")
+	p.pos = end + 3
+	if delimiter == "'''" {
+		return newScalar(body), nil
+	}
+
+	// a backslash at the end of a line removes the line break and following whitespace
+	lines := strings.Split(body, "\n")
+	var builder strings.Builder
+	trim := false
+	for i, line := range lines {
+		if trim {
+			line = strings.TrimLeft(line, " \t")
+		}
+		trimmed := strings.TrimRight(line, " \t")
+		trim = strings.HasSuffix(trimmed, `\`) && !strings.HasSuffix(trimmed, `\\`)
+		if trim {
+			line = strings.TrimSuffix(trimmed, `\`)
+		}
+		builder.WriteString(line)
+		if !trim && i != len(lines)-1 {
+			builder.WriteByte('\n')
+		}
+	}
+
+	value, err := unescapeTOML(builder.String())
+	if err != nil {
+		return nil, p.errorf("%s", err)
+	}
+	return newScalar(value), nil
+}
+
+// unescapeTOML decodes the escape sequences within a basic string
+func unescapeTOML(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			builder.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return "", fmt.Errorf("invalid escape at end of string")
+		}
+		switch e := s[i]; e {
+		case 'b':
+			builder.WriteByte('\b')
+		case 't':
+			builder.WriteByte('\t')
+		case 'n':
+			builder.WriteByte('\n')
+		case 'f':
+			builder.WriteByte('\f')
+		case 'r':
+			builder.WriteByte('\r')
+		case 'e':
+			builder.WriteByte(0x1b)
+		case '"', '\\':
+			builder.WriteByte(e)
+		case 'u', 'U':
+			size := 4
+			if e == 'U' {
+				size = 8
+			}
+			if i+size >= len(s) {
+				return "", fmt.Errorf("invalid escape %q", s[i-1:])
+			}
+			code, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape %q", s[i-1:i+1+size])
+			}
+			builder.WriteRune(rune(code))
+			i += size
+		default:
+			return "", fmt.Errorf("invalid escape %q", s[i-1:i+1])
+		}
+	}
+	return builder.String(), nil
+}
+
+// writeTOML writes a mapping from table names to tables as a TOML document.
+// Scalars are always written as strings.
+func writeTOML(builder *strings.Builder, node *dataNode) {
+	for i, table := range node.items {
+		if i > 0 {
+			builder.WriteByte('\n')
+		}
+		builder.WriteByte('[')
+		builder.WriteString(quoteTOMLKey(node.keys[i]))
+		builder.WriteString("]\n")
+
+		for j, value := range table.items {
+			builder.WriteString(quoteTOMLKey(table.keys[j]))
+			builder.WriteString(" = ")
+			if value.kind == scalarNode {
+				builder.WriteString(quoteTOML(value.value))
+			} else {
+				builder.WriteByte('[')
+				for k, item := range value.items {
+					if k > 0 {
+						builder.WriteString(", ")
+					}
+					builder.WriteString(quoteTOML(item.value))
+				}
+				builder.WriteByte(']')
+			}
+			builder.WriteByte('\n')
+		}
+	}
+}
+
+// quoteTOMLKey returns key as a bare key if possible, and as a basic string otherwise
+func quoteTOMLKey(key string) string {
+	for i := 0; i < len(key); i++ {
+		if !isBareTOMLKey(key[i]) {
+			return quoteTOML(key)
+		}
+	}
+	if key == "" {
+		return `""`
+	}
+	return key
+}
+
+// quoteTOML returns value as a basic string
+func quoteTOML(value string) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for _, r := range value {
+		switch {
+		case r == '"' || r == '\\':
+			builder.WriteByte('\\')
+			builder.WriteRune(r)
+		case r == '\n':
+			builder.WriteString(`\n`)
+		case r == '\t':
+			builder.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&builder, `\u%04X`, r)
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
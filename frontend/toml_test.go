@@ -0,0 +1,96 @@
+package frontend
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_parseTOML(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    string
+		wantErr bool
+	}{
+		{"empty", "", `{}`, false},
+		{"comment only", "# nothing here\n", `{}`, false},
+		{"tables", "[a]\nx = \"1\"\n\n[b]\ny = 'two'\n", `{"a": {"x": "1"}, "b": {"y": "two"}}`, false},
+		{"empty table", "[a]\n", `{"a": {}}`, false},
+		{"quoted names", "[\"a b\"]\n\"c.d\" = 1\n'e' = 2\n", `{"a b": {"c.d": "1", "e": "2"}}`, false},
+		{"other scalars", "[a]\ni = 1984\nf = 1.5\nb = true\nd = 2002-12-31\n", `{"a": {"i": "1984", "f": "1.5", "b": "true", "d": "2002-12-31"}}`, false},
+		{"comments", "# start\n[a] # table\nx = \"# not a comment\" # comment\n", `{"a": {"x": "# not a comment"}}`, false},
+		{"escapes", `[a]` + "\n" + `x = "tab\t \"quoted\" \\ \u00e9 \U0001F600"`, `{"a": {"x": "tab\t \"quoted\" \\ é 😀"}}`, false},
+		{"literal string", "[a]\nx = 'C:\\path'\n", `{"a": {"x": "C:\\path"}}`, false},
+		{"arrays", "[a]\nx = [\"1\", 2,\n  'three', # comment\n]\ny = []\n", `{"a": {"x": ["1", "2", "three"], "y": []}}`, false},
+		{"multi-line basic", "[a]\nx = \"\"\"\nline one\nline \\\n    two\"\"\"\n", `{"a": {"x": "line one\nline two"}}`, false},
+		{"multi-line literal", "[a]\nx = '''\nline one\\\nline two'''\n", `{"a": {"x": "line one\\\nline two"}}`, false},
+		{"quotes before delimiter", "[a]\nx = \"\"\"\"quoted\"\"\"\"\n", `{"a": {"x": "\"quoted\""}}`, false},
+		{"crlf", "[a]\r\nx = 1\r\n", `{"a": {"x": "1"}}`, false},
+		{"byte order mark", "\ufeff[a]\nx = 1\n", `{"a": {"x": "1"}}`, false},
+
+		{"key outside table", "x = 1\n", "", true},
+		{"duplicate table", "[a]\n[a]\n", "", true},
+		{"duplicate key", "[a]\nx = 1\nx = 2\n", "", true},
+		{"dotted key", "[a]\nx.y = 1\n", "", true},
+		{"dotted table", "[a.b]\n", "", true},
+		{"array of tables", "[[a]]\n", "", true},
+		{"inline table", "[a]\nx = {y = 1}\n", "", true},
+		{"nested array", "[a]\nx = [[1]]\n", "", true},
+		{"missing value", "[a]\nx =\n", "", true},
+		{"missing equals", "[a]\nx 1\n", "", true},
+		{"unterminated string", "[a]\nx = \"abc\n", "", true},
+		{"unterminated multi-line string", "[a]\nx = '''abc\n", "", true},
+		{"invalid escape", "[a]\nx = \"\\q\"\n", "", true},
+		{"trailing content", "[a]\nx = \"1\" \"2\"\n", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOML(tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseTOML() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if d := describe(got); d != tt.want {
+				t.Errorf("parseTOML() = %s, want %s", d, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseTOML_line(t *testing.T) {
+	_, err := parseTOML("[a]\nx = \"\"\"\n\n\"\"\"\ny = \n")
+	if err == nil || err.Error() != "toml: line 5: expected a value" {
+		t.Errorf("parseTOML() error = %v, want error on line 5", err)
+	}
+}
+
+func Test_writeTOML(t *testing.T) {
+	table := &dataNode{kind: mappingNode}
+	table.set("plain", newScalar("value"))
+	table.set("escaped", newScalar("\"quoted\" \\ line\nbreak\ttab\a"))
+	table.set("list", &dataNode{kind: sequenceNode, items: []*dataNode{newScalar("a"), newScalar("b")}})
+	root := &dataNode{kind: mappingNode}
+	root.set("first", table)
+	root.set("with space", &dataNode{kind: mappingNode})
+
+	want := "[first]\nplain = \"value\"\nescaped = \"\\\"quoted\\\" \\\\ line\\nbreak\\ttab\\u0007\"\nlist = [\"a\", \"b\"]\n\n[\"with space\"]\n"
+
+	var builder strings.Builder
+	writeTOML(&builder, root)
+	if got := builder.String(); got != want {
+		t.Errorf("writeTOML() = %q, want %q", got, want)
+	}
+
+	// reading the output back should result in the same node
+	parsed, err := parseTOML(builder.String())
+	if err != nil {
+		t.Errorf("parseTOML() error = %v", err)
+		return
+	}
+	if got, want := describe(parsed), describe(root); got != want {
+		t.Errorf("parseTOML(writeTOML()) = %s, want %s", got, want)
+	}
+}
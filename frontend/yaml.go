@@ -0,0 +1,679 @@
+package frontend
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// This file implements the subset of YAML needed to read and write bibliographies.
+// It supports block and flow collections, plain, quoted and block scalars, and comments.
+// Anchors, aliases, tags and multiple documents are not supported.
+
+// yamlLine is a single line of YAML source
+type yamlLine struct {
+	indent int    // number of leading spaces
+	text   string // text behind the indentation
+	raw    string // the entire line
+	number int    // one-based line number
+}
+
+// yamlParser parses a YAML document
+type yamlParser struct {
+	lines []yamlLine
+	pos   int // index of the next line
+}
+
+// yamlError is an error within a YAML document
+type yamlError struct {
+	Line    int
+	Message string
+}
+
+func (err *yamlError) Error() string {
+	return fmt.Sprintf("yaml: line %d: %s", err.Line, err.Message)
+}
+
+// parseYAML parses a YAML document.
+// The document may be enclosed in '---' and '...' or '---' markers, as in the metadata block of a Markdown file.
+func parseYAML(source string) (*dataNode, error) {
+	p := &yamlParser{}
+	started := false
+	for i, raw := range strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n") {
+		if i == 0 {
+			raw = strings.TrimPrefix(raw, "\ufeff")
+		}
+		if strings.TrimRight(raw, " \t") == "---" {
+			if started || len(p.lines) > 0 {
+				break
+			}
+			started = true
+			continue
+		}
+		if strings.TrimRight(raw, " \t") == "..." {
+			break
+		}
+		text := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(text, "\t") && strings.TrimSpace(text) != "" {
+			return nil, &yamlError{Line: i + 1, Message: "tabs can not be used for indentation"}
+		}
+		p.lines = append(p.lines, yamlLine{indent: len(raw) - len(text), text: text, raw: raw, number: i + 1})
+	}
+
+	node, err := p.parseBlock(0)
+	if err != nil {
+		return nil, err
+	}
+	if line, ok := p.peek(); ok {
+		return nil, &yamlError{Line: line.number, Message: "unexpected content"}
+	}
+	if node == nil {
+		node = newScalar("")
+	}
+	return node, nil
+}
+
+// peek returns the next line that is neither blank nor a comment
+func (p *yamlParser) peek() (line yamlLine, ok bool) {
+	for p.pos < len(p.lines) {
+		line = p.lines[p.pos]
+		if text := stripComment(line.text); strings.TrimSpace(text) != "" {
+			line.text = text
+			return line, true
+		}
+		p.pos++
+	}
+	return yamlLine{}, false
+}
+
+// parseBlock parses a block node indented by at least indent spaces.
+// Returns nil if there is no such node.
+func (p *yamlParser) parseBlock(indent int) (*dataNode, error) {
+	line, ok := p.peek()
+	if !ok || line.indent < indent {
+		return nil, nil
+	}
+
+	switch {
+	case isSequenceItem(line.text):
+		return p.parseSequence(line.indent)
+	case mappingKey(line.text) >= 0:
+		return p.parseMapping(line.indent)
+	}
+
+	// a scalar or flow collection on its own
+	p.pos++
+	return parseInline(line.text, line.number)
+}
+
+// isSequenceItem checks if text starts a block sequence item
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// parseSequence parses a block sequence whose items are indented by exactly indent spaces
+func (p *yamlParser) parseSequence(indent int) (*dataNode, error) {
+	node := &dataNode{kind: sequenceNode}
+	for {
+		line, ok := p.peek()
+		if !ok || line.indent != indent || !isSequenceItem(line.text) {
+			break
+		}
+		if node.line == 0 {
+			node.line = line.number
+		}
+
+		// an item on the following lines
+		rest := strings.TrimLeft(line.text[1:], " ")
+		if rest == "" {
+			p.pos++
+			item, err := p.parseBlock(indent + 1)
+			if err != nil {
+				return nil, err
+			}
+			if item == nil {
+				item = newScalar("")
+			}
+			node.items = append(node.items, item)
+			continue
+		}
+
+		// an item starting on the same line, which is parsed as if it was on its own line
+		p.lines[p.pos].indent = indent + len(line.text) - len(rest)
+		p.lines[p.pos].text = rest
+		item, err := p.parseBlock(p.lines[p.pos].indent)
+		if err != nil {
+			return nil, err
+		}
+		node.items = append(node.items, item)
+	}
+	return node, nil
+}
+
+// parseMapping parses a block mapping whose keys are indented by exactly indent spaces
+func (p *yamlParser) parseMapping(indent int) (*dataNode, error) {
+	node := &dataNode{kind: mappingNode}
+	for {
+		line, ok := p.peek()
+		if !ok || line.indent != indent {
+			break
+		}
+		if node.line == 0 {
+			node.line = line.number
+		}
+
+		colon := mappingKey(line.text)
+		if colon < 0 {
+			return nil, &yamlError{Line: line.number, Message: "expected a mapping key"}
+		}
+		key, err := parseKey(line.text[:colon], line.number)
+		if err != nil {
+			return nil, err
+		}
+		if node.get(key) != nil {
+			return nil, &yamlError{Line: line.number, Message: fmt.Sprintf("duplicate key %q", key)}
+		}
+		rest := strings.TrimSpace(line.text[colon+1:])
+		p.pos++
+
+		var value *dataNode
+		switch {
+		case rest == "":
+			// a nested block, or a sequence at the same indentation
+			if next, ok := p.peek(); ok && next.indent == indent && isSequenceItem(next.text) {
+				value, err = p.parseSequence(indent)
+			} else {
+				value, err = p.parseBlock(indent + 1)
+			}
+		case rest[0] == '|' || rest[0] == '>':
+			value, err = p.parseBlockScalar(rest, indent, line.number)
+		default:
+			value, err = parseInline(rest, line.number)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			value = newScalar("")
+		}
+		node.set(key, value)
+	}
+	return node, nil
+}
+
+// parseBlockScalar parses a literal ('|') or folded ('>') block scalar with the given header
+func (p *yamlParser) parseBlockScalar(header string, indent int, number int) (*dataNode, error) {
+	folded := header[0] == '>'
+	chomp := byte(0)
+	for _, c := range []byte(header[1:]) {
+		switch c {
+		case '-', '+':
+			chomp = c
+		default:
+			return nil, &yamlError{Line: number, Message: fmt.Sprintf("unsupported block scalar header %q", header)}
+		}
+	}
+
+	// collect the lines more indented than the parent
+	var lines []string
+	content := -1
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line.raw) == "" {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		if line.indent <= indent || (content >= 0 && line.indent < content) {
+			break
+		}
+		if content < 0 {
+			content = line.indent
+		}
+		lines = append(lines, line.raw[content:])
+		p.pos++
+	}
+
+	// trailing blank lines belong to the next node, unless kept
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	if len(lines) == 0 {
+		return newScalar(""), nil
+	}
+
+	var builder strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			// folded line breaks become spaces, except around empty and more indented lines
+			switch {
+			case !folded, line == "", strings.HasPrefix(line, " "), strings.HasPrefix(lines[i-1], " "):
+				builder.WriteByte('\n')
+			case lines[i-1] != "":
+				builder.WriteByte(' ')
+			}
+		}
+		builder.WriteString(line)
+	}
+	switch chomp {
+	case 0:
+		builder.WriteByte('\n')
+	case '+':
+		builder.WriteString(strings.Repeat("\n", trailing+1))
+	}
+	return newScalar(builder.String()), nil
+}
+
+// mappingKey returns the index of the ':' terminating a mapping key at the beginning of text, or -1.
+func mappingKey(text string) int {
+	start := 0
+	if text != "" && (text[0] == '"' || text[0] == '\'') {
+		end := quotedEnd(text)
+		if end < 0 {
+			return -1
+		}
+		start = end
+	} else if text != "" && strings.ContainsRune("[{-?", rune(text[0])) && !(text[0] == '-' && len(text) > 1 && text[1] != ' ') {
+		return -1
+	}
+
+	for i := start; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\t') {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseKey parses a mapping key
+func parseKey(text string, number int) (string, error) {
+	node, err := parseInline(strings.TrimSpace(text), number)
+	if err != nil {
+		return "", err
+	}
+	if node.kind != scalarNode {
+		return "", &yamlError{Line: number, Message: "unsupported complex key"}
+	}
+	return node.value, nil
+}
+
+// stripComment removes a trailing comment from text
+func stripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			switch {
+			case c == '\\' && quote == '"':
+				i++
+			case c == '\'' && quote == '\'' && i+1 < len(text) && text[i+1] == '\'':
+				i++
+			case c == quote:
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.ContainsRune(" \t[{,:-", rune(text[i-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return strings.TrimRight(text[:i], " \t")
+		}
+	}
+	return strings.TrimRight(text, " \t")
+}
+
+// quotedEnd returns the index behind the quoted scalar at the beginning of text, or -1 if it is not terminated
+func quotedEnd(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && quote == '"':
+			i++
+		case text[i] == quote && quote == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// parseInline parses a scalar or flow collection spanning all of text
+func parseInline(text string, number int) (*dataNode, error) {
+	f := &flowParser{text: text, number: number}
+	node, err := f.parse(false)
+	if err != nil {
+		return nil, err
+	}
+	f.skipSpace()
+	if f.pos != len(text) {
+		return nil, &yamlError{Line: number, Message: fmt.Sprintf("unexpected %q", text[f.pos:])}
+	}
+	return node, nil
+}
+
+// flowParser parses scalars and flow collections within a single line
+type flowParser struct {
+	text   string
+	pos    int
+	number int
+}
+
+func (f *flowParser) skipSpace() {
+	for f.pos < len(f.text) && (f.text[f.pos] == ' ' || f.text[f.pos] == '\t') {
+		f.pos++
+	}
+}
+
+func (f *flowParser) errorf(format string, args ...interface{}) error {
+	return &yamlError{Line: f.number, Message: fmt.Sprintf(format, args...)}
+}
+
+// parse parses a single node.
+// inFlow indicates if the node is within a flow collection.
+func (f *flowParser) parse(inFlow bool) (*dataNode, error) {
+	f.skipSpace()
+	if f.pos == len(f.text) {
+		return newScalar(""), nil
+	}
+
+	node := &dataNode{line: f.number}
+	switch c := f.text[f.pos]; c {
+	case '[':
+		node.kind = sequenceNode
+		f.pos++
+		for {
+			f.skipSpace()
+			if f.pos < len(f.text) && f.text[f.pos] == ']' {
+				f.pos++
+				return node, nil
+			}
+			item, err := f.parse(true)
+			if err != nil {
+				return nil, err
+			}
+			node.items = append(node.items, item)
+			if err := f.separator(']'); err != nil {
+				return nil, err
+			}
+		}
+	case '{':
+		node.kind = mappingNode
+		f.pos++
+		for {
+			f.skipSpace()
+			if f.pos < len(f.text) && f.text[f.pos] == '}' {
+				f.pos++
+				return node, nil
+			}
+			key, err := f.parse(true)
+			if err != nil {
+				return nil, err
+			}
+			if key.kind != scalarNode {
+				return nil, f.errorf("unsupported complex key")
+			}
+			f.skipSpace()
+			if f.pos >= len(f.text) || f.text[f.pos] != ':' {
+				return nil, f.errorf("expected ':' in flow mapping")
+			}
+			f.pos++
+			value, err := f.parse(true)
+			if err != nil {
+				return nil, err
+			}
+			node.set(key.value, value)
+			if err := f.separator('}'); err != nil {
+				return nil, err
+			}
+		}
+	case '"', '\'':
+		end := quotedEnd(f.text[f.pos:])
+		if end < 0 {
+			return nil, f.errorf("unterminated quoted scalar")
+		}
+		value, err := unquote(f.text[f.pos : f.pos+end])
+		if err != nil {
+			return nil, f.errorf("%s", err)
+		}
+		f.pos += end
+		node.value = value
+		return node, nil
+	case '&', '*', '!':
+		return nil, f.errorf("anchors, aliases and tags are not supported")
+	case '|', '>', '%', '@', '`':
+		return nil, f.errorf("unexpected %q", c)
+	}
+
+	// a plain scalar, which ends at a flow indicator within flow collections
+	start := f.pos
+	for f.pos < len(f.text) {
+		c := f.text[f.pos]
+		if inFlow && (c == ',' || c == ']' || c == '}' || (c == ':' && (f.pos+1 == len(f.text) || strings.ContainsRune(" ,]}", rune(f.text[f.pos+1]))))) {
+			break
+		}
+		f.pos++
+	}
+	node.value = strings.TrimSpace(f.text[start:f.pos])
+	if node.value == "~" || node.value == "null" || node.value == "Null" || node.value == "NULL" {
+		node.value = ""
+	}
+	return node, nil
+}
+
+// separator reads a ',' or the closing character of a flow collection
+func (f *flowParser) separator(closing byte) error {
+	f.skipSpace()
+	if f.pos < len(f.text) && f.text[f.pos] == ',' {
+		f.pos++
+		return nil
+	}
+	if f.pos < len(f.text) && f.text[f.pos] == closing {
+		return nil
+	}
+	return f.errorf("expected ',' or %q", closing)
+}
+
+// unquote decodes a single- or double-quoted scalar
+func unquote(quoted string) (string, error) {
+	body := quoted[1 : len(quoted)-1]
+	if quoted[0] == '\'' {
+		return strings.ReplaceAll(body, "''", "'"), nil
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if c != '\\' {
+			builder.WriteByte(c)
+			continue
+		}
+		i++
+		if i >= len(body) {
+			return "", fmt.Errorf("invalid escape at end of string")
+		}
+		switch e := body[i]; e {
+		case '\\', '"', '/', ' ':
+			builder.WriteByte(e)
+		case '0':
+			builder.WriteByte(0)
+		case 'a':
+			builder.WriteByte('\a')
+		case 'b':
+			builder.WriteByte('\b')
+		case 't', '\t':
+			builder.WriteByte('\t')
+		case 'n':
+			builder.WriteByte('\n')
+		case 'v':
+			builder.WriteByte('\v')
+		case 'f':
+			builder.WriteByte('\f')
+		case 'r':
+			builder.WriteByte('\r')
+		case 'e':
+			builder.WriteByte(0x1b)
+		case 'N':
+			builder.WriteString("\u0085")
+		case '_':
+			builder.WriteString(" ")
+		case 'x', 'u', 'U':
+			size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[e]
+			if i+size >= len(body) {
+				return "", fmt.Errorf("invalid escape %q", body[i-1:])
+			}
+			code, err := strconv.ParseUint(body[i+1:i+1+size], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape %q", body[i-1:i+1+size])
+			}
+			builder.WriteRune(rune(code))
+			i += size
+		default:
+			return "", fmt.Errorf("invalid escape %q", body[i-1:i+1])
+		}
+	}
+	return builder.String(), nil
+}
+
+// writeYAML writes node as a YAML document
+func writeYAML(builder *strings.Builder, node *dataNode) {
+	switch node.kind {
+	case scalarNode:
+		builder.WriteString(quoteYAML(node.value, false))
+		builder.WriteByte('\n')
+	default:
+		writeYAMLBlock(builder, node, 0)
+	}
+}
+
+// writeYAMLBlock writes the entries of a block mapping or sequence, each starting on a new line indented by indent spaces
+func writeYAMLBlock(builder *strings.Builder, node *dataNode, indent int) {
+	prefix := strings.Repeat(" ", indent)
+	for i, item := range node.items {
+		builder.WriteString(prefix)
+		if node.kind == mappingNode {
+			builder.WriteString(quoteYAML(node.keys[i], false))
+			builder.WriteByte(':')
+		} else {
+			builder.WriteByte('-')
+		}
+		writeYAMLValue(builder, item, indent, node.kind == sequenceNode)
+	}
+}
+
+// writeYAMLValue writes the value of a mapping entry or sequence item, starting directly behind the ':' or '-'
+func writeYAMLValue(builder *strings.Builder, node *dataNode, indent int, inSequence bool) {
+	switch {
+	case node.kind == scalarNode:
+		if node.value != "" {
+			builder.WriteByte(' ')
+			builder.WriteString(quoteYAML(node.value, false))
+		}
+		builder.WriteByte('\n')
+	case len(node.items) == 0:
+		if node.kind == mappingNode {
+			builder.WriteString(" {}\n")
+		} else {
+			builder.WriteString(" []\n")
+		}
+	case node.kind == sequenceNode && inSequence && isFlat(node):
+		// nested sequences of scalars, such as dates, are written inline
+		builder.WriteByte(' ')
+		writeYAMLFlow(builder, node)
+		builder.WriteByte('\n')
+	case inSequence:
+		// the first line of the value continues the sequence item
+		var nested strings.Builder
+		writeYAMLBlock(&nested, node, indent+2)
+		builder.WriteByte(' ')
+		builder.WriteString(strings.TrimLeft(nested.String(), " "))
+	case node.kind == sequenceNode:
+		builder.WriteByte('\n')
+		writeYAMLBlock(builder, node, indent)
+	default:
+		builder.WriteByte('\n')
+		writeYAMLBlock(builder, node, indent+2)
+	}
+}
+
+// isFlat checks if node is a sequence consisting only of scalars
+func isFlat(node *dataNode) bool {
+	for _, item := range node.items {
+		if item.kind != scalarNode {
+			return false
+		}
+	}
+	return true
+}
+
+// writeYAMLFlow writes a flow sequence of scalars
+func writeYAMLFlow(builder *strings.Builder, node *dataNode) {
+	builder.WriteByte('[')
+	for i, item := range node.items {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(quoteYAML(item.value, true))
+	}
+	builder.WriteByte(']')
+}
+
+// quoteYAML returns value as a plain scalar if possible, and as a double-quoted scalar otherwise.
+// inFlow indicates that value is written within a flow collection.
+func quoteYAML(value string, inFlow bool) string {
+	if isPlainYAML(value, inFlow) {
+		return value
+	}
+
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for _, r := range value {
+		switch {
+		case r == '"' || r == '\\':
+			builder.WriteByte('\\')
+			builder.WriteRune(r)
+		case r == '\n':
+			builder.WriteString(`\n`)
+		case r == '\t':
+			builder.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f || r == utf8.RuneError:
+			fmt.Fprintf(&builder, `\u%04x`, r)
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
+
+// isPlainYAML checks if value can be written as a plain scalar that is read back as the same string
+func isPlainYAML(value string, inFlow bool) bool {
+	if value == "" || value != strings.TrimSpace(value) {
+		return false
+	}
+	if strings.ContainsRune("-?:,[]{}#&*!|>'\"%@`", rune(value[0])) {
+		return false
+	}
+	if strings.Contains(value, ": ") || strings.Contains(value, " #") || strings.HasSuffix(value, ":") {
+		return false
+	}
+	if inFlow && strings.ContainsAny(value, ",[]{}") {
+		return false
+	}
+	for _, r := range value {
+		if r < 0x20 || r == 0x7f || r == utf8.RuneError {
+			return false
+		}
+	}
+
+	// values other YAML parsers read as something other than a string
+	switch strings.ToLower(value) {
+	case "~", "null", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return false
+	}
+	return true
+}
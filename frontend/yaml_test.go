@@ -0,0 +1,192 @@
+package frontend
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// describe returns a compact description of node, for use in tests
+func describe(node *dataNode) string {
+	var builder strings.Builder
+	switch node.kind {
+	case scalarNode:
+		fmt.Fprintf(&builder, "%q", node.value)
+	case mappingNode:
+		builder.WriteByte('{')
+		for i, key := range node.keys {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			fmt.Fprintf(&builder, "%q: %s", key, describe(node.items[i]))
+		}
+		builder.WriteByte('}')
+	case sequenceNode:
+		builder.WriteByte('[')
+		for i, item := range node.items {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteString(describe(item))
+		}
+		builder.WriteByte(']')
+	}
+	return builder.String()
+}
+
+func Test_parseYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    string
+		wantErr bool
+	}{
+		{"empty", "", `""`, false},
+		{"comment only", "# nothing here\n", `""`, false},
+		{"scalar", "hello world", `"hello world"`, false},
+		{"null", "a: ~\nb: null\nc:\n", `{"a": "", "b": "", "c": ""}`, false},
+
+		{"mapping", "a: 1\nb: two words\n", `{"a": "1", "b": "two words"}`, false},
+		{"nested mapping", "a:\n  b: 1\n  c:\n    d: 2\ne: 3\n", `{"a": {"b": "1", "c": {"d": "2"}}, "e": "3"}`, false},
+		{"quoted keys", "\"a b\": 1\n'c': 2\n", `{"a b": "1", "c": "2"}`, false},
+		{"url value", "url: https://example.com/a?b#c\n", `{"url": "https://example.com/a?b#c"}`, false},
+		{"comments", "# start\na: 1 # one\n\n  # indented\nb: '#2' # two\n", `{"a": "1", "b": "#2"}`, false},
+		{"crlf", "a: 1\r\nb: 2\r\n", `{"a": "1", "b": "2"}`, false},
+
+		{"sequence", "- a\n- b\n", `["a", "b"]`, false},
+		{"sequence of mappings", "- a: 1\n  b: 2\n- a: 3\n", `[{"a": "1", "b": "2"}, {"a": "3"}]`, false},
+		{"sequence at key indentation", "a:\n- 1\n- 2\nb: 3\n", `{"a": ["1", "2"], "b": "3"}`, false},
+		{"nested sequences", "- - 1\n  - 2\n- - 3\n", `[["1", "2"], ["3"]]`, false},
+		{"item on next line", "-\n  a: 1\n- \n", `[{"a": "1"}, ""]`, false},
+
+		{"flow sequence", "a: [1, 'two', \"three\", [4]]\n", `{"a": ["1", "two", "three", ["4"]]}`, false},
+		{"flow mapping", "a: {b: 1, c: [2, 3]}\n", `{"a": {"b": "1", "c": ["2", "3"]}}`, false},
+		{"empty flow", "a: []\nb: {}\n", `{"a": [], "b": {}}`, false},
+
+		{"single quoted", "a: 'it''s # here'\n", `{"a": "it's # here"}`, false},
+		{"double quoted", `a: "tab\there \"quoted\" \\ \u00e9 \x41"`, `{"a": "tab\there \"quoted\" \\ é A"}`, false},
+		{"literal block", "a: |\n  line one\n    indented\n\n  line three\nb: 1\n", `{"a": "line one\n  indented\n\nline three\n", "b": "1"}`, false},
+		{"folded block", "a: >\n  line one\n  same line\n\n  next paragraph\n", `{"a": "line one same line\nnext paragraph\n"}`, false},
+		{"strip block", "a: |-\n  text\n\nb: 1\n", `{"a": "text", "b": "1"}`, false},
+		{"keep block", "a: |+\n  text\n\nb: 1\n", `{"a": "text\n\n", "b": "1"}`, false},
+
+		{"document markers", "---\na: 1\n...\nignored: [\n", `{"a": "1"}`, false},
+		{"front matter", "---\na: 1\n---\n# Heading\n", `{"a": "1"}`, false},
+		{"byte order mark", "\ufeffa: 1\n", `{"a": "1"}`, false},
+
+		{"duplicate key", "a: 1\na: 2\n", "", true},
+		{"bad indentation", "a:\n    b: 1\n  c: 2\n", "", true},
+		{"tab indentation", "a:\n\tb: 1\n", "", true},
+		{"anchor", "a: &x 1\n", "", true},
+		{"alias", "a: *x\n", "", true},
+		{"unterminated quote", "a: 'b\n", "", true},
+		{"unterminated flow", "a: [1, 2\n", "", true},
+		{"trailing content", "a: 'b' c\n", "", true},
+		{"invalid escape", `a: "\q"`, "", true},
+		{"block header", "a: |2\n  b\n", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML(tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseYAML() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if d := describe(got); d != tt.want {
+				t.Errorf("parseYAML() = %s, want %s", d, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseYAML_line(t *testing.T) {
+	_, err := parseYAML("a: 1\n\nb: [\n")
+	if err == nil || err.Error() != "yaml: line 3: expected ',' or ']'" {
+		t.Errorf("parseYAML() error = %v, want error on line 3", err)
+	}
+}
+
+func Test_writeYAML(t *testing.T) {
+	mapping := func(pairs ...interface{}) *dataNode {
+		node := &dataNode{kind: mappingNode}
+		for i := 0; i < len(pairs); i += 2 {
+			node.set(pairs[i].(string), pairs[i+1].(*dataNode))
+		}
+		return node
+	}
+	sequence := func(items ...*dataNode) *dataNode {
+		return &dataNode{kind: sequenceNode, items: items}
+	}
+
+	tests := []struct {
+		name string
+		node *dataNode
+		want string
+	}{
+		{"scalar", newScalar("hello"), "hello\n"},
+		{"mapping", mapping("a", newScalar("1"), "b", mapping("c", newScalar("2"))), "a: 1\nb:\n  c: 2\n"},
+		{"sequence in mapping", mapping("a", sequence(newScalar("1"), newScalar("2"))), "a:\n- 1\n- 2\n"},
+		{"mappings in sequence", sequence(mapping("a", newScalar("1"), "b", newScalar("2")), mapping("c", newScalar(""))), "- a: 1\n  b: 2\n- c:\n"},
+		{"flat sequence in sequence", mapping("d", sequence(sequence(newScalar("1953"), newScalar("4")))), "d:\n- [1953, 4]\n"},
+		{"empty collections", mapping("a", mapping(), "b", sequence()), "a: {}\nb: []\n"},
+		{"quoted", mapping("a: b", newScalar("yes"), "c", newScalar("line\nbreak")), "\"a: b\": \"yes\"\nc: \"line\\nbreak\"\n"},
+		{"quoted in flow", sequence(sequence(newScalar("a, b"), newScalar("[c]"))), "- [\"a, b\", \"[c]\"]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var builder strings.Builder
+			writeYAML(&builder, tt.node)
+			if got := builder.String(); got != tt.want {
+				t.Errorf("writeYAML() = %q, want %q", got, tt.want)
+			}
+
+			// reading the output back should result in the same node
+			parsed, err := parseYAML(builder.String())
+			if err != nil {
+				t.Errorf("parseYAML() error = %v", err)
+				return
+			}
+			if got, want := describe(parsed), describe(tt.node); got != want {
+				t.Errorf("parseYAML(writeYAML()) = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func Test_quoteYAML(t *testing.T) {
+	tests := []struct {
+		value  string
+		inFlow bool
+		want   string
+	}{
+		{"plain text", false, "plain text"},
+		{"The {\\TeX}book", false, "The {\\TeX}book"},
+		{"1984", false, "1984"},
+		{"https://example.com", false, "https://example.com"},
+		{"", false, `""`},
+		{" padded ", false, `" padded "`},
+		{"{braced}", false, `"{braced}"`},
+		{"- dash", false, `"- dash"`},
+		{"key: value", false, `"key: value"`},
+		{"ends with:", false, `"ends with:"`},
+		{"a #comment", false, `"a #comment"`},
+		{"a#b", false, "a#b"},
+		{"No", false, `"No"`},
+		{"null", false, `"null"`},
+		{"quote \" and \\", false, `quote " and \`},
+		{"\"quoted\" start", false, `"\"quoted\" start"`},
+		{"a, b", false, "a, b"},
+		{"a, b", true, `"a, b"`},
+		{"bell\a", false, `"bell\u0007"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := quoteYAML(tt.value, tt.inFlow); got != tt.want {
+				t.Errorf("quoteYAML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
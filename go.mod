@@ -1,5 +1,3 @@
 module github.com/tkw1536/gotexml
 
 go 1.23
-
-require github.com/pkg/errors v0.9.1
@@ -0,0 +1,465 @@
+// Package keygen generates citation labels for BibTeX entries from templates.
+package keygen
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/latex"
+)
+
+// DefaultTemplate is the template used when Generator.Template is empty
+const DefaultTemplate = "[auth][year]"
+
+// DefaultStopWords are words ignored by the title markers
+var DefaultStopWords = map[string]struct{}{
+	"a": {}, "an": {}, "the": {}, "and": {}, "or": {}, "but": {}, "nor": {},
+	"of": {}, "on": {}, "in": {}, "into": {}, "for": {}, "to": {}, "from": {},
+	"by": {}, "with": {}, "without": {}, "at": {}, "as": {}, "via": {},
+	"about": {}, "towards": {}, "toward": {}, "is": {}, "are": {},
+}
+
+// placeholderLabel matches labels commonly used as placeholders
+var placeholderLabel = regexp.MustCompile(`^(?i:key|todo|fixme|tbd|none|null|nil|xx+|\?+)$`)
+
+// DefaultIsPlaceholder checks if label is empty or commonly used as a placeholder, such as 'todo' or '???'.
+func DefaultIsPlaceholder(label string) bool {
+	return label == "" || placeholderLabel.MatchString(label)
+}
+
+// Generator generates labels for entries from a template.
+//
+// A template consists of literal text and markers of the form '[name]' or '[name:modifier:...]'.
+// The following markers are supported:
+//
+//   - '[auth]': last name of the first author (or editor, if there are no authors)
+//   - '[authN]': the first N characters of '[auth]'
+//   - '[authors]': last names of all authors
+//   - '[authorsN]': last names of the first N authors, followed by 'EtAl' if there are more
+//   - '[authEtAl]': last name of the first author, followed by the second or 'EtAl' if there are more than two
+//   - '[authorLast]': last name of the last author
+//   - '[authorIni]': the first letters of the last names of all authors
+//   - '[edtr]': last name of the first editor
+//   - '[year]': the year of the entry, from the 'year' or 'date' field
+//   - '[title]': all words of the title, capitalized
+//   - '[shorttitle]': the first three words of the title that are not stop words, capitalized
+//   - '[veryshorttitle]': the first word of the title that is not a stop word
+//   - '[FIELD]': the value of the field FIELD
+//
+// The following modifiers may follow a marker:
+//
+//   - 'lower', 'upper': change the case of the value
+//   - 'capitalize': capitalize every word of the value
+//   - a number N: restrict word-based markers to the first N words, and truncate all other markers to N characters
+//
+// Values are decoded from LaTeX and transliterated into ascii.
+// Apart from literal text in the template, only letters and digits are retained.
+type Generator struct {
+	Template string // template to generate labels from; if empty, DefaultTemplate is used
+
+	StopWords     map[string]struct{}     // lower case words ignored in titles; if nil, DefaultStopWords is used
+	IsPlaceholder func(label string) bool // checks if a label is a placeholder; if nil, DefaultIsPlaceholder is used
+
+	OnlyMissing bool // only generate labels for entries whose label is missing or a placeholder
+}
+
+// marker is a single compiled part of a template
+type marker struct {
+	literal   string   // literal text, used when name is empty
+	name      string   // name of the marker
+	count     int      // numeric argument of the marker name, e.g. 3 for 'authors3', or -1
+	modifiers []string // modifiers applied to the value
+}
+
+// markerPattern matches a single marker within a template
+var markerPattern = regexp.MustCompile(`\[([^\[\]]*)\]`)
+
+// markerName matches the name of a marker, optionally followed by a number
+var markerName = regexp.MustCompile(`^([a-zA-Z]+?)(\d*)$`)
+
+// compile compiles a template into a list of markers
+func compile(template string) (markers []marker, err error) {
+	last := 0
+	for _, loc := range markerPattern.FindAllStringSubmatchIndex(template, -1) {
+		if loc[0] > last {
+			markers = append(markers, marker{literal: template[last:loc[0]]})
+		}
+		last = loc[1]
+
+		parts := strings.Split(template[loc[2]:loc[3]], ":")
+		match := markerName.FindStringSubmatch(parts[0])
+		if match == nil {
+			return nil, fmt.Errorf("invalid marker %q in template", template[loc[0]:loc[1]])
+		}
+
+		m := marker{name: match[1], count: -1, modifiers: parts[1:]}
+		if match[2] != "" {
+			m.count, _ = strconv.Atoi(match[2])
+		}
+		for _, mod := range m.modifiers {
+			switch mod {
+			case "lower", "upper", "capitalize":
+			default:
+				if _, err := strconv.Atoi(mod); err != nil {
+					return nil, fmt.Errorf("invalid modifier %q in template", mod)
+				}
+			}
+		}
+		markers = append(markers, m)
+	}
+	if last < len(template) {
+		rest := template[last:]
+		if strings.ContainsAny(rest, "[]") {
+			return nil, fmt.Errorf("unbalanced brackets in template %q", template)
+		}
+		markers = append(markers, marker{literal: rest})
+	}
+	return
+}
+
+// Label generates a label for the given entry, without taking collisions into account.
+// macros are used to evaluate field values, see bibliography.BibFile.Macros.
+func (g Generator) Label(entry *bibliography.BibEntry, macros map[string]string) (string, error) {
+	markers, err := g.compile()
+	if err != nil {
+		return "", err
+	}
+	return g.label(markers, entry, macros), nil
+}
+
+// compile compiles the template of this generator
+func (g Generator) compile() ([]marker, error) {
+	template := g.Template
+	if template == "" {
+		template = DefaultTemplate
+	}
+	return compile(template)
+}
+
+// label generates a label for entry using markers
+func (g Generator) label(markers []marker, entry *bibliography.BibEntry, macros map[string]string) string {
+	var builder strings.Builder
+	for _, m := range markers {
+		if m.name == "" {
+			builder.WriteString(m.literal)
+			continue
+		}
+		builder.WriteString(g.expand(m, entry, macros))
+	}
+	return builder.String()
+}
+
+// expand expands a single marker for entry
+func (g Generator) expand(m marker, entry *bibliography.BibEntry, macros map[string]string) string {
+	// find a numeric modifier, if any
+	limit := -1
+	for _, mod := range m.modifiers {
+		if n, err := strconv.Atoi(mod); err == nil {
+			limit = n
+		}
+	}
+
+	// compute the words making up this marker
+	words, wordBased := g.words(m, entry, macros)
+	if wordBased && limit >= 0 && limit < len(words) {
+		words = words[:limit]
+	}
+
+	// apply case modifiers
+	for _, mod := range m.modifiers {
+		for i, w := range words {
+			switch mod {
+			case "lower":
+				words[i] = strings.ToLower(w)
+			case "upper":
+				words[i] = strings.ToUpper(w)
+			case "capitalize":
+				words[i] = capitalize(w)
+			}
+		}
+	}
+
+	value := strings.Join(words, "")
+	if !wordBased && limit >= 0 && limit < len(value) {
+		value = value[:limit]
+	}
+	return value
+}
+
+// words computes the words of a marker.
+// wordBased indicates if the marker consists of separate words, such as titles or lists of names.
+func (g Generator) words(m marker, entry *bibliography.BibEntry, macros map[string]string) (words []string, wordBased bool) {
+	switch strings.ToLower(m.name) {
+	case "auth":
+		names := lastNames(entry, macros, "author", "editor")
+		if len(names) == 0 {
+			return nil, false
+		}
+		word := names[0]
+		if m.count >= 0 && m.count < len(word) {
+			word = word[:m.count]
+		}
+		return []string{word}, false
+	case "authors":
+		names := lastNames(entry, macros, "author", "editor")
+		if m.count >= 0 && m.count < len(names) {
+			names = append(names[:m.count:m.count], "EtAl")
+		}
+		return names, true
+	case "authetal":
+		names := lastNames(entry, macros, "author", "editor")
+		switch {
+		case len(names) > 2:
+			return []string{names[0], "EtAl"}, true
+		default:
+			return names, true
+		}
+	case "authorlast":
+		names := lastNames(entry, macros, "author", "editor")
+		if len(names) == 0 {
+			return nil, false
+		}
+		return names[len(names)-1:], false
+	case "authorini":
+		names := lastNames(entry, macros, "author", "editor")
+		for i, n := range names {
+			names[i] = n[:1]
+		}
+		return names, true
+	case "edtr":
+		names := lastNames(entry, macros, "editor")
+		if len(names) == 0 {
+			return nil, false
+		}
+		return names[:1], false
+	case "year":
+		return []string{year(entry, macros)}, false
+	case "title":
+		words := titleWords(entry, macros, nil)
+		for i, w := range words {
+			words[i] = capitalize(w)
+		}
+		return words, true
+	case "shorttitle":
+		words := titleWords(entry, macros, g.stopWords())
+		if len(words) > 3 {
+			words = words[:3]
+		}
+		for i, w := range words {
+			words[i] = capitalize(w)
+		}
+		return words, true
+	case "veryshorttitle":
+		words := titleWords(entry, macros, g.stopWords())
+		if len(words) > 1 {
+			words = words[:1]
+		}
+		return words, true
+	}
+
+	// any other field
+	field := entry.GetField(m.name)
+	if field == nil {
+		return nil, false
+	}
+	return []string{clean(field.Evaluate(macros))}, false
+}
+
+// stopWords returns the stop words to use
+func (g Generator) stopWords() map[string]struct{} {
+	if g.StopWords == nil {
+		return DefaultStopWords
+	}
+	return g.StopWords
+}
+
+// lastNames returns the cleaned last names of the people in the first non-empty field among fields
+func lastNames(entry *bibliography.BibEntry, macros map[string]string, fields ...string) (names []string) {
+	for _, name := range fields {
+		field := entry.GetField(name)
+		if field == nil {
+			continue
+		}
+		for _, n := range bibliography.ParseNames(field.Evaluate(macros)) {
+			if n.IsOthers() {
+				names = append(names, "EtAl")
+				continue
+			}
+			if last := clean(n.Von + n.Last); last != "" {
+				names = append(names, last)
+			}
+		}
+		if len(names) > 0 {
+			return
+		}
+	}
+	return
+}
+
+// yearPattern matches a year
+var yearPattern = regexp.MustCompile(`\d{4}`)
+
+// year returns the year of an entry
+func year(entry *bibliography.BibEntry, macros map[string]string) string {
+	for _, name := range []string{"year", "date"} {
+		if field := entry.GetField(name); field != nil {
+			if y := yearPattern.FindString(field.Evaluate(macros)); y != "" {
+				return y
+			}
+		}
+	}
+	return ""
+}
+
+// titleWords returns the cleaned words of the title of entry, skipping stopWords
+func titleWords(entry *bibliography.BibEntry, macros map[string]string, stopWords map[string]struct{}) (words []string) {
+	field := entry.GetField("title")
+	if field == nil {
+		return nil
+	}
+
+	title := latex.ASCII(latex.Decode(field.Evaluate(macros)))
+	for _, w := range strings.FieldsFunc(title, func(r rune) bool { return unicode.IsSpace(r) || r == '-' || r == '/' }) {
+		if _, ok := stopWords[strings.ToLower(w)]; ok {
+			continue
+		}
+		if w = clean(w); w != "" {
+			words = append(words, w)
+		}
+	}
+	return
+}
+
+// clean decodes s, transliterates it into ascii and removes everything except letters and digits
+func clean(s string) string {
+	s = latex.ASCII(latex.Decode(s))
+	return strings.Map(func(r rune) rune {
+		if ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// capitalize turns the first letter of s into upper case
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// Apply generates labels for the entries in file.
+// Returns a map from entries to the labels generated for them.
+//
+// Labels are disambiguated across the entire file by appending 'a', 'b', ... to all entries that would otherwise share a label.
+// If OnlyMissing is set, only entries with a missing or placeholder label are relabelled, and their labels are chosen so as not to clash with the remaining labels.
+// Crossref values referring to a relabelled entry are updated to match, see bibliography.BibFile.RenameEntries.
+func (g Generator) Apply(file *bibliography.BibFile) (labels map[*bibliography.BibEntry]string, err error) {
+	markers, err := g.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	isPlaceholder := g.IsPlaceholder
+	if isPlaceholder == nil {
+		isPlaceholder = DefaultIsPlaceholder
+	}
+
+	macros := file.Macros()
+
+	// determine which entries to relabel, and which labels are already taken
+	var targets []*bibliography.BibEntry
+	taken := make(map[string]struct{})
+	for _, entry := range file.Entries {
+		if entry.IsSpecial() {
+			continue
+		}
+		if g.OnlyMissing && !isPlaceholder(entry.Label()) {
+			taken[strings.ToLower(entry.Label())] = struct{}{}
+			continue
+		}
+		targets = append(targets, entry)
+	}
+
+	// generate base labels and group them
+	bases := make([]string, len(targets))
+	groups := make(map[string]int)
+	for i, entry := range targets {
+		bases[i] = g.label(markers, entry, macros)
+		groups[strings.ToLower(bases[i])]++
+	}
+
+	// disambiguate the labels
+	labels = make(map[*bibliography.BibEntry]string, len(targets))
+	next := make(map[string]int)
+	for i, entry := range targets {
+		base := bases[i]
+		key := strings.ToLower(base)
+
+		label := base
+		_, clash := taken[key]
+		if groups[key] > 1 || clash || label == "" {
+			for {
+				label = base + suffix(next[key])
+				next[key]++
+				if _, ok := taken[strings.ToLower(label)]; !ok {
+					break
+				}
+			}
+		}
+		taken[strings.ToLower(label)] = struct{}{}
+		labels[entry] = label
+	}
+
+	// crossrefs referring to entries with a unique label are updated
+	if _, err := file.RenameEntries(labels); err != nil {
+		return nil, err
+	}
+
+	// entries without a label get one
+	for _, entry := range targets {
+		setLabel(entry, labels[entry])
+	}
+
+	return labels, nil
+}
+
+// suffix returns the disambiguation suffix for the given index, i.e. 'a', 'b', ..., 'z', 'aa', 'ab', ...
+func suffix(index int) string {
+	s := ""
+	for index >= 0 {
+		s = string(rune('a'+index%26)) + s
+		index = index/26 - 1
+	}
+	return s
+}
+
+// setLabel sets the label of entry, adding a label to entries that do not have one
+func setLabel(entry *bibliography.BibEntry, label string) {
+	if entry.SetLabel(label) {
+		return
+	}
+
+	labelElement := &bibliography.BibFieldElement{
+		Value:  &bibliography.BibString{Kind: bibliography.BibStringLiteral, Value: label},
+		Suffix: &bibliography.BibString{},
+	}
+
+	// the first field is empty, so we can use it
+	if len(entry.Fields) > 0 && entry.Fields[0].Empty() {
+		entry.Fields[0].Elements = []*bibliography.BibFieldElement{labelElement}
+		return
+	}
+
+	// insert a new first field
+	field := &bibliography.BibField{
+		Elements: []*bibliography.BibFieldElement{labelElement},
+		Suffix:   bibliography.BibString{Value: ","},
+	}
+	entry.Fields = append([]*bibliography.BibField{field}, entry.Fields...)
+}
@@ -0,0 +1,161 @@
+package keygen
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+const keygenTestEntry = `@inproceedings{BreCodJuc:tospsme14,
+  author    = {Thilo Breitsprecher and Mihai Codescu and Lutz Schr{\"{o}}der},
+  title     = {Towards Ontological Support for Principle Solutions},
+  year      = {2014},
+  booktitle = {FOIS},
+}`
+
+func TestGenerator_Label(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{"", "Breitsprecher2014", false},
+		{"[auth:lower][year][shorttitle:1]", "breitsprecher2014Ontological", false},
+		{"[auth3]", "Bre", false},
+		{"[authors]", "BreitsprecherCodescuSchroder", false},
+		{"[authors2]", "BreitsprecherCodescuEtAl", false},
+		{"[authEtAl]", "BreitsprecherEtAl", false},
+		{"[authorLast:upper]", "SCHRODER", false},
+		{"[authorIni]", "BCS", false},
+		{"[shorttitle]", "OntologicalSupportPrinciple", false},
+		{"[veryshorttitle:lower]", "ontological", false},
+		{"[title:2]", "TowardsOntological", false},
+		{"[booktitle]-[year]", "FOIS-2014", false},
+		{"[edtr][unknown]", "", false},
+		{"[booktitle:2]", "FO", false},
+		{"[auth", "", true},
+		{"[auth:unknown]", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			file, err := bibliography.NewBibFileFromReader(utils.NewRuneReaderFromString(keygenTestEntry))
+			if err != nil {
+				panic(err)
+			}
+
+			got, err := Generator{Template: tt.template}.Label(file.Entries[0], nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Generator.Label() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Generator.Label() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+const keygenTestFile = `@string{me = "Bart Kiers"}
+@article{x, author = me, year = 2005}
+@article{y, author = {Kiers, B.}, year = 2005}
+@article{, author = {Someone}, year = 2001}
+@article{todo, author = {Kiers, Bart}, year = 2005}
+@misc{z, crossref = {x}, author = {Other}, year = 1999}
+`
+
+func TestGenerator_Apply(t *testing.T) {
+	tests := []struct {
+		name        string
+		onlyMissing bool
+		want        string
+	}{
+		{
+			"all entries",
+			false,
+			`@string{me = "Bart Kiers"}
+@article{Kiers2005a, author = me, year = 2005}
+@article{Kiers2005b, author = {Kiers, B.}, year = 2005}
+@article{Someone2001, author = {Someone}, year = 2001}
+@article{Kiers2005c, author = {Kiers, Bart}, year = 2005}
+@misc{Other1999, crossref = {Kiers2005a}, author = {Other}, year = 1999}
+`,
+		},
+		{
+			"only missing",
+			true,
+			`@string{me = "Bart Kiers"}
+@article{x, author = me, year = 2005}
+@article{y, author = {Kiers, B.}, year = 2005}
+@article{Someone2001, author = {Someone}, year = 2001}
+@article{Kiers2005, author = {Kiers, Bart}, year = 2005}
+@misc{z, crossref = {x}, author = {Other}, year = 1999}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := bibliography.NewBibFileFromReader(utils.NewRuneReaderFromString(keygenTestFile))
+			if err != nil {
+				panic(err)
+			}
+
+			if _, err := (Generator{OnlyMissing: tt.onlyMissing}).Apply(file); err != nil {
+				t.Errorf("Generator.Apply() error = %v", err)
+				return
+			}
+
+			var buffer bytes.Buffer
+			file.Write(&buffer)
+			if got := buffer.String(); got != tt.want {
+				t.Errorf("Generator.Apply() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerator_Apply_duplicates(t *testing.T) {
+	file, err := bibliography.NewBibFileFromString(`@book{Knuth1984, author = {Lamport, Leslie}, year = 1986}
+@article{knuth1984, author = {Kiers, Bart}, year = 2005}
+@book{x, author = {Knuth, Donald}, year = 1984}
+@misc{y, author = {Other}, year = 1999, crossref = {x}}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (Generator{}).Apply(file); err != nil {
+		t.Fatalf("Generator.Apply() error = %v", err)
+	}
+
+	want := `@book{Lamport1986, author = {Lamport, Leslie}, year = 1986}
+@article{Kiers2005, author = {Kiers, Bart}, year = 2005}
+@book{Knuth1984, author = {Knuth, Donald}, year = 1984}
+@misc{Other1999, author = {Other}, year = 1999, crossref = {Knuth1984}}
+`
+	var buffer bytes.Buffer
+	file.Write(&buffer)
+	if got := buffer.String(); got != want {
+		t.Errorf("Generator.Apply() wrote %q, want %q", got, want)
+	}
+}
+
+func Test_suffix(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "a"},
+		{1, "b"},
+		{25, "z"},
+		{26, "aa"},
+		{27, "ab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := suffix(tt.index); got != tt.want {
+				t.Errorf("suffix() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
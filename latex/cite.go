@@ -0,0 +1,247 @@
+// Package latex implements helpers for working with LaTeX sources and LaTeX-encoded text.
+package latex
+
+import (
+	"regexp"
+	"strings"
+)
+
+// citeCommand matches the names of commands that take citation keys.
+// This includes the standard LaTeX '\cite' and '\nocite', as well as natbib and biblatex variants.
+var citeCommand = regexp.MustCompile(`^(?i:[a-z]*cite[a-z]*)$`)
+
+// nonCiteCommands are commands matched by citeCommand that do not take citation keys
+var nonCiteCommands = map[string]struct{}{
+	"citetext":  {},
+	"citestyle": {},
+}
+
+// IsCiteCommand checks if name (without a leading backslash) is the name of a command taking citation keys.
+func IsCiteCommand(name string) bool {
+	if _, ok := nonCiteCommands[name]; ok {
+		return false
+	}
+	return citeCommand.MatchString(name)
+}
+
+// isMultiCiteCommand checks if the named command is a biblatex multicite command, e.g. '\cites'.
+// These take several groups of optional arguments and keys.
+func isMultiCiteCommand(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), "cites")
+}
+
+// Citation represents the use of a single citation key within a LaTeX source
+type Citation struct {
+	Command string // name of the command, without a leading backslash
+	Key     string // the cited key
+	Offset  int    // byte offset of Key within the source
+}
+
+// Citations returns all the citation keys used within the given LaTeX source, in order.
+// Comments are ignored.
+func Citations(source string) (citations []Citation) {
+	scanCitations(source, func(c Citation) string {
+		citations = append(citations, c)
+		return c.Key
+	})
+	return
+}
+
+// RenameCitations renames citation keys within '\cite'-like commands in the given LaTeX source.
+// renames maps old keys to new keys.
+// Keys are compared case-insensitively, the way BibTeX and bibliography.BibFile.RenameLabels compare labels.
+// Whitespace surrounding keys and all other parts of the source are left untouched.
+//
+// Returns the updated source and the number of keys renamed.
+func RenameCitations(source string, renames map[string]string) (result string, count int) {
+	normalized := make(map[string]string, len(renames))
+	for old, new := range renames {
+		normalized[strings.ToLower(old)] = new
+	}
+
+	result = scanCitations(source, func(c Citation) string {
+		if new, ok := normalized[strings.ToLower(c.Key)]; ok && new != c.Key {
+			count++
+			return new
+		}
+		return c.Key
+	})
+	return
+}
+
+// scanCitations scans source for citations and calls replace for each one.
+// Returns source with each key replaced by the return value of replace.
+func scanCitations(source string, replace func(c Citation) string) string {
+	var builder strings.Builder
+	builder.Grow(len(source))
+
+	i := 0
+	for i < len(source) {
+		switch source[i] {
+		case '%':
+			// skip comments until the end of the line
+			end := strings.IndexByte(source[i:], '\n')
+			if end == -1 {
+				end = len(source) - i
+			}
+			builder.WriteString(source[i : i+end])
+			i += end
+			continue
+		case '\\':
+		default:
+			builder.WriteByte(source[i])
+			i++
+			continue
+		}
+
+		// read the command name
+		start := i
+		i++
+		for i < len(source) && isLetter(source[i]) {
+			i++
+		}
+		name := source[start+1 : i]
+		if name == "" && i < len(source) {
+			// control symbol, e.g. '\%'
+			i++
+		}
+		builder.WriteString(source[start:i])
+
+		if !IsCiteCommand(name) {
+			continue
+		}
+
+		// skip a starred variant
+		if i < len(source) && source[i] == '*' {
+			builder.WriteByte('*')
+			i++
+		}
+
+		multi := isMultiCiteCommand(name)
+		for {
+			// skip whitespace between arguments
+			j := skipSpace(source, i)
+
+			if j >= len(source) {
+				break
+			}
+
+			// optional arguments and multicite pre- and postnotes
+			if source[j] == '[' || (multi && source[j] == '(') {
+				end := matchGroup(source, j)
+				if end == -1 {
+					break
+				}
+				builder.WriteString(source[i:end])
+				i = end
+				continue
+			}
+
+			if source[j] != '{' {
+				break
+			}
+
+			// mandatory argument containing the keys
+			end := matchGroup(source, j)
+			if end == -1 {
+				break
+			}
+			builder.WriteString(source[i : j+1])
+			replaceKeys(&builder, source, j+1, end-1, name, replace)
+			builder.WriteByte('}')
+			i = end
+
+			if !multi {
+				break
+			}
+		}
+	}
+
+	return builder.String()
+}
+
+// replaceKeys writes the comma-separated list of keys in source[start:end] to builder.
+// Each key is replaced by the return value of replace.
+func replaceKeys(builder *strings.Builder, source string, start, end int, command string, replace func(c Citation) string) {
+	for _, item := range strings.SplitAfter(source[start:end], ",") {
+		// split the item into leading space, key and trailing space + separator
+		key := strings.TrimRightFunc(strings.TrimSuffix(item, ","), isSpace)
+		trailing := item[len(key):]
+		trimmed := strings.TrimLeftFunc(key, isSpace)
+		leading := key[:len(key)-len(trimmed)]
+
+		builder.WriteString(leading)
+		if trimmed != "" {
+			builder.WriteString(replace(Citation{
+				Command: command,
+				Key:     trimmed,
+				Offset:  start + len(leading),
+			}))
+		}
+		builder.WriteString(trailing)
+
+		start += len(item)
+	}
+}
+
+// matchGroup returns the index after the group starting at source[start].
+// source[start] must be one of '{', '[' or '('.
+// Groups opened by '{' may be nested, for '[' and '(' only nested braces are taken into account.
+// If there is no matching closing character, returns -1.
+func matchGroup(source string, start int) int {
+	open := source[start]
+	var closing byte
+	switch open {
+	case '{':
+		closing = '}'
+	case '[':
+		closing = ']'
+	case '(':
+		closing = ')'
+	}
+
+	level := 0
+	for i := start + 1; i < len(source); i++ {
+		switch source[i] {
+		case '\\':
+			i++ // skip escaped characters
+		case '{':
+			level++
+		case '}':
+			if level == 0 && closing == '}' {
+				return i + 1
+			}
+			level--
+		case closing:
+			if level == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// skipSpace returns the index of the first non-space character in source at or after i.
+// Paragraph breaks, i.e. more than one newline, are not skipped.
+func skipSpace(source string, i int) int {
+	newlines := 0
+	for ; i < len(source) && isSpace(rune(source[i])); i++ {
+		if source[i] == '\n' {
+			newlines++
+			if newlines > 1 {
+				break
+			}
+		}
+	}
+	return i
+}
+
+// isLetter checks if b is an ascii letter
+func isLetter(b byte) bool {
+	return ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+// isSpace checks if r is a space as understood by TeX
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
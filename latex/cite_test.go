@@ -0,0 +1,56 @@
+package latex
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenameCitations(t *testing.T) {
+	renames := map[string]string{"old": "new", "a": "b"}
+	tests := []struct {
+		name      string
+		source    string
+		want      string
+		wantCount int
+	}{
+		{"no citations", `Hello \emph{old} world`, `Hello \emph{old} world`, 0},
+		{"simple cite", `see \cite{old}.`, `see \cite{new}.`, 1},
+		{"key list", `\cite{x, old ,a}`, `\cite{x, new ,b}`, 2},
+		{"optional arguments", `\citep[see][p.~3]{old}`, `\citep[see][p.~3]{new}`, 1},
+		{"starred", `\citet*{old}`, `\citet*{new}`, 1},
+		{"biblatex", `\parencite[3]{old} \textcite{a} \Autocite{old}`, `\parencite[3]{new} \textcite{b} \Autocite{new}`, 3},
+		{"multicite", `\cites(pre)(post)[a]{old}[b]{a} text {old}`, `\cites(pre)(post)[a]{new}[b]{b} text {old}`, 2},
+		{"nocite", `\nocite{old}`, `\nocite{new}`, 1},
+		{"space before argument", "\\cite\n  {old}", "\\cite\n  {new}", 1},
+		{"paragraph break", "\\cite\n\n{old}", "\\cite\n\n{old}", 0},
+		{"comment", "% \\cite{old}\n\\cite{old}", "% \\cite{old}\n\\cite{new}", 1},
+		{"escaped percent", `50\% \cite{old}`, `50\% \cite{new}`, 1},
+		{"citetext", `\citetext{old}`, `\citetext{old}`, 0},
+		{"case insensitive", `\cite{OLD} \nocite{A}`, `\cite{new} \nocite{b}`, 2},
+		{"already renamed", `\cite{new, b}`, `\cite{new, b}`, 0},
+		{"unbalanced", `\cite{old`, `\cite{old`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotCount := RenameCitations(tt.source, renames)
+			if got != tt.want {
+				t.Errorf("RenameCitations() got = %q, want %q", got, tt.want)
+			}
+			if gotCount != tt.wantCount {
+				t.Errorf("RenameCitations() count = %v, want %v", gotCount, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestCitations(t *testing.T) {
+	source := `\citep[p.~1]{a, b} and \citeauthor{c}`
+	want := []Citation{
+		{Command: "citep", Key: "a", Offset: 13},
+		{Command: "citep", Key: "b", Offset: 16},
+		{Command: "citeauthor", Key: "c", Offset: 35},
+	}
+	if got := Citations(source); !reflect.DeepEqual(got, want) {
+		t.Errorf("Citations() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,300 @@
+package latex
+
+import (
+	"strings"
+	"unicode"
+)
+
+// symbols maps LaTeX commands (without a leading backslash) to their unicode replacement
+var symbols = map[string]string{
+	// special letters
+	"ss": "ß", "SS": "SS",
+	"o": "ø", "O": "Ø",
+	"ae": "æ", "AE": "Æ",
+	"oe": "œ", "OE": "Œ",
+	"aa": "å", "AA": "Å",
+	"l": "ł", "L": "Ł",
+	"i": "ı", "j": "ȷ",
+	"dh": "ð", "DH": "Ð",
+	"th": "þ", "TH": "Þ",
+	"ng": "ŋ", "NG": "Ŋ",
+
+	// punctuation and symbols
+	"textendash":        "–",
+	"textemdash":        "—",
+	"textquoteleft":     "‘",
+	"textquoteright":    "’",
+	"textquotedblleft":  "“",
+	"textquotedblright": "”",
+	"guillemotleft":     "«",
+	"guillemotright":    "»",
+	"ldots":             "…",
+	"dots":              "…",
+	"textellipsis":      "…",
+	"copyright":         "©",
+	"textcopyright":     "©",
+	"textregistered":    "®",
+	"texttrademark":     "™",
+	"S":                 "§",
+	"P":                 "¶",
+	"euro":              "€",
+	"pounds":            "£",
+	"textdegree":        "°",
+	"textasciitilde":    "~",
+	"textasciicircum":   "^",
+	"textbackslash":     "\\",
+	"textunderscore":    "_",
+	"textbar":           "|",
+	"textless":          "<",
+	"textgreater":       ">",
+	"quad":              " ",
+	"qquad":             " ",
+
+	// logos
+	"TeX":    "TeX",
+	"LaTeX":  "LaTeX",
+	"BibTeX": "BibTeX",
+
+	// greek letters, typically in math mode
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ε", "varepsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "iota": "ι", "kappa": "κ", "lambda": "λ",
+	"mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π", "rho": "ρ", "sigma": "σ",
+	"tau": "τ", "upsilon": "υ", "phi": "φ", "varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π",
+	"Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+}
+
+// controlSymbols maps LaTeX control symbols (without a leading backslash) to their replacement
+var controlSymbols = map[rune]string{
+	'&': "&", '%': "%", '$': "$", '#': "#", '_': "_", '{': "{", '}': "}",
+	' ': " ", ',': " ", ';': " ", ':': " ", '!': "", '/': "", '-': "",
+	'\\': " ",
+}
+
+// Decode decodes LaTeX-encoded text into plain unicode text.
+//
+// Accents and special characters are replaced by their unicode equivalents, grouping braces and math delimiters are removed.
+// Unknown commands are dropped, but their arguments are retained; e.g. '\emph{word}' decodes to 'word'.
+// TeX ligatures for dashes and quotes, such as '--', are replaced by the characters they represent, and ties ('~') by non-breaking spaces.
+func Decode(s string) string {
+	d := decoder{input: []rune(s)}
+	d.decode(false)
+	return d.output.String()
+}
+
+// decoder holds the state of a Decode call
+type decoder struct {
+	input  []rune
+	pos    int
+	output strings.Builder
+}
+
+// decode decodes input until the end or until the closing brace of the current group.
+// group indicates if we are inside a group.
+func (d *decoder) decode(group bool) {
+	for d.pos < len(d.input) {
+		r := d.input[d.pos]
+		switch r {
+		case '{':
+			d.pos++
+			d.decode(true)
+		case '}':
+			d.pos++
+			if group {
+				return
+			}
+		case '$':
+			d.pos++
+		case '\\':
+			d.command()
+		case '~':
+			d.pos++
+			d.output.WriteRune('\u00A0') // non-breaking space
+		case '-':
+			switch {
+			case d.hasPrefix("---"):
+				d.pos += 3
+				d.output.WriteRune('—')
+			case d.hasPrefix("--"):
+				d.pos += 2
+				d.output.WriteRune('–')
+			default:
+				d.pos++
+				d.output.WriteRune('-')
+			}
+		case '`':
+			if d.hasPrefix("``") {
+				d.pos += 2
+				d.output.WriteRune('“')
+			} else {
+				d.pos++
+				d.output.WriteRune('‘')
+			}
+		case '\'':
+			if d.hasPrefix("''") {
+				d.pos += 2
+				d.output.WriteRune('”')
+			} else {
+				d.pos++
+				d.output.WriteRune('\'')
+			}
+		default:
+			d.pos++
+			d.output.WriteRune(r)
+		}
+	}
+}
+
+// hasPrefix checks if the remaining input starts with prefix
+func (d *decoder) hasPrefix(prefix string) bool {
+	i := d.pos
+	for _, r := range prefix {
+		if i >= len(d.input) || d.input[i] != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// command decodes a command starting with a backslash at the current position
+func (d *decoder) command() {
+	d.pos++ // skip the backslash
+	if d.pos >= len(d.input) {
+		return
+	}
+
+	name := d.readName()
+
+	// accents
+	if mark, ok := accentMarks[name]; ok {
+		d.accent(name, mark)
+		return
+	}
+
+	// control symbols
+	if runes := []rune(name); len(runes) == 1 && !isLetterRune(runes[0]) {
+		d.output.WriteString(controlSymbols[runes[0]])
+		return
+	}
+
+	// other commands
+	if replacement, ok := symbols[name]; ok {
+		d.output.WriteString(replacement)
+		return
+	}
+
+	// unknown commands are dropped, but their arguments are kept
+}
+
+// readName reads the name of a command, i.e. either a sequence of letters or a single non-letter.
+// Spaces following a sequence of letters are skipped.
+func (d *decoder) readName() string {
+	start := d.pos
+	if !isLetterRune(d.input[d.pos]) {
+		d.pos++
+		return string(d.input[start:d.pos])
+	}
+
+	for d.pos < len(d.input) && isLetterRune(d.input[d.pos]) {
+		d.pos++
+	}
+	name := string(d.input[start:d.pos])
+	d.skipSpace()
+	return name
+}
+
+// skipSpace skips spaces in the input
+func (d *decoder) skipSpace() {
+	for d.pos < len(d.input) && unicode.IsSpace(d.input[d.pos]) {
+		d.pos++
+	}
+}
+
+// accent decodes the argument of an accent command and applies the accent to it
+func (d *decoder) accent(name string, mark rune) {
+	d.skipSpace()
+	if d.pos >= len(d.input) {
+		return
+	}
+
+	// decode the argument
+	var arg string
+	switch d.input[d.pos] {
+	case '{':
+		d.pos++
+		inner := decoder{input: d.input, pos: d.pos}
+		inner.decode(true)
+		d.pos = inner.pos
+		arg = inner.output.String()
+	case '\\':
+		inner := decoder{input: d.input, pos: d.pos}
+		inner.command()
+		d.pos = inner.pos
+		arg = inner.output.String()
+	default:
+		arg = string(d.input[d.pos])
+		d.pos++
+	}
+
+	runes := []rune(arg)
+	if len(runes) == 0 {
+		d.output.WriteRune(mark)
+		return
+	}
+
+	// dotless i and j are only used to place accents
+	base := runes[0]
+	switch base {
+	case 'ı':
+		base = 'i'
+	case 'ȷ':
+		base = 'j'
+	}
+
+	if composed, ok := accentComposed[name][base]; ok {
+		d.output.WriteRune(composed)
+	} else {
+		d.output.WriteRune(base)
+		d.output.WriteRune(mark)
+	}
+	d.output.WriteString(string(runes[1:]))
+}
+
+// isLetterRune checks if r is an ascii letter
+func isLetterRune(r rune) bool {
+	return r <= unicode.MaxASCII && isLetter(byte(r))
+}
+
+// ASCII transliterates unicode text into ascii.
+// Accented latin characters are replaced by their base characters, and special letters by their common transliteration, e.g. 'ß' by 'ss'.
+// Typographic punctuation is replaced by its ascii equivalent; all other non-ascii characters are removed.
+func ASCII(s string) string {
+	var builder strings.Builder
+	builder.Grow(len(s))
+	for _, r := range s {
+		if r <= unicode.MaxASCII {
+			builder.WriteRune(r)
+			continue
+		}
+		if replacement, ok := asciiTransliterations[r]; ok {
+			builder.WriteString(replacement)
+			continue
+		}
+		switch r {
+		case '–', '—':
+			builder.WriteRune('-')
+		case '‘', '’':
+			builder.WriteRune('\'')
+		case '“', '”', '«', '»':
+			builder.WriteRune('"')
+		case '…':
+			builder.WriteString("...")
+		default:
+			if unicode.IsSpace(r) {
+				builder.WriteRune(' ')
+			}
+		}
+	}
+	return builder.String()
+}
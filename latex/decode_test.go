@@ -0,0 +1,55 @@
+package latex
+
+import "testing"
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain", `hello world`, "hello world"},
+		{"braces", `{Bib}\TeX`, "BibTeX"},
+		{"accent in braces", `Schr{\"{o}}der`, "Schröder"},
+		{"accent without braces", `G\"odel`, "Gödel"},
+		{"accent with letter command", `\v{S}koda \c c`, "Škoda ç"},
+		{"dotless i", `Ba\c{s}\i{}k \'{\i}`, "Başık í"},
+		{"uncomposable accent", `\"z`, "z̈"},
+		{"special letters", `Stra{\ss}e {\O}re \L{}\'od\'z`, "Straße Øre Łódź"},
+		{"escapes", `50\% \& \$5 \_`, "50% & $5 _"},
+		{"dashes and quotes", "pages 1--2 --- ``quoted''", "pages 1–2 — “quoted”"},
+		{"tie", `A.~Author`, "A.\u00A0Author"},
+		{"unknown commands", `\emph{very} \textbf{bold}`, "very bold"},
+		{"math", `$\alpha$-conversion`, "α-conversion"},
+		{"trailing backslash", `oops\`, "oops"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Decode(tt.input); got != tt.want {
+				t.Errorf("Decode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestASCII(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"ascii", "hello", "hello"},
+		{"accents", "Schröder Gödel Škoda", "Schroder Godel Skoda"},
+		{"special letters", "Straße Øre Łódź Æsir", "Strasse Ore Lodz AEsir"},
+		{"punctuation", "1–2 “quoted” …", "1-2 \"quoted\" ..."},
+		{"other scripts", "αβ", ""},
+		{"combining marks", "z̈", "z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ASCII(tt.input); got != tt.want {
+				t.Errorf("ASCII() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
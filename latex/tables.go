@@ -0,0 +1,97 @@
+package latex
+
+// This file contains tables of accented characters.
+
+// accentMarks maps LaTeX accent commands to the corresponding unicode combining character
+var accentMarks = map[string]rune{
+	"`": '\u0300',
+	`'`: '\u0301',
+	`^`: '\u0302',
+	`~`: '\u0303',
+	`=`: '\u0304',
+	`u`: '\u0306',
+	`.`: '\u0307',
+	`"`: '\u0308',
+	`r`: '\u030A',
+	`H`: '\u030B',
+	`v`: '\u030C',
+	`d`: '\u0323',
+	`c`: '\u0327',
+	`k`: '\u0328',
+	`b`: '\u0331',
+}
+
+// accentComposed maps LaTeX accent commands and base letters to precomposed characters
+var accentComposed = map[string]map[rune]rune{
+	"`": {'A': 'À', 'E': 'È', 'I': 'Ì', 'N': 'Ǹ', 'O': 'Ò', 'U': 'Ù', 'W': 'Ẁ', 'Y': 'Ỳ', 'a': 'à', 'e': 'è', 'i': 'ì', 'n': 'ǹ', 'o': 'ò', 'u': 'ù', 'w': 'ẁ', 'y': 'ỳ'},
+	`'`: {'A': 'Á', 'C': 'Ć', 'E': 'É', 'G': 'Ǵ', 'I': 'Í', 'K': 'Ḱ', 'L': 'Ĺ', 'M': 'Ḿ', 'N': 'Ń', 'O': 'Ó', 'P': 'Ṕ', 'R': 'Ŕ', 'S': 'Ś', 'U': 'Ú', 'W': 'Ẃ', 'Y': 'Ý', 'Z': 'Ź', 'a': 'á', 'c': 'ć', 'e': 'é', 'g': 'ǵ', 'i': 'í', 'k': 'ḱ', 'l': 'ĺ', 'm': 'ḿ', 'n': 'ń', 'o': 'ó', 'p': 'ṕ', 'r': 'ŕ', 's': 'ś', 'u': 'ú', 'w': 'ẃ', 'y': 'ý', 'z': 'ź'},
+	`^`: {'A': 'Â', 'C': 'Ĉ', 'E': 'Ê', 'G': 'Ĝ', 'H': 'Ĥ', 'I': 'Î', 'J': 'Ĵ', 'O': 'Ô', 'S': 'Ŝ', 'U': 'Û', 'W': 'Ŵ', 'Y': 'Ŷ', 'Z': 'Ẑ', 'a': 'â', 'c': 'ĉ', 'e': 'ê', 'g': 'ĝ', 'h': 'ĥ', 'i': 'î', 'j': 'ĵ', 'o': 'ô', 's': 'ŝ', 'u': 'û', 'w': 'ŵ', 'y': 'ŷ', 'z': 'ẑ'},
+	`~`: {'A': 'Ã', 'E': 'Ẽ', 'I': 'Ĩ', 'N': 'Ñ', 'O': 'Õ', 'U': 'Ũ', 'V': 'Ṽ', 'Y': 'Ỹ', 'a': 'ã', 'e': 'ẽ', 'i': 'ĩ', 'n': 'ñ', 'o': 'õ', 'u': 'ũ', 'v': 'ṽ', 'y': 'ỹ'},
+	`=`: {'A': 'Ā', 'E': 'Ē', 'G': 'Ḡ', 'I': 'Ī', 'O': 'Ō', 'U': 'Ū', 'Y': 'Ȳ', 'a': 'ā', 'e': 'ē', 'g': 'ḡ', 'i': 'ī', 'o': 'ō', 'u': 'ū', 'y': 'ȳ'},
+	`u`: {'A': 'Ă', 'E': 'Ĕ', 'G': 'Ğ', 'I': 'Ĭ', 'O': 'Ŏ', 'U': 'Ŭ', 'a': 'ă', 'e': 'ĕ', 'g': 'ğ', 'i': 'ĭ', 'o': 'ŏ', 'u': 'ŭ'},
+	`.`: {'A': 'Ȧ', 'B': 'Ḃ', 'C': 'Ċ', 'D': 'Ḋ', 'E': 'Ė', 'F': 'Ḟ', 'G': 'Ġ', 'H': 'Ḣ', 'I': 'İ', 'M': 'Ṁ', 'N': 'Ṅ', 'O': 'Ȯ', 'P': 'Ṗ', 'R': 'Ṙ', 'S': 'Ṡ', 'T': 'Ṫ', 'W': 'Ẇ', 'X': 'Ẋ', 'Y': 'Ẏ', 'Z': 'Ż', 'a': 'ȧ', 'b': 'ḃ', 'c': 'ċ', 'd': 'ḋ', 'e': 'ė', 'f': 'ḟ', 'g': 'ġ', 'h': 'ḣ', 'm': 'ṁ', 'n': 'ṅ', 'o': 'ȯ', 'p': 'ṗ', 'r': 'ṙ', 's': 'ṡ', 't': 'ṫ', 'w': 'ẇ', 'x': 'ẋ', 'y': 'ẏ', 'z': 'ż'},
+	`"`: {'A': 'Ä', 'E': 'Ë', 'H': 'Ḧ', 'I': 'Ï', 'O': 'Ö', 'U': 'Ü', 'W': 'Ẅ', 'X': 'Ẍ', 'Y': 'Ÿ', 'a': 'ä', 'e': 'ë', 'h': 'ḧ', 'i': 'ï', 'o': 'ö', 't': 'ẗ', 'u': 'ü', 'w': 'ẅ', 'x': 'ẍ', 'y': 'ÿ'},
+	`r`: {'A': 'Å', 'U': 'Ů', 'a': 'å', 'u': 'ů', 'w': 'ẘ', 'y': 'ẙ'},
+	`H`: {'O': 'Ő', 'U': 'Ű', 'o': 'ő', 'u': 'ű'},
+	`v`: {'A': 'Ǎ', 'C': 'Č', 'D': 'Ď', 'E': 'Ě', 'G': 'Ǧ', 'H': 'Ȟ', 'I': 'Ǐ', 'K': 'Ǩ', 'L': 'Ľ', 'N': 'Ň', 'O': 'Ǒ', 'R': 'Ř', 'S': 'Š', 'T': 'Ť', 'U': 'Ǔ', 'Z': 'Ž', 'a': 'ǎ', 'c': 'č', 'd': 'ď', 'e': 'ě', 'g': 'ǧ', 'h': 'ȟ', 'i': 'ǐ', 'j': 'ǰ', 'k': 'ǩ', 'l': 'ľ', 'n': 'ň', 'o': 'ǒ', 'r': 'ř', 's': 'š', 't': 'ť', 'u': 'ǔ', 'z': 'ž'},
+	`d`: {'A': 'Ạ', 'B': 'Ḅ', 'D': 'Ḍ', 'E': 'Ẹ', 'H': 'Ḥ', 'I': 'Ị', 'K': 'Ḳ', 'L': 'Ḷ', 'M': 'Ṃ', 'N': 'Ṇ', 'O': 'Ọ', 'R': 'Ṛ', 'S': 'Ṣ', 'T': 'Ṭ', 'U': 'Ụ', 'V': 'Ṿ', 'W': 'Ẉ', 'Y': 'Ỵ', 'Z': 'Ẓ', 'a': 'ạ', 'b': 'ḅ', 'd': 'ḍ', 'e': 'ẹ', 'h': 'ḥ', 'i': 'ị', 'k': 'ḳ', 'l': 'ḷ', 'm': 'ṃ', 'n': 'ṇ', 'o': 'ọ', 'r': 'ṛ', 's': 'ṣ', 't': 'ṭ', 'u': 'ụ', 'v': 'ṿ', 'w': 'ẉ', 'y': 'ỵ', 'z': 'ẓ'},
+	`c`: {'C': 'Ç', 'D': 'Ḑ', 'E': 'Ȩ', 'G': 'Ģ', 'H': 'Ḩ', 'K': 'Ķ', 'L': 'Ļ', 'N': 'Ņ', 'R': 'Ŗ', 'S': 'Ş', 'T': 'Ţ', 'c': 'ç', 'd': 'ḑ', 'e': 'ȩ', 'g': 'ģ', 'h': 'ḩ', 'k': 'ķ', 'l': 'ļ', 'n': 'ņ', 'r': 'ŗ', 's': 'ş', 't': 'ţ'},
+	`k`: {'A': 'Ą', 'E': 'Ę', 'I': 'Į', 'O': 'Ǫ', 'U': 'Ų', 'a': 'ą', 'e': 'ę', 'i': 'į', 'o': 'ǫ', 'u': 'ų'},
+	`b`: {'B': 'Ḇ', 'D': 'Ḏ', 'K': 'Ḵ', 'L': 'Ḻ', 'N': 'Ṉ', 'R': 'Ṟ', 'T': 'Ṯ', 'Z': 'Ẕ', 'b': 'ḇ', 'd': 'ḏ', 'h': 'ẖ', 'k': 'ḵ', 'l': 'ḻ', 'n': 'ṉ', 'r': 'ṟ', 't': 'ṯ', 'z': 'ẕ'},
+}
+
+// asciiTransliterations maps non-ascii latin characters to ascii replacements
+var asciiTransliterations = map[rune]string{
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Æ': "AE", 'Ç': "C", 'È': "E", 'É': "E",
+	'Ê': "E", 'Ë': "E", 'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I", 'Ð': "D", 'Ñ': "N", 'Ò': "O", 'Ó': "O",
+	'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O", 'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "U", 'Ý': "Y", 'Þ': "Th",
+	'ß': "ss", 'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae", 'ç': "c", 'è': "e",
+	'é': "e", 'ê': "e", 'ë': "e", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ð': "d", 'ñ': "n", 'ò': "o",
+	'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y",
+	'þ': "th", 'ÿ': "y", 'Ā': "A", 'ā': "a", 'Ă': "A", 'ă': "a", 'Ą': "A", 'ą': "a", 'Ć': "C", 'ć': "c",
+	'Ĉ': "C", 'ĉ': "c", 'Ċ': "C", 'ċ': "c", 'Č': "C", 'č': "c", 'Ď': "D", 'ď': "d", 'Đ': "D", 'đ': "d",
+	'Ē': "E", 'ē': "e", 'Ĕ': "E", 'ĕ': "e", 'Ė': "E", 'ė': "e", 'Ę': "E", 'ę': "e", 'Ě': "E", 'ě': "e",
+	'Ĝ': "G", 'ĝ': "g", 'Ğ': "G", 'ğ': "g", 'Ġ': "G", 'ġ': "g", 'Ģ': "G", 'ģ': "g", 'Ĥ': "H", 'ĥ': "h",
+	'Ħ': "H", 'ħ': "h", 'Ĩ': "I", 'ĩ': "i", 'Ī': "I", 'ī': "i", 'Ĭ': "I", 'ĭ': "i", 'Į': "I", 'į': "i",
+	'İ': "I", 'ı': "i", 'Ĵ': "J", 'ĵ': "j", 'Ķ': "K", 'ķ': "k", 'ĸ': "q", 'Ĺ': "L", 'ĺ': "l", 'Ļ': "L",
+	'ļ': "l", 'Ľ': "L", 'ľ': "l", 'Ŀ': "L", 'ŀ': "l", 'Ł': "L", 'ł': "l", 'Ń': "N", 'ń': "n", 'Ņ': "N",
+	'ņ': "n", 'Ň': "N", 'ň': "n", 'ŉ': "n", 'Ŋ': "NG", 'ŋ': "ng", 'Ō': "O", 'ō': "o", 'Ŏ': "O", 'ŏ': "o",
+	'Ő': "O", 'ő': "o", 'Œ': "OE", 'œ': "oe", 'Ŕ': "R", 'ŕ': "r", 'Ŗ': "R", 'ŗ': "r", 'Ř': "R", 'ř': "r",
+	'Ś': "S", 'ś': "s", 'Ŝ': "S", 'ŝ': "s", 'Ş': "S", 'ş': "s", 'Š': "S", 'š': "s", 'Ţ': "T", 'ţ': "t",
+	'Ť': "T", 'ť': "t", 'Ũ': "U", 'ũ': "u", 'Ū': "U", 'ū': "u", 'Ŭ': "U", 'ŭ': "u", 'Ů': "U", 'ů': "u",
+	'Ű': "U", 'ű': "u", 'Ų': "U", 'ų': "u", 'Ŵ': "W", 'ŵ': "w", 'Ŷ': "Y", 'ŷ': "y", 'Ÿ': "Y", 'Ź': "Z",
+	'ź': "z", 'Ż': "Z", 'ż': "z", 'Ž': "Z", 'ž': "z", 'Ơ': "O", 'ơ': "o", 'Ư': "U", 'ư': "u", 'Ǎ': "A",
+	'ǎ': "a", 'Ǐ': "I", 'ǐ': "i", 'Ǒ': "O", 'ǒ': "o", 'Ǔ': "U", 'ǔ': "u", 'Ǖ': "U", 'ǖ': "u", 'Ǘ': "U",
+	'ǘ': "u", 'Ǚ': "U", 'ǚ': "u", 'Ǜ': "U", 'ǜ': "u", 'Ǟ': "A", 'ǟ': "a", 'Ǡ': "A", 'ǡ': "a", 'Ǧ': "G",
+	'ǧ': "g", 'Ǩ': "K", 'ǩ': "k", 'Ǫ': "O", 'ǫ': "o", 'Ǭ': "O", 'ǭ': "o", 'ǰ': "j", 'Ǵ': "G", 'ǵ': "g",
+	'Ǹ': "N", 'ǹ': "n", 'Ǻ': "A", 'ǻ': "a", 'Ȁ': "A", 'ȁ': "a", 'Ȃ': "A", 'ȃ': "a", 'Ȅ': "E", 'ȅ': "e",
+	'Ȇ': "E", 'ȇ': "e", 'Ȉ': "I", 'ȉ': "i", 'Ȋ': "I", 'ȋ': "i", 'Ȍ': "O", 'ȍ': "o", 'Ȏ': "O", 'ȏ': "o",
+	'Ȑ': "R", 'ȑ': "r", 'Ȓ': "R", 'ȓ': "r", 'Ȕ': "U", 'ȕ': "u", 'Ȗ': "U", 'ȗ': "u", 'Ș': "S", 'ș': "s",
+	'Ț': "T", 'ț': "t", 'Ȟ': "H", 'ȟ': "h", 'Ȧ': "A", 'ȧ': "a", 'Ȩ': "E", 'ȩ': "e", 'Ȫ': "O", 'ȫ': "o",
+	'Ȭ': "O", 'ȭ': "o", 'Ȯ': "O", 'ȯ': "o", 'Ȱ': "O", 'ȱ': "o", 'Ȳ': "Y", 'ȳ': "y", 'ȷ': "j", 'Ḁ': "A",
+	'ḁ': "a", 'Ḃ': "B", 'ḃ': "b", 'Ḅ': "B", 'ḅ': "b", 'Ḇ': "B", 'ḇ': "b", 'Ḉ': "C", 'ḉ': "c", 'Ḋ': "D",
+	'ḋ': "d", 'Ḍ': "D", 'ḍ': "d", 'Ḏ': "D", 'ḏ': "d", 'Ḑ': "D", 'ḑ': "d", 'Ḓ': "D", 'ḓ': "d", 'Ḕ': "E",
+	'ḕ': "e", 'Ḗ': "E", 'ḗ': "e", 'Ḙ': "E", 'ḙ': "e", 'Ḛ': "E", 'ḛ': "e", 'Ḝ': "E", 'ḝ': "e", 'Ḟ': "F",
+	'ḟ': "f", 'Ḡ': "G", 'ḡ': "g", 'Ḣ': "H", 'ḣ': "h", 'Ḥ': "H", 'ḥ': "h", 'Ḧ': "H", 'ḧ': "h", 'Ḩ': "H",
+	'ḩ': "h", 'Ḫ': "H", 'ḫ': "h", 'Ḭ': "I", 'ḭ': "i", 'Ḯ': "I", 'ḯ': "i", 'Ḱ': "K", 'ḱ': "k", 'Ḳ': "K",
+	'ḳ': "k", 'Ḵ': "K", 'ḵ': "k", 'Ḷ': "L", 'ḷ': "l", 'Ḹ': "L", 'ḹ': "l", 'Ḻ': "L", 'ḻ': "l", 'Ḽ': "L",
+	'ḽ': "l", 'Ḿ': "M", 'ḿ': "m", 'Ṁ': "M", 'ṁ': "m", 'Ṃ': "M", 'ṃ': "m", 'Ṅ': "N", 'ṅ': "n", 'Ṇ': "N",
+	'ṇ': "n", 'Ṉ': "N", 'ṉ': "n", 'Ṋ': "N", 'ṋ': "n", 'Ṍ': "O", 'ṍ': "o", 'Ṏ': "O", 'ṏ': "o", 'Ṑ': "O",
+	'ṑ': "o", 'Ṓ': "O", 'ṓ': "o", 'Ṕ': "P", 'ṕ': "p", 'Ṗ': "P", 'ṗ': "p", 'Ṙ': "R", 'ṙ': "r", 'Ṛ': "R",
+	'ṛ': "r", 'Ṝ': "R", 'ṝ': "r", 'Ṟ': "R", 'ṟ': "r", 'Ṡ': "S", 'ṡ': "s", 'Ṣ': "S", 'ṣ': "s", 'Ṥ': "S",
+	'ṥ': "s", 'Ṧ': "S", 'ṧ': "s", 'Ṩ': "S", 'ṩ': "s", 'Ṫ': "T", 'ṫ': "t", 'Ṭ': "T", 'ṭ': "t", 'Ṯ': "T",
+	'ṯ': "t", 'Ṱ': "T", 'ṱ': "t", 'Ṳ': "U", 'ṳ': "u", 'Ṵ': "U", 'ṵ': "u", 'Ṷ': "U", 'ṷ': "u", 'Ṹ': "U",
+	'ṹ': "u", 'Ṻ': "U", 'ṻ': "u", 'Ṽ': "V", 'ṽ': "v", 'Ṿ': "V", 'ṿ': "v", 'Ẁ': "W", 'ẁ': "w", 'Ẃ': "W",
+	'ẃ': "w", 'Ẅ': "W", 'ẅ': "w", 'Ẇ': "W", 'ẇ': "w", 'Ẉ': "W", 'ẉ': "w", 'Ẋ': "X", 'ẋ': "x", 'Ẍ': "X",
+	'ẍ': "x", 'Ẏ': "Y", 'ẏ': "y", 'Ẑ': "Z", 'ẑ': "z", 'Ẓ': "Z", 'ẓ': "z", 'Ẕ': "Z", 'ẕ': "z", 'ẖ': "h",
+	'ẗ': "t", 'ẘ': "w", 'ẙ': "y", 'ẞ': "SS", 'Ạ': "A", 'ạ': "a", 'Ả': "A", 'ả': "a", 'Ấ': "A", 'ấ': "a",
+	'Ầ': "A", 'ầ': "a", 'Ẩ': "A", 'ẩ': "a", 'Ẫ': "A", 'ẫ': "a", 'Ậ': "A", 'ậ': "a", 'Ắ': "A", 'ắ': "a",
+	'Ằ': "A", 'ằ': "a", 'Ẳ': "A", 'ẳ': "a", 'Ẵ': "A", 'ẵ': "a", 'Ặ': "A", 'ặ': "a", 'Ẹ': "E", 'ẹ': "e",
+	'Ẻ': "E", 'ẻ': "e", 'Ẽ': "E", 'ẽ': "e", 'Ế': "E", 'ế': "e", 'Ề': "E", 'ề': "e", 'Ể': "E", 'ể': "e",
+	'Ễ': "E", 'ễ': "e", 'Ệ': "E", 'ệ': "e", 'Ỉ': "I", 'ỉ': "i", 'Ị': "I", 'ị': "i", 'Ọ': "O", 'ọ': "o",
+	'Ỏ': "O", 'ỏ': "o", 'Ố': "O", 'ố': "o", 'Ồ': "O", 'ồ': "o", 'Ổ': "O", 'ổ': "o", 'Ỗ': "O", 'ỗ': "o",
+	'Ộ': "O", 'ộ': "o", 'Ớ': "O", 'ớ': "o", 'Ờ': "O", 'ờ': "o", 'Ở': "O", 'ở': "o", 'Ỡ': "O", 'ỡ': "o",
+	'Ợ': "O", 'ợ': "o", 'Ụ': "U", 'ụ': "u", 'Ủ': "U", 'ủ': "u", 'Ứ': "U", 'ứ': "u", 'Ừ': "U", 'ừ': "u",
+	'Ử': "U", 'ử': "u", 'Ữ': "U", 'ữ': "u", 'Ự': "U", 'ự': "u", 'Ỳ': "Y", 'ỳ': "y", 'Ỵ': "Y", 'ỵ': "y",
+	'Ỷ': "Y", 'ỷ': "y", 'Ỹ': "Y", 'ỹ': "y",
+}
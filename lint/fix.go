@@ -0,0 +1,150 @@
+package lint
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// edit is a replacement of the runes from start (inclusive) to end (exclusive)
+type edit struct {
+	start, end int
+	text       string
+}
+
+// overlaps checks if two edits touch the same part of the source.
+// Edits starting at the same position always overlap, as their order would be ambiguous.
+func (e edit) overlaps(other edit) bool {
+	return e.start == other.start || (e.start < other.end && other.start < e.end)
+}
+
+// ApplyFixes applies the first fix of each finding to source, and returns the changed source.
+// The ranges of fixes refer to runes within source, see utils.ReaderPosition.RuneOffset.
+//
+// Fixes are applied in order of findings.
+// A fix that overlaps a previously applied fix is skipped, as is a fix with a range outside of source.
+// Applied is the number of applied fixes.
+func ApplyFixes(source string, findings []Finding) (fixed string, applied int) {
+	runes := []rune(source)
+
+	var edits []edit
+	for _, finding := range findings {
+		if len(finding.Fixes) == 0 {
+			continue
+		}
+
+		candidates, ok := newEdits(runes, finding.Fixes[0])
+		if !ok || anyOverlap(candidates, edits) {
+			continue
+		}
+		edits = append(edits, candidates...)
+		applied++
+	}
+
+	// apply edits from the back, so that earlier offsets remain valid
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	for _, e := range edits {
+		runes = append(runes[:e.start], append([]rune(e.text), runes[e.end:]...)...)
+	}
+	return string(runes), applied
+}
+
+// newEdits turns the replacements of fix into edits of runes.
+// If a replacement is out of range, or replacements overlap each other, returns ok = false.
+func newEdits(runes []rune, fix Fix) (edits []edit, ok bool) {
+	for _, r := range fix.Replacements {
+		e := edit{start: int(r.Range.Start.RuneOffset), text: r.Text}
+		switch {
+		case r.Insert:
+			e.end = e.start
+		case r.Range.End.EOF:
+			e.end = int(r.Range.End.RuneOffset)
+		default:
+			e.end = int(r.Range.End.RuneOffset) + 1
+
+			// the reader turns '\r\n' and '\n\r' into a single character
+			if e.end < len(runes) && isLineBreak(runes[e.end-1], runes[e.end]) {
+				e.end++
+			}
+		}
+		if e.start > e.end || e.end > len(runes) || anyOverlap([]edit{e}, edits) {
+			return nil, false
+		}
+		edits = append(edits, e)
+	}
+	return edits, true
+}
+
+// isLineBreak checks if a and b form a two-character line break
+func isLineBreak(a, b rune) bool {
+	return (a == '\r' && b == '\n') || (a == '\n' && b == '\r')
+}
+
+// anyOverlap checks if any of candidates overlaps any of edits
+func anyOverlap(candidates, edits []edit) bool {
+	for _, c := range candidates {
+		for _, e := range edits {
+			if c.overlaps(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ErrMixedLineEndings is returned by FixFile for files that mix different line breaks
+var ErrMixedLineEndings = errors.New("can not fix a file with mixed line endings")
+
+// FixFile applies the fixes of findings to file, see ApplyFixes.
+// Findings must have been created from file, as it was read.
+//
+// The fixed source is parsed into a new BibFile, keeping the line ending and encoding of file.
+// Parts of file that are not touched by a fix keep their formatting.
+//
+// Fixes refer to the source of file, which is written again using a single line ending, see bibliography.BibFile.LineEnding.
+// If that does not reproduce the source, because it mixes different line breaks, returns ErrMixedLineEndings.
+func FixFile(file *bibliography.BibFile, findings []Finding) (fixed *bibliography.BibFile, applied int, err error) {
+	// write the source as utf-8, as offsets refer to decoded runes
+	plain := *file
+	plain.Encoding, plain.BOM = "", false
+
+	var buffer bytes.Buffer
+	if err := plain.Write(&buffer); err != nil {
+		return nil, 0, err
+	}
+
+	// check that offsets within the written source match those of file
+	written, err := bibliography.NewBibFileFromReader(utils.NewRuneReaderFromString(buffer.String()))
+	if err != nil {
+		return nil, 0, err
+	}
+	if !sameSources(file, written) {
+		return nil, 0, ErrMixedLineEndings
+	}
+
+	source, applied := ApplyFixes(buffer.String(), findings)
+	fixed, err = bibliography.NewBibFileFromReader(utils.NewRuneReaderFromString(source))
+	if err != nil {
+		return nil, 0, err
+	}
+	fixed.Encoding, fixed.BOM = file.Encoding, file.BOM
+	return fixed, applied, nil
+}
+
+// sameSources checks if the entries and suffixes of two files have the same source ranges
+func sameSources(a, b *bibliography.BibFile) bool {
+	if len(a.Entries) != len(b.Entries) || a.Suffix.Source != b.Suffix.Source {
+		return false
+	}
+	for i, entry := range a.Entries {
+		if entry.Source != b.Entries[i].Source {
+			return false
+		}
+	}
+	return true
+}
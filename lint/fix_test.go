@@ -0,0 +1,168 @@
+package lint
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// at returns a position at the given rune offset
+func at(offset uint) utils.ReaderPosition {
+	return utils.ReaderPosition{RuneOffset: offset}
+}
+
+// fixOf returns a finding with a single fix consisting of replacements
+func fixOf(replacements ...Replacement) Finding {
+	return Finding{Fixes: []Fix{{Replacements: replacements}}}
+}
+
+func TestApplyFixes(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		findings    []Finding
+		want        string
+		wantApplied int
+	}{
+		{
+			"replace and insert",
+			"hello world",
+			[]Finding{
+				fixOf(Replacement{Range: utils.ReaderRange{Start: at(6), End: at(10)}, Text: "there"}),
+				fixOf(Replacement{Range: utils.ReaderRange{Start: at(0)}, Insert: true, Text: "oh, "}),
+			},
+			"oh, hello there",
+			2,
+		},
+		{
+			"overlapping fixes",
+			"hello world",
+			[]Finding{
+				fixOf(Replacement{Range: utils.ReaderRange{Start: at(0), End: at(4)}, Text: "howdy"}),
+				fixOf(Replacement{Range: utils.ReaderRange{Start: at(4), End: at(6)}, Text: "!"}),
+				{},
+			},
+			"howdy world",
+			1,
+		},
+		{
+			"fix is applied entirely or not at all",
+			"abc",
+			[]Finding{
+				fixOf(Replacement{Range: utils.ReaderRange{Start: at(1), End: at(1)}, Text: "B"}),
+				fixOf(Replacement{Range: utils.ReaderRange{Start: at(0), End: at(0)}, Text: "A"}, Replacement{Range: utils.ReaderRange{Start: at(1), End: at(1)}, Text: "X"}),
+			},
+			"aBc",
+			1,
+		},
+		{
+			"out of range",
+			"abc",
+			[]Finding{fixOf(Replacement{Range: utils.ReaderRange{Start: at(2), End: at(3)}})},
+			"abc",
+			0,
+		},
+		{
+			"line break",
+			"a\r\nb",
+			[]Finding{fixOf(Replacement{Range: utils.ReaderRange{Start: at(0), End: at(1)}, Text: "A"})},
+			"Ab",
+			1,
+		},
+		{
+			"multi-byte runes",
+			"café au lait",
+			[]Finding{fixOf(Replacement{Range: utils.ReaderRange{Start: at(5), End: at(6)}, Text: "ou"})},
+			"café ou lait",
+			1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotApplied := ApplyFixes(tt.source, tt.findings)
+			if got != tt.want {
+				t.Errorf("ApplyFixes() got = %q, want %q", got, tt.want)
+			}
+			if gotApplied != tt.wantApplied {
+				t.Errorf("ApplyFixes() applied = %v, want %v", gotApplied, tt.wantApplied)
+			}
+		})
+	}
+}
+
+func TestFixFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		want        string
+		wantApplied int
+	}{
+		{
+			"nothing to fix",
+			"@misc{a, title = {A}}",
+			"@misc{a, title = {A}}",
+			0,
+		},
+		{
+			"all fixes",
+			"@misc{ a ,\n  pages =  {1 - 10},\n  month = {february},\n  pages = {1 - 10}}\n",
+			"@misc{a,\n  pages =  {1--10},\n  month = feb,}\n",
+			4,
+		},
+		{
+			"duplicate field with different value",
+			"@misc{a, title = {A}, title = {B}}",
+			"@misc{a, title = {A}, title = {B}}",
+			0,
+		},
+		{
+			"duplicate field followed by another field",
+			"@misc{a,\n\tyear = 2020,\n\tyear = {2020},\n\tyear = 2020,\n\tnote = {x}\n}",
+			"@misc{a,\n\tyear = 2020,\n\tyear = {2020},\n\tnote = {x}\n}",
+			1,
+		},
+		{
+			"windows line endings",
+			"@misc{a,\r\n  month = \"Jan\",\r\n  pages = {3-4}\r\n}\r\n",
+			"@misc{a,\r\n  month = jan,\r\n  pages = {3--4}\r\n}\r\n",
+			2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := bibliography.NewBibFileFromReader(utils.NewRuneReaderFromString(tt.input))
+			if err != nil {
+				t.Fatalf("NewBibFileFromReader() error = %v", err)
+			}
+
+			fixed, gotApplied, err := FixFile(file, Lint(file))
+			if err != nil {
+				t.Fatalf("FixFile() error = %v", err)
+			}
+			if gotApplied != tt.wantApplied {
+				t.Errorf("FixFile() applied = %v, want %v", gotApplied, tt.wantApplied)
+			}
+
+			var buffer bytes.Buffer
+			if err := fixed.Write(&buffer); err != nil {
+				t.Fatalf("BibFile.Write() error = %v", err)
+			}
+			if got := buffer.String(); got != tt.want {
+				t.Errorf("FixFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFixFile_mixedLineEndings(t *testing.T) {
+	file, err := bibliography.NewBibFileFromReader(utils.NewRuneReaderFromString("@article{a,\n  title = {x},\r\n  pages = {1-10},\r\n  month = jan,\r\n}\n"))
+	if err != nil {
+		t.Fatalf("NewBibFileFromReader() error = %v", err)
+	}
+	if _, _, err := FixFile(file, Lint(file)); !errors.Is(err, ErrMixedLineEndings) {
+		t.Errorf("FixFile() error = %v, want %v", err, ErrMixedLineEndings)
+	}
+}
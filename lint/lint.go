@@ -0,0 +1,92 @@
+// Package lint checks BibFiles for common problems, and reports them in different formats.
+package lint
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// Rule is a single check performed by the linter
+type Rule struct {
+	ID          string         // stable identifier of this rule, such as "duplicate-label"
+	Description string         // short description of the problem found by this rule
+	Severity    utils.Severity // severity of findings of this rule
+
+	check func(file *bibliography.BibFile, report func(Finding))
+}
+
+// Finding is a problem found within a file
+type Finding struct {
+	Rule *Rule // rule that produced this finding
+	utils.Diagnostic
+
+	Code  utils.ErrorCode // code of the underlying error, for parse errors
+	Fixes []Fix           // possible fixes of this finding, if any
+}
+
+// Fix is a possible fix of a finding, consisting of replacements to be made within the source
+type Fix struct {
+	Description  string
+	Replacements []Replacement
+}
+
+// Replacement replaces part of the source with new text
+type Replacement struct {
+	Range utils.ReaderRange // inclusive range of source to replace
+
+	// Insert indicates that nothing is replaced.
+	// Instead, Text is inserted before Range.Start.
+	Insert bool
+	Text   string
+}
+
+// ParseErrorRule is the rule of findings created from parse errors
+var ParseErrorRule = &Rule{
+	ID:          "parse-error",
+	Description: "The file is not syntactically valid",
+	Severity:    utils.SeverityError,
+}
+
+// Rules are the rules checked by Lint
+var Rules = []*Rule{
+	duplicateLabelRule,
+	duplicateFieldRule,
+	undefinedMacroRule,
+	missingFieldRule,
+	labelWhitespaceRule,
+	pageRangeRule,
+	monthRule,
+}
+
+// Lint checks file using all Rules.
+// Findings are ordered by their position in file.
+func Lint(file *bibliography.BibFile) (findings []Finding) {
+	for _, rule := range Rules {
+		rule.check(file, func(finding Finding) {
+			finding.Rule = rule
+			finding.Severity = rule.Severity
+			findings = append(findings, finding)
+		})
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Range.Start.RuneOffset < findings[j].Range.Start.RuneOffset
+	})
+	return
+}
+
+// NewFindingFromError creates a new finding from an error that occurred while parsing a file.
+// See utils.NewDiagnosticFromError.
+func NewFindingFromError(err error) (finding Finding) {
+	finding.Rule = ParseErrorRule
+	finding.Diagnostic = utils.NewDiagnosticFromError(err)
+
+	var rerr *utils.ReaderError
+	if errors.As(err, &rerr) {
+		finding.Code = rerr.Code
+	}
+	return
+}
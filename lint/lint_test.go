@@ -0,0 +1,68 @@
+package lint
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"no problems", "@misc{a, title = {A}}\n@misc{b, title = {B}, month = jan}", nil},
+		{"duplicate label", "@misc{a, title = {A}}\n@misc{A, title = {B}}", []string{"2:7 duplicate-label: duplicate label \"A\""}},
+		{"duplicate label of special entry", "@string{a = {A}}\n@string{a = {B}}", nil},
+		{"duplicate field", "@misc{a, title = {A}, Title = {B}}", []string{"1:23 duplicate-field: duplicate field \"Title\""}},
+		{"undefined macro", "@string{b = {B}}\n@misc{a, title = b # c, year = 2020}", []string{"2:22 undefined-macro: undefined macro \"c\""}},
+		{"undefined macro in preamble", "@preamble{a # b}", nil},
+		{"missing field", "@article{a, author = {A}, journal = {J}, year = 2020}", []string{"1:2 missing-field: missing required field \"title\""}},
+		{"missing alternatives", "@book{a, title = {T}, publisher = {P}, year = 2020}", []string{"1:2 missing-field: missing required field \"author\" or \"editor\""}},
+		{"missing field with crossref", "@inproceedings{a, crossref = {b}}", nil},
+		{"unknown kind", "@software{a}", nil},
+		{"label whitespace", "@misc{ a ,}", []string{"1:8 label-whitespace: label \"a\" is surrounded by whitespace"}},
+		{"page range", "@misc{a, pages = {1-10}}\n@misc{b, pages = {1--10}}", []string{"1:18 page-range: page range \"1-10\" should use '--'"}},
+		{"month", "@misc{a, month = {January}}\n@misc{b, month = Feb}\n@misc{c, month = mar}", []string{"1:18 month: month {January} should be given as jan", "2:18 month: month Feb should be given as feb"}},
+		{"month macro", "@string{Spring = {March}}\n@misc{a, month = Spring}", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := bibliography.NewBibFileFromReader(utils.NewRuneReaderFromString(tt.input))
+			if err != nil {
+				t.Fatalf("NewBibFileFromReader() error = %v", err)
+			}
+
+			var got []string
+			for _, finding := range Lint(file) {
+				start := finding.Range.Start
+				got = append(got, fmt.Sprintf("%d:%d %s: %s", start.Line+1, start.Column+1, finding.Rule.ID, finding.Message))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFindingFromError(t *testing.T) {
+	_, err := bibliography.NewBibFileFromReader(utils.NewRuneReaderFromString("@book{a,\n  title = {x"))
+	if err == nil {
+		t.Fatal("NewBibFileFromReader() error = nil, want an error")
+	}
+
+	got := NewFindingFromError(err)
+	if got.Rule != ParseErrorRule {
+		t.Errorf("NewFindingFromError().Rule = %v, want %v", got.Rule.ID, ParseErrorRule.ID)
+	}
+	if got.Code != utils.CodeUnbalancedBrace {
+		t.Errorf("NewFindingFromError().Code = %v, want %v", got.Code, utils.CodeUnbalancedBrace)
+	}
+	if want := utils.NewDiagnosticFromError(err); !reflect.DeepEqual(got.Diagnostic, want) {
+		t.Errorf("NewFindingFromError().Diagnostic = %v, want %v", got.Diagnostic, want)
+	}
+}
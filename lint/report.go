@@ -0,0 +1,333 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// Format is an output format of a Reporter
+type Format string
+
+// supported formats
+const (
+	FormatText  Format = "text"  // human-readable diagnostics, see utils.Renderer
+	FormatJSON  Format = "json"  // one JSON object per line and finding
+	FormatSARIF Format = "sarif" // a single SARIF 2.1.0 log
+)
+
+// ParseFormat parses the name of a format
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(name); format {
+	case FormatText, FormatJSON, FormatSARIF:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown format %q", name)
+}
+
+// String returns the name of this format
+func (format Format) String() string {
+	return string(format)
+}
+
+// Set sets format to the format with the given name, see ParseFormat.
+// Together with String, this allows using a format as a command line flag, see flag.Var.
+func (format *Format) Set(name string) (err error) {
+	*format, err = ParseFormat(name)
+	return
+}
+
+// Reporter writes findings in a specific format
+type Reporter interface {
+	// Report reports the findings within a single file.
+	// Source is the content of the file, and may be empty if it is not known.
+	Report(filename, source string, findings []Finding) error
+
+	// Close finishes the report, and must be called after all files have been reported
+	Close() error
+}
+
+// NewReporter creates a new reporter writing the given format to writer.
+// If color is true, text reports use ANSI escape sequences.
+func NewReporter(writer io.Writer, format Format, color bool) Reporter {
+	switch format {
+	case FormatJSON:
+		return &jsonReporter{encoder: json.NewEncoder(writer)}
+	case FormatSARIF:
+		return &sarifReporter{writer: writer}
+	}
+	return &textReporter{writer: writer, color: color}
+}
+
+// ReportError reports an error that occurred while parsing source to writer, see NewFindingFromError.
+// This allows commands to report parse errors in the same formats as findings.
+func ReportError(writer io.Writer, format Format, filename, source string, err error) error {
+	reporter := NewReporter(writer, format, false)
+	if err := reporter.Report(filename, source, []Finding{NewFindingFromError(err)}); err != nil {
+		return err
+	}
+	return reporter.Close()
+}
+
+// textReporter renders findings as human-readable diagnostics
+type textReporter struct {
+	writer io.Writer
+	color  bool
+}
+
+func (tr *textReporter) Report(filename, source string, findings []Finding) error {
+	renderer := &utils.Renderer{Filename: filename, Source: source, Color: tr.color}
+	for _, finding := range findings {
+		diagnostic := finding.Diagnostic
+		diagnostic.Message = fmt.Sprintf("%s [%s]", diagnostic.Message, finding.Rule.ID)
+		if err := renderer.Render(tr.writer, diagnostic); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tr *textReporter) Close() error {
+	return nil
+}
+
+// jsonReporter writes one JSON object per finding
+type jsonReporter struct {
+	encoder *json.Encoder
+}
+
+// jsonFinding is a finding as written by jsonReporter
+type jsonFinding struct {
+	File     string            `json:"file"`
+	Rule     string            `json:"rule"`
+	Severity string            `json:"severity"`
+	Code     utils.ErrorCode   `json:"code,omitempty"`
+	Message  string            `json:"message"`
+	Range    utils.ReaderRange `json:"range"`
+	Notes    []string          `json:"notes,omitempty"`
+	Fixes    []jsonFix         `json:"fixes,omitempty"`
+}
+
+type jsonFix struct {
+	Description  string            `json:"description"`
+	Replacements []jsonReplacement `json:"replacements"`
+}
+
+type jsonReplacement struct {
+	Range  utils.ReaderRange `json:"range"`
+	Insert bool              `json:"insert,omitempty"`
+	Text   string            `json:"text"`
+}
+
+func (jr *jsonReporter) Report(filename, source string, findings []Finding) error {
+	for _, finding := range findings {
+		jf := jsonFinding{
+			File:     filename,
+			Rule:     finding.Rule.ID,
+			Severity: finding.Severity.String(),
+			Code:     finding.Code,
+			Message:  finding.Message,
+			Range:    finding.Range,
+			Notes:    finding.Notes,
+		}
+		for _, fix := range finding.Fixes {
+			jfix := jsonFix{Description: fix.Description, Replacements: []jsonReplacement{}}
+			for _, r := range fix.Replacements {
+				jfix.Replacements = append(jfix.Replacements, jsonReplacement{Range: r.Range, Insert: r.Insert, Text: r.Text})
+			}
+			jf.Fixes = append(jf.Fixes, jfix)
+		}
+
+		if err := jr.encoder.Encode(jf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (jr *jsonReporter) Close() error {
+	return nil
+}
+
+// sarifReporter collects findings, and writes them as a single SARIF log when closed.
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+type sarifReporter struct {
+	writer  io.Writer
+	results []sarifResult
+}
+
+// the subset of SARIF used by sarifReporter
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool       sarifTool     `json:"tool"`
+		ColumnKind string        `json:"columnKind"`
+		Results    []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID                   string             `json:"id"`
+		ShortDescription     sarifMessage       `json:"shortDescription"`
+		DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+	}
+	sarifConfiguration struct {
+		Level string `json:"level"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID     string            `json:"ruleId"`
+		RuleIndex  int               `json:"ruleIndex"`
+		Level      string            `json:"level"`
+		Message    sarifMessage      `json:"message"`
+		Locations  []sarifLocation   `json:"locations"`
+		Fixes      []sarifFix        `json:"fixes,omitempty"`
+		Properties map[string]string `json:"properties,omitempty"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           sarifRegion           `json:"region"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   uint `json:"startLine"`
+		StartColumn uint `json:"startColumn"`
+		EndLine     uint `json:"endLine"`
+		EndColumn   uint `json:"endColumn"`
+		CharOffset  uint `json:"charOffset"`
+		CharLength  uint `json:"charLength"`
+	}
+	sarifFix struct {
+		Description     sarifMessage          `json:"description"`
+		ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+	}
+	sarifArtifactChange struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Replacements     []sarifReplacement    `json:"replacements"`
+	}
+	sarifReplacement struct {
+		DeletedRegion   sarifRegion   `json:"deletedRegion"`
+		InsertedContent *sarifMessage `json:"insertedContent,omitempty"`
+	}
+)
+
+// sarifRules are the rules listed in SARIF logs, in order
+var sarifRules = append([]*Rule{ParseErrorRule}, Rules...)
+
+func (sr *sarifReporter) Report(filename, source string, findings []Finding) error {
+	artifact := sarifArtifactLocation{URI: filepath.ToSlash(filename)}
+	for _, finding := range findings {
+		result := sarifResult{
+			RuleID:    finding.Rule.ID,
+			RuleIndex: -1,
+			Level:     finding.Severity.String(),
+			Message:   sarifMessage{Text: finding.Message},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: artifact,
+				Region:           newSARIFRegion(finding.Range, false),
+			}}},
+		}
+		for i, rule := range sarifRules {
+			if rule == finding.Rule {
+				result.RuleIndex = i
+				break
+			}
+		}
+		for _, note := range finding.Notes {
+			result.Message.Text += "\n" + note
+		}
+		if finding.Code != utils.CodeUnknown {
+			result.Properties = map[string]string{"code": finding.Code.String()}
+		}
+
+		for _, fix := range finding.Fixes {
+			change := sarifArtifactChange{ArtifactLocation: artifact, Replacements: []sarifReplacement{}}
+			for _, r := range fix.Replacements {
+				replacement := sarifReplacement{DeletedRegion: newSARIFRegion(r.Range, r.Insert)}
+				if r.Text != "" {
+					replacement.InsertedContent = &sarifMessage{Text: r.Text}
+				}
+				change.Replacements = append(change.Replacements, replacement)
+			}
+			result.Fixes = append(result.Fixes, sarifFix{
+				Description:     sarifMessage{Text: fix.Description},
+				ArtifactChanges: []sarifArtifactChange{change},
+			})
+		}
+
+		sr.results = append(sr.results, result)
+	}
+	return nil
+}
+
+// newSARIFRegion turns a range into a SARIF region.
+// If empty is set, the region is empty and located before the start of the range.
+func newSARIFRegion(rng utils.ReaderRange, empty bool) (region sarifRegion) {
+	region.StartLine = rng.Start.Line + 1
+	region.StartColumn = rng.Start.Column + 1
+	region.CharOffset = rng.Start.RuneOffset
+
+	switch {
+	case empty:
+		region.EndLine = region.StartLine
+		region.EndColumn = region.StartColumn
+	case rng.End.EOF:
+		// the end of input is not a character
+		region.EndLine = rng.End.Line + 1
+		region.EndColumn = rng.End.Column + 1
+		region.CharLength = rng.End.RuneOffset - rng.Start.RuneOffset
+	default:
+		region.EndLine = rng.End.Line + 1
+		region.EndColumn = rng.End.Column + 2
+		region.CharLength = rng.End.RuneOffset - rng.Start.RuneOffset + 1
+	}
+	return
+}
+
+func (sr *sarifReporter) Close() error {
+	driver := sarifDriver{Name: "biblint", InformationURI: "https://github.com/tkw1536/gotexml"}
+	for _, rule := range sarifRules {
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			ShortDescription:     sarifMessage{Text: rule.Description},
+			DefaultConfiguration: sarifConfiguration{Level: rule.Severity.String()},
+		})
+	}
+
+	results := sr.results
+	if results == nil {
+		results = []sarifResult{}
+	}
+
+	encoder := json.NewEncoder(sr.writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:       sarifTool{Driver: driver},
+			ColumnKind: "unicodeCodePoints",
+			Results:    results,
+		}},
+	})
+}
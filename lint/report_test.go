@@ -0,0 +1,107 @@
+package lint
+
+import (
+	"bytes"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Format
+		wantErr bool
+	}{
+		{"text", FormatText, false},
+		{"json", FormatJSON, false},
+		{"sarif", FormatSARIF, false},
+		{"xml", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFormat(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat_Set(t *testing.T) {
+	format := FormatText
+	if err := format.Set("sarif"); err != nil || format != FormatSARIF {
+		t.Errorf("Format.Set() = %v, %v, want %v, nil", format, err, FormatSARIF)
+	}
+	if err := format.Set("xml"); err == nil {
+		t.Error("Format.Set() error = nil, want error")
+	}
+}
+
+// brokenSource is the source of a file that can not be parsed
+const brokenSource = "@book{a,\n  title = {x"
+
+// writeReport reports the findings of testdata/findings.bib and brokenSource in the given format
+func writeReport(format Format) string {
+	source := utils.ReadFileOrPanic(path.Join("testdata", "findings.bib"))
+	file, err := bibliography.NewBibFileFromReader(utils.NewRuneReaderFromString(source))
+	if err != nil {
+		panic(err)
+	}
+	findings := Lint(file)
+
+	_, err = bibliography.NewBibFileFromReader(utils.NewRuneReaderFromString(brokenSource))
+
+	var buffer bytes.Buffer
+	reporter := NewReporter(&buffer, format, false)
+	if err := reporter.Report("testdata/findings.bib", source, findings); err != nil {
+		panic(err)
+	}
+	if err := reporter.Report("broken.bib", brokenSource, []Finding{NewFindingFromError(err)}); err != nil {
+		panic(err)
+	}
+	if err := reporter.Close(); err != nil {
+		panic(err)
+	}
+	return buffer.String()
+}
+
+func TestNewReporter(t *testing.T) {
+	tests := []struct {
+		format Format
+		asset  string
+	}{
+		{FormatText, "report.txt"},
+		{FormatJSON, "report.jsonl"},
+		{FormatSARIF, "report.sarif"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			want := utils.ReadFileOrPanic(path.Join("testdata", tt.asset))
+			if got := writeReport(tt.format); got != want {
+				t.Errorf("NewReporter().Report() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestReportError(t *testing.T) {
+	_, err := bibliography.NewBibFileFromReader(utils.NewRuneReaderFromString(brokenSource))
+
+	var buffer bytes.Buffer
+	if err := ReportError(&buffer, FormatJSON, "broken.bib", brokenSource, err); err != nil {
+		t.Fatal(err)
+	}
+
+	// the error is reported like the last finding of the JSON report
+	report := strings.Split(strings.TrimSuffix(utils.ReadFileOrPanic(path.Join("testdata", "report.jsonl")), "\n"), "\n")
+	if got, want := buffer.String(), report[len(report)-1]+"\n"; got != want {
+		t.Errorf("ReportError() = %s, want %s", got, want)
+	}
+}
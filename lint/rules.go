@@ -0,0 +1,214 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// duplicateLabelRule finds entries that use the label of a previous entry
+var duplicateLabelRule = &Rule{
+	ID:          "duplicate-label",
+	Description: "An entry uses the same label as a previous entry",
+	Severity:    utils.SeverityWarning,
+	check: func(file *bibliography.BibFile, report func(Finding)) {
+		labels := make(map[string]*bibliography.BibEntry)
+		for _, entry := range file.Entries {
+			if entry.IsSpecial() || entry.Label() == "" {
+				continue
+			}
+
+			label := strings.ToLower(entry.Label())
+			previous, ok := labels[label]
+			if !ok {
+				labels[label] = entry
+				continue
+			}
+			report(Finding{Diagnostic: utils.Diagnostic{
+				Message: fmt.Sprintf("duplicate label %q", entry.Label()),
+				Range:   entry.Fields[0].Elements[0].Value.Source,
+				Notes:   []string{fmt.Sprintf("previously used on line %d", previous.Fields[0].Elements[0].Value.Source.Start.Line+1)},
+			}})
+		}
+	},
+}
+
+// duplicateFieldRule finds fields that occur more than once within the same entry
+var duplicateFieldRule = &Rule{
+	ID:          "duplicate-field",
+	Description: "An entry contains the same field more than once",
+	Severity:    utils.SeverityWarning,
+	check: func(file *bibliography.BibFile, report func(Finding)) {
+		for _, entry := range file.Entries {
+			if entry.IsSpecial() {
+				continue
+			}
+
+			seen := make(map[string]*bibliography.BibField)
+			for _, field := range entry.Fields {
+				key := field.GetKey()
+				if key == nil {
+					continue
+				}
+
+				name := strings.ToLower(key.Value.Value)
+				previous, ok := seen[name]
+				if !ok {
+					seen[name] = field
+					continue
+				}
+
+				finding := Finding{Diagnostic: utils.Diagnostic{
+					Message: fmt.Sprintf("duplicate field %q", key.Value.Value),
+					Range:   key.Value.Source,
+				}}
+				if sameValue(field, previous) {
+					finding.Fixes = []Fix{{
+						Description:  "Remove the duplicate field",
+						Replacements: []Replacement{{Range: removalRange(field)}},
+					}}
+				}
+				report(finding)
+			}
+		}
+	},
+}
+
+// sameValue checks if two 'key = value' fields have exactly the same value
+func sameValue(a, b *bibliography.BibField) bool {
+	av, bv := a.GetValue(), b.GetValue()
+	if len(av) != len(bv) {
+		return false
+	}
+	for i := range av {
+		if av[i].Value.Kind != bv[i].Value.Kind || av[i].Value.Value != bv[i].Value.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// removalRange returns the range to remove when removing field from its entry.
+// This includes the spaces preceding the field, as well as a terminating ','.
+// A terminating '}' is kept, leaving a trailing ',' in the preceding field.
+func removalRange(field *bibliography.BibField) (rng utils.ReaderRange) {
+	rng = field.Source
+	if field.Prefix.Value != "" {
+		rng.Start = field.Prefix.Source.Start
+	}
+	if field.Suffix.Value == "," {
+		return
+	}
+
+	last := field.Elements[len(field.Elements)-1]
+	rng.End = last.Value.Source.End
+	if last.Suffix != nil && last.Suffix.Value != "" {
+		rng.End = last.Suffix.Source.End
+	}
+	return
+}
+
+// undefinedMacroRule finds references to macros that are neither defined in the file nor by default
+var undefinedMacroRule = &Rule{
+	ID:          "undefined-macro",
+	Description: "A value references a macro that is not defined",
+	Severity:    utils.SeverityWarning,
+	check: func(file *bibliography.BibFile, report func(Finding)) {
+		macros := file.Macros()
+		for _, entry := range file.Entries {
+			if entry.IsKind("comment") || entry.IsKind("preamble") {
+				continue
+			}
+			for _, field := range entry.Fields {
+				if !field.IsKeyValue() {
+					continue
+				}
+				for _, element := range field.GetValue() {
+					if !isUndefined(element, macros) {
+						continue
+					}
+					report(Finding{Diagnostic: utils.Diagnostic{
+						Message: fmt.Sprintf("undefined macro %q", element.Value.Value),
+						Range:   element.Value.Source,
+					}})
+				}
+			}
+		}
+	},
+}
+
+// isUndefined checks if element references a macro that is not defined
+func isUndefined(element *bibliography.BibFieldElement, macros map[string]string) bool {
+	value := element.Value
+	if value.Kind != bibliography.BibStringLiteral || value.Value == "" || strings.Trim(value.Value, "0123456789") == "" {
+		return false
+	}
+
+	name := strings.ToLower(value.Value)
+	if _, ok := macros[name]; ok {
+		return false
+	}
+	_, ok := bibliography.DefaultMacros[name]
+	return !ok
+}
+
+// requiredFields are the fields required by standard BibTeX entry kinds.
+// Each group lists alternatives, of which at least one has to be present.
+var requiredFields = map[string][][]string{
+	"article":       {{"author"}, {"title"}, {"journal"}, {"year"}},
+	"book":          {{"author", "editor"}, {"title"}, {"publisher"}, {"year"}},
+	"booklet":       {{"title"}},
+	"conference":    {{"author"}, {"title"}, {"booktitle"}, {"year"}},
+	"inbook":        {{"author", "editor"}, {"title"}, {"chapter", "pages"}, {"publisher"}, {"year"}},
+	"incollection":  {{"author"}, {"title"}, {"booktitle"}, {"publisher"}, {"year"}},
+	"inproceedings": {{"author"}, {"title"}, {"booktitle"}, {"year"}},
+	"manual":        {{"title"}},
+	"mastersthesis": {{"author"}, {"title"}, {"school"}, {"year"}},
+	"phdthesis":     {{"author"}, {"title"}, {"school"}, {"year"}},
+	"proceedings":   {{"title"}, {"year"}},
+	"techreport":    {{"author"}, {"title"}, {"institution"}, {"year"}},
+	"unpublished":   {{"author"}, {"title"}, {"note"}},
+}
+
+// missingFieldRule finds entries that lack a field required by their kind.
+// Entries with a 'crossref' field are skipped, as they may inherit fields from the referenced entry.
+var missingFieldRule = &Rule{
+	ID:          "missing-field",
+	Description: "An entry lacks a field required by its kind",
+	Severity:    utils.SeverityWarning,
+	check: func(file *bibliography.BibFile, report func(Finding)) {
+		for _, entry := range file.Entries {
+			if entry.Kind == nil {
+				continue
+			}
+			groups, ok := requiredFields[strings.ToLower(entry.Kind.Value)]
+			if !ok || entry.GetField("crossref") != nil {
+				continue
+			}
+
+		groups:
+			for _, group := range groups {
+				for _, name := range group {
+					if entry.GetField(name) != nil {
+						continue groups
+					}
+				}
+				report(Finding{Diagnostic: utils.Diagnostic{
+					Message: fmt.Sprintf("missing required field %s", quoteAll(group, " or ")),
+					Range:   entry.Kind.Source,
+				}})
+			}
+		}
+	},
+}
+
+// quoteAll quotes each of names, and joins them using sep
+func quoteAll(names []string, sep string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	return strings.Join(quoted, sep)
+}
@@ -0,0 +1,159 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// labelWhitespaceRule finds labels surrounded by whitespace
+var labelWhitespaceRule = &Rule{
+	ID:          "label-whitespace",
+	Description: "The label of an entry is surrounded by whitespace",
+	Severity:    utils.SeverityNote,
+	check: func(file *bibliography.BibFile, report func(Finding)) {
+		for _, entry := range file.Entries {
+			if entry.IsSpecial() || entry.Label() == "" {
+				continue
+			}
+
+			field := entry.Fields[0]
+			label := field.Elements[0]
+
+			var replacements []Replacement
+			for _, space := range []*bibliography.BibString{&field.Prefix, label.Suffix} {
+				if space != nil && space.Value != "" && strings.TrimSpace(space.Value) == "" {
+					replacements = append(replacements, Replacement{Range: space.Source})
+				}
+			}
+			if len(replacements) == 0 {
+				continue
+			}
+
+			report(Finding{
+				Diagnostic: utils.Diagnostic{
+					Message: fmt.Sprintf("label %q is surrounded by whitespace", entry.Label()),
+					Range:   label.Value.Source,
+				},
+				Fixes: []Fix{{Description: "Remove the whitespace", Replacements: replacements}},
+			})
+		}
+	},
+}
+
+// pageRange matches a range of pages separated by a single hyphen
+var pageRange = regexp.MustCompile(`^([0-9A-Za-z]+)\s*-\s*([0-9A-Za-z]+)$`)
+
+// pageRangeRule finds page ranges using a single hyphen instead of an en-dash ('--')
+var pageRangeRule = &Rule{
+	ID:          "page-range",
+	Description: "A page range is separated by a single hyphen instead of '--'",
+	Severity:    utils.SeverityNote,
+	check: func(file *bibliography.BibFile, report func(Finding)) {
+		for _, entry := range file.Entries {
+			if entry.IsSpecial() {
+				continue
+			}
+
+			field := entry.GetField("pages")
+			value := singleValue(field)
+			if value == nil || value.Kind == bibliography.BibStringLiteral {
+				continue
+			}
+
+			match := pageRange.FindStringSubmatch(value.Value)
+			if match == nil {
+				continue
+			}
+			replacement := bibliography.BibString{Kind: value.Kind, Value: match[1] + "--" + match[2]}
+
+			report(Finding{
+				Diagnostic: utils.Diagnostic{
+					Message: fmt.Sprintf("page range %q should use '--'", value.Value),
+					Range:   value.Source,
+				},
+				Fixes: []Fix{{
+					Description:  fmt.Sprintf("Replace with %q", replacement.Value),
+					Replacements: []Replacement{{Range: value.Source, Text: stringOf(replacement)}},
+				}},
+			})
+		}
+	},
+}
+
+// monthRule finds months that are not given using the predefined macros, such as 'jan'
+var monthRule = &Rule{
+	ID:          "month",
+	Description: "A month is not given using a predefined macro such as 'jan'",
+	Severity:    utils.SeverityNote,
+	check: func(file *bibliography.BibFile, report func(Finding)) {
+		macros := file.Macros()
+		for _, entry := range file.Entries {
+			if entry.IsSpecial() {
+				continue
+			}
+
+			value := singleValue(entry.GetField("month"))
+			if value == nil {
+				continue
+			}
+
+			// literals are fine, as long as they use the macro as is
+			if value.Kind == bibliography.BibStringLiteral {
+				if _, ok := macros[strings.ToLower(value.Value)]; ok || value.Value == strings.ToLower(value.Value) {
+					continue
+				}
+			}
+
+			macro, ok := monthMacro(value.Value)
+			if !ok {
+				continue
+			}
+
+			report(Finding{
+				Diagnostic: utils.Diagnostic{
+					Message: fmt.Sprintf("month %s should be given as %s", stringOf(*value), macro),
+					Range:   value.Source,
+				},
+				Fixes: []Fix{{
+					Description:  fmt.Sprintf("Replace with %s", macro),
+					Replacements: []Replacement{{Range: value.Source, Text: macro}},
+				}},
+			})
+		}
+	},
+}
+
+// monthMacro returns the predefined macro of the month with the given name or abbreviation
+func monthMacro(name string) (macro string, ok bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for macro, month := range bibliography.DefaultMacros {
+		if name == macro || name == strings.ToLower(month) {
+			return macro, true
+		}
+	}
+	return "", false
+}
+
+// singleValue returns the value of a 'key = value' field consisting of a single element.
+// If field is nil or has a different form, returns nil.
+func singleValue(field *bibliography.BibField) *bibliography.BibString {
+	if field == nil || !field.IsKeyValue() {
+		return nil
+	}
+	value := field.GetValue()
+	if len(value) != 1 {
+		return nil
+	}
+	return value[0].Value
+}
+
+// stringOf returns the source representation of bs
+func stringOf(bs bibliography.BibString) string {
+	var builder strings.Builder
+	bs.Write(&builder)
+	return builder.String()
+}
@@ -0,0 +1,257 @@
+package lsp
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// document is a document opened by the client
+type document struct {
+	uri     string
+	version int
+	text    string
+
+	lines []utils.LineSpan // byte offsets of lines within text
+
+	file *bibliography.BibFile // parsed file, nil if parsing failed
+	err  error                 // error that occurred during parsing, if any
+
+	macros map[string]string // macros of the last successfully parsed version
+}
+
+// newDocument creates a new document and parses it
+func newDocument(uri string, version int, text string) *document {
+	doc := &document{uri: uri}
+	doc.update(version, text)
+	return doc
+}
+
+// update replaces the text of a document and re-parses it
+func (doc *document) update(version int, text string) {
+	doc.version = version
+	doc.text = text
+	doc.lines = utils.LineSpans(text)
+
+	doc.setFile(bibliography.NewBibFileFromReader(utils.NewRuneReaderFromString(text)))
+}
+
+// edit replaces the text within rng by text, and re-parses only the affected parts of the document.
+// If rng is nil, text replaces the entire document.
+func (doc *document) edit(version int, rng *Range, text string) {
+	if rng == nil {
+		doc.update(version, text)
+		return
+	}
+
+	edit := bibliography.TextEdit{Start: doc.offset(rng.Start), End: doc.offset(rng.End), Text: text}
+	if edit.End < edit.Start {
+		edit.End = edit.Start
+	}
+	if doc.file == nil {
+		doc.update(version, edit.Apply(doc.text))
+		return
+	}
+
+	file, newText, err := bibliography.Reparse(doc.file, doc.text, edit)
+	doc.version = version
+	doc.text = newText
+	doc.lines = utils.LineSpans(newText)
+	doc.setFile(file, err)
+}
+
+// setFile stores the result of parsing the text of doc
+func (doc *document) setFile(file *bibliography.BibFile, err error) {
+	doc.file, doc.err = file, err
+	if doc.err != nil {
+		doc.file = nil
+		return
+	}
+	doc.macros = doc.file.Macros()
+}
+
+// line returns the text of the given line, or the empty string if it does not exist
+func (doc *document) line(line int) string {
+	if line < 0 || line >= len(doc.lines) {
+		return ""
+	}
+	return doc.text[doc.lines[line].Start:doc.lines[line].End]
+}
+
+// toLSP converts a position of the parser to a position counted in UTF-16 code units
+func (doc *document) toLSP(pos utils.ReaderPosition) Position {
+	return Position{Line: int(pos.Line), Character: int(pos.UTF16Column)}
+}
+
+// fromLSP converts a position counted in UTF-16 code units into a position of the parser.
+// Positions behind the end of a line are moved to the end of the line, the rune offset is not computed.
+func (doc *document) fromLSP(pos Position) (rp utils.ReaderPosition) {
+	rp.Line = uint(pos.Line)
+	if pos.Line < 0 || pos.Line >= len(doc.lines) {
+		return
+	}
+
+	span := doc.lines[pos.Line]
+	rp.Offset = uint(span.End)
+	for i, r := range doc.text[span.Start:span.End] {
+		if int(rp.UTF16Column) >= pos.Character {
+			rp.Offset = uint(span.Start + i)
+			break
+		}
+		rp.Column++
+		rp.UTF16Column += uint(utils.UTF16Len(r))
+	}
+	return
+}
+
+// offset converts a position counted in UTF-16 code units into a byte offset within the text
+func (doc *document) offset(pos Position) int {
+	switch {
+	case pos.Line >= len(doc.lines):
+		return len(doc.text)
+	case pos.Line < 0:
+		return 0
+	}
+	return int(doc.fromLSP(pos).Offset)
+}
+
+// end returns the position at the end of the document
+func (doc *document) end() Position {
+	last := len(doc.lines) - 1
+	return doc.toLSP(doc.fromLSP(Position{Line: last, Character: math.MaxInt}))
+}
+
+// rangeOf converts an inclusive range of the parser into a range
+func (doc *document) rangeOf(source utils.ReaderRange) Range {
+	end := doc.toLSP(source.End)
+	if !source.End.EOF && int(source.End.Offset) < len(doc.text) {
+		// the range ends behind its last character, or at the end of the line
+		if r, _ := utf8.DecodeRuneInString(doc.text[source.End.Offset:]); r != '\n' && r != '\r' {
+			end.Character += utils.UTF16Len(r)
+		}
+	}
+	return Range{Start: doc.toLSP(source.Start), End: end}
+}
+
+// contains checks if the inclusive range source contains pos, or if pos is directly behind it
+func contains(source utils.ReaderRange, pos utils.ReaderPosition) bool {
+	after := func(a, b utils.ReaderPosition) bool {
+		return a.Line > b.Line || a.Line == b.Line && a.Column >= b.Column
+	}
+	end := source.End
+	end.Column++
+	return after(pos, source.Start) && after(end, pos)
+}
+
+// at finds the entry, field and element at pos.
+// Any of the returned values may be nil.
+func (doc *document) at(pos utils.ReaderPosition) (entry *bibliography.BibEntry, field *bibliography.BibField, element *bibliography.BibFieldElement) {
+	if doc.file == nil {
+		return
+	}
+	for _, e := range doc.file.Entries {
+		if !contains(e.Source, pos) {
+			continue
+		}
+		entry = e
+		for _, f := range e.Fields {
+			if f.Empty() || !contains(f.Source, pos) {
+				continue
+			}
+			field = f
+			for _, el := range f.Elements {
+				if el.Value.Value != "" && contains(el.Value.Source, pos) {
+					element = el
+					return
+				}
+			}
+			return
+		}
+		return
+	}
+	return
+}
+
+// isWordByte checks if c may be part of a field name, macro name or label being completed
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == ':' || c == '.' || c >= utf8.RuneSelf
+}
+
+// completionContext describes the syntactic context of a position, for completion
+type completionContext struct {
+	kind   string // kind of the surrounding entry
+	prefix string // partial word being typed
+
+	fieldName bool // a field name is being typed
+	value     bool // a value is being typed outside of braces and quotes
+}
+
+// contextAt determines the context at offset.
+// It scans the raw text, so that it works for documents that currently fail to parse.
+func (doc *document) contextAt(offset int) (ctx completionContext) {
+	start := offset
+	for start > 0 && isWordByte(doc.text[start-1]) {
+		start--
+	}
+	ctx.prefix = doc.text[start:offset]
+
+	text := doc.text[:start]
+	inEntry, inQuote := false, false
+	depth := 0
+	var last byte // last significant character at depth 1
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if !inEntry {
+			if c != '@' || (i > 0 && !isSpaceByte(text[i-1])) {
+				continue
+			}
+			j := i + 1
+			for j < len(text) && isWordByte(text[j]) {
+				j++
+			}
+			kind := text[i+1 : j]
+			for j < len(text) && isSpaceByte(text[j]) {
+				j++
+			}
+			if j < len(text) && text[j] == '{' {
+				inEntry, depth, last, ctx.kind = true, 1, '{', kind
+				i = j
+			}
+			continue
+		}
+
+		switch {
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				inEntry = false
+				continue
+			}
+		case c == '"' && depth == 1:
+			inQuote = !inQuote
+		}
+		if depth == 1 && !isSpaceByte(c) {
+			last = c
+		}
+	}
+
+	if !inEntry {
+		ctx.kind = ""
+		return
+	}
+	if depth == 1 && !inQuote {
+		ctx.fieldName = last == ','
+		ctx.value = last == '=' || last == '#'
+	}
+	return
+}
+
+// isSpaceByte checks if c is an ascii space
+func isSpaceByte(c byte) bool {
+	return strings.IndexByte(" \t\r\n\f\v", c) >= 0
+}
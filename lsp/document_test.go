@@ -0,0 +1,115 @@
+package lsp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+func Test_document_positions(t *testing.T) {
+	// 'ö' is one UTF-16 code unit, '𝔸' is two
+	doc := newDocument("file:///test.bib", 0, "@misc{a,\n  title = {ö𝔸x}\n}\n")
+
+	tests := []struct {
+		name   string
+		reader utils.ReaderPosition // rune offsets are not computed by fromLSP
+		lsp    Position
+	}{
+		{"start", utils.ReaderPosition{Line: 0, Column: 0}, Position{Line: 0, Character: 0}},
+		{"ascii", utils.ReaderPosition{Line: 1, Column: 3, UTF16Column: 3, Offset: 12}, Position{Line: 1, Character: 3}},
+		{"after bmp character", utils.ReaderPosition{Line: 1, Column: 12, UTF16Column: 12, Offset: 22}, Position{Line: 1, Character: 12}},
+		{"after astral character", utils.ReaderPosition{Line: 1, Column: 13, UTF16Column: 14, Offset: 26}, Position{Line: 1, Character: 14}},
+		{"end of line", utils.ReaderPosition{Line: 1, Column: 15, UTF16Column: 16, Offset: 28}, Position{Line: 1, Character: 16}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := doc.toLSP(tt.reader); got != tt.lsp {
+				t.Errorf("document.toLSP() = %v, want %v", got, tt.lsp)
+			}
+			if got := doc.fromLSP(tt.lsp); got != tt.reader {
+				t.Errorf("document.fromLSP() = %v, want %v", got, tt.reader)
+			}
+			if got := doc.offset(tt.lsp); got != int(tt.reader.Offset) {
+				t.Errorf("document.offset() = %v, want %v", got, tt.reader.Offset)
+			}
+		})
+	}
+
+	// positions of the parser map to the same positions
+	for _, entry := range doc.file.Entries {
+		for _, pos := range []utils.ReaderPosition{entry.Source.Start, entry.Source.End} {
+			rp := doc.fromLSP(doc.toLSP(pos))
+			if rp.Line != pos.Line || rp.Column != pos.Column || rp.Offset != pos.Offset {
+				t.Errorf("document.fromLSP(document.toLSP(%#v)) = %#v", pos, rp)
+			}
+		}
+	}
+
+	if got, want := doc.end(), (Position{Line: 3, Character: 0}); got != want {
+		t.Errorf("document.end() = %v, want %v", got, want)
+	}
+}
+
+func Test_document_contextAt(t *testing.T) {
+	tests := []struct {
+		name string
+		text string // the cursor is at the end of text
+		want completionContext
+	}{
+		{"outside of entries", "@misc{a, title = {x}}\nti", completionContext{prefix: "ti"}},
+		{"label", "@misc{lab", completionContext{kind: "misc", prefix: "lab"}},
+		{"field name", "@article{a,\n  ti", completionContext{kind: "article", prefix: "ti", fieldName: true}},
+		{"field name after field", "@Book{a,\n  title = {A, B},\n  ", completionContext{kind: "Book", fieldName: true}},
+		{"inside braces", "@book{a, title = {A, b", completionContext{kind: "book", prefix: "b"}},
+		{"inside quotes", "@book{a, title = \"A, b", completionContext{kind: "book", prefix: "b"}},
+		{"value", "@book{a, month = j", completionContext{kind: "book", prefix: "j", value: true}},
+		{"concatenation", "@book{a, author = me # y", completionContext{kind: "book", prefix: "y", value: true}},
+		{"email in prefix", "x@y.z @misc{a, ", completionContext{kind: "misc", fieldName: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := newDocument("file:///test.bib", 0, tt.text)
+			if got := doc.contextAt(len(tt.text)); got != tt.want {
+				t.Errorf("document.contextAt() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_document_edit(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		rng      *Range
+		newText  string
+		wantText string
+		wantErr  bool
+	}{
+		{"full", "@misc{a}", nil, "@book{b}", "@book{b}", false},
+		{"insert", "@misc{a}\n@misc{b}", &Range{Start: Position{1, 7}, End: Position{1, 7}}, ", year = 2000", "@misc{a}\n@misc{b, year = 2000}", false},
+		{"replace after astral character", "@misc{𝔸, title = x}", &Range{Start: Position{0, 18}, End: Position{0, 19}}, "{ö}", "@misc{𝔸, title = {ö}}", false},
+		{"introduce error", "@misc{a}", &Range{Start: Position{0, 7}, End: Position{0, 8}}, "", "@misc{a", true},
+		{"fix error", "@misc{a", &Range{Start: Position{0, 7}, End: Position{0, 7}}, "}", "@misc{a}", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := newDocument("file:///test.bib", 0, tt.text)
+			doc.edit(1, tt.rng, tt.newText)
+
+			if doc.text != tt.wantText {
+				t.Errorf("document.edit() text = %q, want %q", doc.text, tt.wantText)
+			}
+			if (doc.err != nil) != tt.wantErr {
+				t.Errorf("document.edit() error = %v, wantErr %v", doc.err, tt.wantErr)
+			}
+			want := newDocument("file:///test.bib", 1, tt.wantText)
+			if !reflect.DeepEqual(doc.file, want.file) {
+				t.Errorf("document.edit() file = %v, want %v", doc.file, want.file)
+			}
+			if !reflect.DeepEqual(doc.lines, want.lines) {
+				t.Errorf("document.edit() lines = %v, want %v", doc.lines, want.lines)
+			}
+		})
+	}
+}
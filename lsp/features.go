@@ -0,0 +1,307 @@
+package lsp
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/latex"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// diagnosticSource is the source of diagnostics reported by this package
+const diagnosticSource = "biblsp"
+
+// diagnostics computes the diagnostics of a document
+func diagnostics(doc *document) (diagnostics []Diagnostic) {
+	diagnostics = []Diagnostic{}
+
+	// report parse errors at their location
+	if doc.err != nil {
+		diagnostic := utils.NewDiagnosticFromError(doc.err)
+		pos := Position{}
+		if _, ok := doc.err.(*utils.ReaderError); ok {
+			pos = doc.toLSP(diagnostic.Range.Start)
+		}
+		return append(diagnostics, Diagnostic{
+			Range:    Range{Start: pos, End: Position{Line: pos.Line, Character: pos.Character + 1}},
+			Severity: SeverityError,
+			Source:   diagnosticSource,
+			Message:  diagnostic.Message,
+		})
+	}
+
+	macros := doc.file.Macros()
+	labels := make(map[string]bool)
+	for _, entry := range doc.file.Entries {
+		if entry.IsKind("comment") || entry.IsKind("preamble") {
+			continue
+		}
+
+		// duplicate labels
+		if !entry.IsSpecial() && entry.Label() != "" {
+			label := strings.ToLower(entry.Label())
+			if labels[label] {
+				diagnostics = append(diagnostics, Diagnostic{
+					Range:    doc.rangeOf(entry.Fields[0].Elements[0].Value.Source),
+					Severity: SeverityWarning,
+					Source:   diagnosticSource,
+					Message:  fmt.Sprintf("duplicate label %q", entry.Label()),
+				})
+			}
+			labels[label] = true
+		}
+
+		// undefined macros
+		for _, field := range entry.Fields {
+			for _, element := range field.GetValue() {
+				if name, ok := macroReference(element); ok && !isDefined(name, macros) {
+					diagnostics = append(diagnostics, Diagnostic{
+						Range:    doc.rangeOf(element.Value.Source),
+						Severity: SeverityWarning,
+						Source:   diagnosticSource,
+						Message:  fmt.Sprintf("undefined macro %q", element.Value.Value),
+					})
+				}
+			}
+		}
+	}
+	return
+}
+
+// macroReference checks if element references a macro, and returns its lower case name
+func macroReference(element *bibliography.BibFieldElement) (name string, ok bool) {
+	if element.Value.Kind != bibliography.BibStringLiteral || element.Value.Value == "" {
+		return "", false
+	}
+	for _, r := range element.Value.Value {
+		if r < '0' || r > '9' {
+			return strings.ToLower(element.Value.Value), true
+		}
+	}
+	return "", false // numbers are not macros
+}
+
+// isDefined checks if a macro is defined, either within macros or by default
+func isDefined(name string, macros map[string]string) bool {
+	if _, ok := macros[name]; ok {
+		return true
+	}
+	_, ok := bibliography.DefaultMacros[name]
+	return ok
+}
+
+// documentSymbols returns one symbol per entry of doc, with fields as children
+func documentSymbols(server *Server, doc *document) interface{} {
+	symbols := []DocumentSymbol{}
+	if doc.file == nil {
+		return symbols
+	}
+
+	for _, entry := range doc.file.Entries {
+		if len(entry.Fields) == 0 || entry.IsKind("comment") {
+			continue
+		}
+
+		symbol := DocumentSymbol{
+			Name:           "@" + entry.Kind.Value,
+			Detail:         entry.Kind.Value,
+			Kind:           SymbolStruct,
+			Range:          doc.rangeOf(entry.Source),
+			SelectionRange: doc.rangeOf(entry.Kind.Source),
+		}
+		switch {
+		case entry.IsKind("string"):
+			symbol.Kind = SymbolConstant
+			for _, field := range entry.Fields {
+				if key := field.GetKey(); key != nil {
+					symbol.Name = key.Value.Value
+					symbol.SelectionRange = doc.rangeOf(key.Value.Source)
+					break
+				}
+			}
+		case entry.IsKind("preamble"):
+			symbol.Kind = SymbolNamespace
+		case entry.Label() != "":
+			symbol.Name = entry.Label()
+			symbol.SelectionRange = doc.rangeOf(entry.Fields[0].Elements[0].Value.Source)
+		}
+
+		for _, field := range entry.Fields {
+			key := field.GetKey()
+			if key == nil || entry.IsKind("string") {
+				continue
+			}
+			symbol.Children = append(symbol.Children, DocumentSymbol{
+				Name:           key.Value.Value,
+				Kind:           SymbolField,
+				Range:          doc.rangeOf(field.Source),
+				SelectionRange: doc.rangeOf(key.Value.Source),
+			})
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// formatter is the formatter used for formatting documents.
+// Unlike the default formatter, it does not reorder entries.
+var formatter = func() bibliography.Formatter {
+	f := bibliography.DefaultFormatter
+	f.SortEntries = false
+	return f
+}()
+
+// formatting formats doc, returning a single edit replacing the entire document
+func formatting(server *Server, doc *document) interface{} {
+	edits := []TextEdit{}
+	if doc.err != nil {
+		return edits
+	}
+
+	// format a fresh copy, so that the document itself is not modified
+	file, err := bibliography.NewBibFileFromReader(utils.NewRuneReaderFromString(doc.text))
+	if err != nil {
+		return edits
+	}
+	formatter.Format(file)
+
+	var buffer bytes.Buffer
+	if err := file.Write(&buffer); err != nil || buffer.String() == doc.text {
+		return edits
+	}
+	return append(edits, TextEdit{
+		Range:   Range{End: doc.end()},
+		NewText: buffer.String(),
+	})
+}
+
+// definition finds the definition of the macro or crossref key at pos.
+// Definitions are looked up in doc first, and then in all other open documents.
+func definition(server *Server, doc *document, pos Position) interface{} {
+	_, field, element := doc.at(doc.fromLSP(pos))
+	if element == nil || element.Role != bibliography.NormalElementRole || !field.IsKeyValue() {
+		return nil
+	}
+
+	var find func(d *document) (Location, bool)
+	if name, ok := macroReference(element); ok {
+		find = func(d *document) (Location, bool) {
+			for _, entry := range d.file.Entries {
+				if !entry.IsKind("string") {
+					continue
+				}
+				for _, f := range entry.Fields {
+					if key := f.GetKey(); key != nil && strings.EqualFold(key.Value.Value, name) {
+						return Location{URI: d.uri, Range: d.rangeOf(key.Value.Source)}, true
+					}
+				}
+			}
+			return Location{}, false
+		}
+	} else if strings.EqualFold(field.Name(), "crossref") {
+		label := element.Value.Value
+		find = func(d *document) (Location, bool) {
+			for _, entry := range d.file.Entries {
+				if !entry.IsSpecial() && strings.EqualFold(entry.Label(), label) {
+					return Location{URI: d.uri, Range: d.rangeOf(entry.Fields[0].Elements[0].Value.Source)}, true
+				}
+			}
+			return Location{}, false
+		}
+	} else {
+		return nil
+	}
+
+	if location, ok := find(doc); ok {
+		return location
+	}
+	for _, uri := range server.sortedURIs() {
+		if d := server.documents[uri]; d != doc && d.file != nil {
+			if location, ok := find(d); ok {
+				return location
+			}
+		}
+	}
+	return nil
+}
+
+// sortedURIs returns the uris of open documents in a deterministic order
+func (server *Server) sortedURIs() (uris []string) {
+	for uri := range server.documents {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return
+}
+
+// hover shows the evaluated value of the field, or macro, at pos
+func hover(server *Server, doc *document, pos Position) interface{} {
+	_, field, element := doc.at(doc.fromLSP(pos))
+	if field == nil || !field.IsKeyValue() {
+		return nil
+	}
+	macros := doc.file.Macros()
+
+	// a macro reference shows the value of the macro
+	if element != nil && element.Role == bibliography.NormalElementRole {
+		if name, ok := macroReference(element); ok {
+			r := doc.rangeOf(element.Value.Source)
+			value := element.Evaluate(macros)
+			if !isDefined(name, macros) {
+				value = "(undefined)"
+			}
+			return Hover{
+				Contents: MarkupContent{Kind: "markdown", Value: fmt.Sprintf("**%s** = %s", element.Value.Value, latex.Decode(value))},
+				Range:    &r,
+			}
+		}
+	}
+
+	r := doc.rangeOf(field.Source)
+	return Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: fmt.Sprintf("**%s**: %s", field.Name(), latex.Decode(field.Evaluate(macros)))},
+		Range:    &r,
+	}
+}
+
+// completion completes field names for the kind of the surrounding entry, and macro names within values
+func completion(server *Server, doc *document, pos Position) interface{} {
+	items := []CompletionItem{}
+	ctx := doc.contextAt(doc.offset(pos))
+	prefix := strings.ToLower(ctx.prefix)
+
+	switch {
+	case ctx.fieldName:
+		names, details := fieldsFor(ctx.kind)
+		for _, name := range names {
+			if strings.HasPrefix(name, prefix) {
+				items = append(items, CompletionItem{Label: name, Kind: CompletionField, Detail: details[name]})
+			}
+		}
+	case ctx.value:
+		var names []string
+		for name := range doc.macros {
+			names = append(names, name)
+		}
+		for name := range bibliography.DefaultMacros {
+			if _, ok := doc.macros[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			value, ok := doc.macros[name]
+			if !ok {
+				value = bibliography.DefaultMacros[name]
+			}
+			if strings.HasPrefix(name, prefix) {
+				items = append(items, CompletionItem{Label: name, Kind: CompletionConstant, Detail: latex.Decode(value)})
+			}
+		}
+	}
+	return items
+}
@@ -0,0 +1,50 @@
+package lsp
+
+import "strings"
+
+// kindFields are the required and optional fields of standard BibTeX entry kinds
+var kindFields = map[string]struct{ required, optional []string }{
+	"article":       {[]string{"author", "title", "journal", "year"}, []string{"volume", "number", "pages", "month", "note"}},
+	"book":          {[]string{"author", "editor", "title", "publisher", "year"}, []string{"volume", "number", "series", "address", "edition", "month", "note"}},
+	"booklet":       {[]string{"title"}, []string{"author", "howpublished", "address", "month", "year", "note"}},
+	"conference":    {[]string{"author", "title", "booktitle", "year"}, []string{"editor", "volume", "number", "series", "pages", "address", "month", "organization", "publisher", "note"}},
+	"inbook":        {[]string{"author", "editor", "title", "chapter", "pages", "publisher", "year"}, []string{"volume", "number", "series", "type", "address", "edition", "month", "note"}},
+	"incollection":  {[]string{"author", "title", "booktitle", "publisher", "year"}, []string{"editor", "volume", "number", "series", "type", "chapter", "pages", "address", "edition", "month", "note"}},
+	"inproceedings": {[]string{"author", "title", "booktitle", "year"}, []string{"editor", "volume", "number", "series", "pages", "address", "month", "organization", "publisher", "note"}},
+	"manual":        {[]string{"title"}, []string{"author", "organization", "address", "edition", "month", "year", "note"}},
+	"mastersthesis": {[]string{"author", "title", "school", "year"}, []string{"type", "address", "month", "note"}},
+	"misc":          {nil, []string{"author", "title", "howpublished", "month", "year", "note"}},
+	"phdthesis":     {[]string{"author", "title", "school", "year"}, []string{"type", "address", "month", "note"}},
+	"proceedings":   {[]string{"title", "year"}, []string{"editor", "volume", "number", "series", "address", "month", "organization", "publisher", "note"}},
+	"techreport":    {[]string{"author", "title", "institution", "year"}, []string{"type", "number", "address", "month", "note"}},
+	"unpublished":   {[]string{"author", "title", "note"}, []string{"month", "year"}},
+}
+
+// commonFields are fields commonly used with any kind of entry
+var commonFields = []string{"abstract", "crossref", "doi", "isbn", "issn", "keywords", "url", "urldate"}
+
+// fieldsFor returns the field names to complete for an entry of the given kind, along with a description of each
+func fieldsFor(kind string) (names []string, details map[string]string) {
+	details = make(map[string]string)
+	add := func(fields []string, detail string) {
+		for _, name := range fields {
+			if _, ok := details[name]; !ok {
+				names = append(names, name)
+				details[name] = detail
+			}
+		}
+	}
+
+	kind = strings.ToLower(kind)
+	if fields, ok := kindFields[kind]; ok {
+		add(fields.required, "required for @"+kind)
+		add(fields.optional, "optional for @"+kind)
+	} else {
+		for _, fields := range kindFields {
+			add(fields.required, "")
+			add(fields.optional, "")
+		}
+	}
+	add(commonFields, "")
+	return
+}
@@ -0,0 +1,248 @@
+// Package lsp implements a Language Server Protocol server for .bib files.
+//
+// The server supports diagnostics, formatting, document symbols, go-to-definition for '@string' macros and 'crossref' keys, completion of field names and macros, and hover showing evaluated field values.
+// Documents are synchronized incrementally; columns are exchanged in UTF-16 code units as required by the protocol.
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Server is a Language Server Protocol server
+type Server struct {
+	conn *conn
+
+	mu        sync.Mutex
+	documents map[string]*document
+
+	shutdown bool // set once a shutdown request has been received
+}
+
+// NewServer creates a new server communicating over r and w
+func NewServer(r io.Reader, w io.Writer) *Server {
+	return &Server{
+		conn:      newConn(r, w),
+		documents: make(map[string]*document),
+	}
+}
+
+// errExit is returned by handlers when the server should exit
+var errExit = fmt.Errorf("exit")
+
+// Run serves requests until the client sends an exit notification or the input ends.
+// Returns nil if the client shut down the server properly.
+func (server *Server) Run() error {
+	for {
+		content, err := server.conn.read()
+		if err == io.EOF {
+			if server.shutdown {
+				return nil
+			}
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal(content, &msg); err != nil {
+			server.conn.write(&message{ID: rawNull(), Error: &responseError{Code: codeParseError, Message: err.Error()}})
+			continue
+		}
+
+		err = server.handle(&msg)
+		if err == errExit {
+			if server.shutdown {
+				return nil
+			}
+			return fmt.Errorf("exit without shutdown")
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// rawNull returns a json null
+func rawNull() *json.RawMessage {
+	null := json.RawMessage("null")
+	return &null
+}
+
+// handle handles a single message
+func (server *Server) handle(msg *message) error {
+	// notifications
+	if msg.ID == nil {
+		switch msg.Method {
+		case "exit":
+			return errExit
+		case "textDocument/didOpen":
+			var params didOpenParams
+			if json.Unmarshal(msg.Params, &params) == nil {
+				server.open(params)
+			}
+		case "textDocument/didChange":
+			var params didChangeParams
+			if json.Unmarshal(msg.Params, &params) == nil {
+				server.change(params)
+			}
+		case "textDocument/didClose":
+			var params didCloseParams
+			if json.Unmarshal(msg.Params, &params) == nil {
+				server.close(params)
+			}
+		}
+		return nil
+	}
+
+	// requests
+	var result interface{}
+	var rerr *responseError
+
+	handler, ok := requestHandlers[msg.Method]
+	switch {
+	case server.shutdown:
+		rerr = &responseError{Code: codeInvalidRequest, Message: "server is shutting down"}
+	case msg.Method == "shutdown":
+		server.shutdown = true
+	case ok:
+		result, rerr = handler(server, msg.Params)
+	default:
+		rerr = &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", msg.Method)}
+	}
+
+	response := &message{ID: msg.ID, Error: rerr}
+	if rerr == nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			response.Error = &responseError{Code: codeInternalError, Message: err.Error()}
+		} else {
+			response.Result = data
+		}
+	}
+	return server.conn.write(response)
+}
+
+// requestHandler handles a single request
+type requestHandler func(server *Server, params json.RawMessage) (result interface{}, err *responseError)
+
+// requestHandlers are the handlers of supported requests, keyed by method
+var requestHandlers = map[string]requestHandler{
+	"initialize":                  handleInitialize,
+	"textDocument/documentSymbol": withDocument(documentSymbols),
+	"textDocument/formatting":     withDocument(formatting),
+	"textDocument/definition":     withPosition(definition),
+	"textDocument/hover":          withPosition(hover),
+	"textDocument/completion":     withPosition(completion),
+}
+
+// handleInitialize handles the initialize request
+func handleInitialize(server *Server, params json.RawMessage) (interface{}, *responseError) {
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"positionEncoding":           "utf-16",
+			"textDocumentSync":           2, // incremental
+			"documentSymbolProvider":     true,
+			"documentFormattingProvider": true,
+			"definitionProvider":         true,
+			"hoverProvider":              true,
+			"completionProvider":         map[string]interface{}{"triggerCharacters": []string{",", "="}},
+		},
+		"serverInfo": map[string]string{"name": "biblsp"},
+	}, nil
+}
+
+// withDocument adapts a function operating on a single document into a requestHandler
+func withDocument(f func(server *Server, doc *document) interface{}) requestHandler {
+	return func(server *Server, raw json.RawMessage) (interface{}, *responseError) {
+		var params documentParams
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, &responseError{Code: codeInvalidParams, Message: err.Error()}
+		}
+
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		doc, ok := server.documents[params.TextDocument.URI]
+		if !ok {
+			return nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown document %q", params.TextDocument.URI)}
+		}
+		return f(server, doc), nil
+	}
+}
+
+// withPosition adapts a function operating on a position within a document into a requestHandler
+func withPosition(f func(server *Server, doc *document, pos Position) interface{}) requestHandler {
+	return func(server *Server, raw json.RawMessage) (interface{}, *responseError) {
+		var params TextDocumentPositionParams
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, &responseError{Code: codeInvalidParams, Message: err.Error()}
+		}
+
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		doc, ok := server.documents[params.TextDocument.URI]
+		if !ok {
+			return nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown document %q", params.TextDocument.URI)}
+		}
+		return f(server, doc, params.Position), nil
+	}
+}
+
+// open handles a newly opened document
+func (server *Server) open(params didOpenParams) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	doc := newDocument(params.TextDocument.URI, params.TextDocument.Version, params.TextDocument.Text)
+	server.documents[doc.uri] = doc
+	server.publishDiagnostics(doc)
+}
+
+// change handles a changed document
+func (server *Server) change(params didChangeParams) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	doc, ok := server.documents[params.TextDocument.URI]
+	if !ok || len(params.ContentChanges) == 0 {
+		return
+	}
+
+	// changes are applied in order, each one to the result of the previous one
+	for _, change := range params.ContentChanges {
+		doc.edit(params.TextDocument.Version, change.Range, change.Text)
+	}
+	server.publishDiagnostics(doc)
+}
+
+// close handles a closed document
+func (server *Server) close(params didCloseParams) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	delete(server.documents, params.TextDocument.URI)
+	server.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+}
+
+// publishDiagnostics sends the diagnostics of doc to the client
+func (server *Server) publishDiagnostics(doc *document) {
+	server.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         doc.uri,
+		Version:     doc.version,
+		Diagnostics: diagnostics(doc),
+	})
+}
+
+// notify sends a notification to the client
+func (server *Server) notify(method string, params interface{}) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+	server.conn.write(&message{Method: method, Params: data})
+}
@@ -0,0 +1,215 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// testClient is a client talking to a server under test
+type testClient struct {
+	t    *testing.T
+	conn *conn
+	id   int
+	done chan error
+}
+
+// newTestClient starts a server and returns a client connected to it
+func newTestClient(t *testing.T) *testClient {
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+
+	client := &testClient{t: t, conn: newConn(clientIn, clientOut), done: make(chan error, 1)}
+	go func() {
+		client.done <- NewServer(serverIn, serverOut).Run()
+		serverOut.Close()
+	}()
+	return client
+}
+
+// notify sends a notification
+func (c *testClient) notify(method string, params interface{}) {
+	data, _ := json.Marshal(params)
+	if err := c.conn.write(&message{Method: method, Params: data}); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// receive reads the next message from the server
+func (c *testClient) receive() *message {
+	content, err := c.conn.read()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	var msg message
+	if err := json.Unmarshal(content, &msg); err != nil {
+		c.t.Fatal(err)
+	}
+	return &msg
+}
+
+// request sends a request and decodes its result into result
+func (c *testClient) request(method string, params interface{}, result interface{}) *responseError {
+	c.id++
+	id := json.RawMessage(strconv.Itoa(c.id))
+	data, _ := json.Marshal(params)
+	if err := c.conn.write(&message{ID: &id, Method: method, Params: data}); err != nil {
+		c.t.Fatal(err)
+	}
+
+	msg := c.receive()
+	if msg.Error != nil {
+		return msg.Error
+	}
+	if err := json.Unmarshal(msg.Result, result); err != nil {
+		c.t.Fatalf("%s: invalid result %s: %v", method, msg.Result, err)
+	}
+	return nil
+}
+
+// open opens a document and returns the published diagnostics
+func (c *testClient) open(uri, text string) []Diagnostic {
+	c.notify("textDocument/didOpen", didOpenParams{TextDocument: TextDocumentItem{URI: uri, LanguageID: "bibtex", Version: 1, Text: text}})
+	msg := c.receive()
+	if msg.Method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("received %q, want diagnostics", msg.Method)
+	}
+	var params publishDiagnosticsParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		c.t.Fatal(err)
+	}
+	return params.Diagnostics
+}
+
+const testDocument = `@string{me = "Ünïcödé 𝔸uthor"}
+
+@book{parent,
+  title = {Parent},
+  author = me
+}
+
+@inproceedings{child,
+  crossref = {parent},
+  month = jan,
+  year = 2020
+}
+`
+
+func TestServer(t *testing.T) {
+	client := newTestClient(t)
+	const uri = "file:///test.bib"
+	at := func(line, character int) TextDocumentPositionParams {
+		return TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: uri}, Position: Position{Line: line, Character: character}}
+	}
+
+	var initialized map[string]interface{}
+	if err := client.request("initialize", map[string]interface{}{}, &initialized); err != nil {
+		t.Fatalf("initialize error = %v", err)
+	}
+	if _, ok := initialized["capabilities"]; !ok {
+		t.Errorf("initialize = %v, want capabilities", initialized)
+	}
+
+	if got := client.open(uri, testDocument); len(got) != 0 {
+		t.Errorf("diagnostics = %v, want none", got)
+	}
+
+	t.Run("documentSymbol", func(t *testing.T) {
+		var symbols []DocumentSymbol
+		client.request("textDocument/documentSymbol", documentParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &symbols)
+
+		var names []string
+		for _, s := range symbols {
+			names = append(names, s.Name)
+		}
+		if want := []string{"me", "parent", "child"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("documentSymbol names = %v, want %v", names, want)
+		}
+		// '𝔸' counts as two UTF-16 code units
+		if want := (Range{Start: Position{0, 0}, End: Position{0, 31}}); symbols[0].Range != want {
+			t.Errorf("documentSymbol range = %v, want %v", symbols[0].Range, want)
+		}
+		if want := (Range{Start: Position{2, 6}, End: Position{2, 12}}); symbols[1].SelectionRange != want {
+			t.Errorf("documentSymbol selection = %v, want %v", symbols[1].SelectionRange, want)
+		}
+		if len(symbols[2].Children) != 3 {
+			t.Errorf("documentSymbol children = %v, want 3", symbols[2].Children)
+		}
+	})
+
+	t.Run("definition", func(t *testing.T) {
+		var location Location
+		client.request("textDocument/definition", at(4, 12), &location)
+		if want := (Location{URI: uri, Range: Range{Start: Position{0, 8}, End: Position{0, 10}}}); location != want {
+			t.Errorf("definition of macro = %v, want %v", location, want)
+		}
+
+		client.request("textDocument/definition", at(8, 16), &location)
+		if want := (Location{URI: uri, Range: Range{Start: Position{2, 6}, End: Position{2, 12}}}); location != want {
+			t.Errorf("definition of crossref = %v, want %v", location, want)
+		}
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		var result Hover
+		client.request("textDocument/hover", at(4, 12), &result)
+		if want := "**me** = Ünïcödé 𝔸uthor"; result.Contents.Value != want {
+			t.Errorf("hover on macro = %q, want %q", result.Contents.Value, want)
+		}
+		client.request("textDocument/hover", at(9, 4), &result)
+		if want := "**month**: January"; result.Contents.Value != want {
+			t.Errorf("hover on field = %q, want %q", result.Contents.Value, want)
+		}
+	})
+
+	t.Run("formatting", func(t *testing.T) {
+		var edits []TextEdit
+		client.request("textDocument/formatting", documentParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &edits)
+		if len(edits) != 1 || edits[0].Range != (Range{End: Position{12, 0}}) {
+			t.Errorf("formatting = %v, want a single edit of the entire document", edits)
+		}
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		client.notify("textDocument/didChange", map[string]interface{}{
+			"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+			"contentChanges": []map[string]string{{"text": "@article{x,\n  jo"}},
+		})
+		client.receive() // diagnostics
+
+		var items []CompletionItem
+		client.request("textDocument/completion", at(1, 4), &items)
+		if want := []CompletionItem{{Label: "journal", Kind: CompletionField, Detail: "required for @article"}}; !reflect.DeepEqual(items, want) {
+			t.Errorf("completion = %v, want %v", items, want)
+		}
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		client.notify("textDocument/didChange", map[string]interface{}{
+			"textDocument":   map[string]interface{}{"uri": uri, "version": 3},
+			"contentChanges": []map[string]string{{"text": "@misc{a, author = nobody}\n@misc{A, year = 1}\n"}},
+		})
+		var params publishDiagnosticsParams
+		json.Unmarshal(client.receive().Params, &params)
+
+		var messages []string
+		for _, d := range params.Diagnostics {
+			messages = append(messages, d.Message)
+		}
+		if want := []string{`undefined macro "nobody"`, `duplicate label "A"`}; !reflect.DeepEqual(messages, want) {
+			t.Errorf("diagnostics = %v, want %v", messages, want)
+		}
+	})
+
+	var result interface{}
+	if err := client.request("unknown/method", nil, &result); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("unknown method error = %v, want code %d", err, codeMethodNotFound)
+	}
+	client.request("shutdown", nil, &result)
+	client.notify("exit", nil)
+	if err := <-client.done; err != nil {
+		t.Errorf("Server.Run() = %v, want nil", err)
+	}
+}
@@ -0,0 +1,508 @@
+// Package merge implements a three-way merge of bibliographies.
+package merge
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/tkw1536/gotexml/bibliography"
+)
+
+// Options control how conflicts are marked
+type Options struct {
+	OursLabel   string // label after the opening conflict marker, defaults to "ours"
+	TheirsLabel string // label after the closing conflict marker, defaults to "theirs"
+	MarkerSize  int    // length of conflict markers, defaults to 7
+}
+
+// Merge performs a three-way merge of two bibliographies ours and theirs derived from a common ancestor base.
+// Returns the merged bibliography along with the number of conflicts.
+// None of the input bibliographies are modified.
+//
+// Entries are matched by label (case-insensitively), '@string' entries by the name of the first macro they define, and other special entries by their content.
+// Changes made on only one side are applied at the entry and field level, changes made on both sides only conflict if they differ.
+// Conflicts are marked using git-style conflict markers around the conflicting fields, or around entire entries if entries were deleted on one side or changed their kind on both.
+//
+// The merged bibliography follows the order of ours, with entries added by theirs inserted after the entry preceding them in theirs.
+func Merge(base, ours, theirs *bibliography.BibFile, options Options) (result *bibliography.BibFile, conflicts int) {
+	m := &merger{options: options}
+	m.setDefaults()
+
+	b := index(base)
+	o := index(ours)
+	t := index(theirs)
+
+	// items of the result, in order
+	var items []*item
+	position := make(map[string]int) // position of keys within items
+
+	// entries in ours
+	for _, key := range o.order {
+		oe := o.entries[key]
+		be, inBase := b.entries[key]
+		te, inTheirs := t.entries[key]
+
+		var it *item
+		switch {
+		case !inTheirs && !inBase:
+			it = &item{entries: []*bibliography.BibEntry{oe.Clone()}}
+		case !inTheirs && text(oe) == text(be):
+			continue // deleted by theirs
+		case !inTheirs:
+			it = m.entryConflict(oe, nil)
+		default:
+			if !inBase {
+				be = nil
+			}
+			it = m.mergeEntry(be, oe, te)
+		}
+
+		position[key] = len(items)
+		items = append(items, it)
+	}
+
+	// entries only in theirs
+	for i, key := range t.order {
+		if _, ok := o.entries[key]; ok {
+			continue
+		}
+		te := t.entries[key]
+		be, inBase := b.entries[key]
+
+		var it *item
+		switch {
+		case !inBase:
+			it = &item{entries: []*bibliography.BibEntry{te.Clone()}}
+		case text(te) == text(be):
+			continue // deleted by ours
+		default:
+			it = m.entryConflict(nil, te)
+		}
+
+		// insert after the closest preceding entry of theirs
+		at := 0
+		for j := i - 1; j >= 0; j-- {
+			if p, ok := position[t.order[j]]; ok {
+				at = p + 1
+				break
+			}
+		}
+		items = append(items[:at], append([]*item{it}, items[at:]...)...)
+		for k, p := range position {
+			if p >= at {
+				position[k] = p + 1
+			}
+		}
+		position[key] = at
+	}
+
+	// build the resulting file
+	result = &bibliography.BibFile{
+		Suffix:     bibliography.BibString{Value: merge3(base.Suffix.Value, ours.Suffix.Value, theirs.Suffix.Value)},
+		LineEnding: ours.LineEnding,
+		Encoding:   ours.Encoding,
+		BOM:        ours.BOM,
+	}
+	pending := ""
+	for _, it := range items {
+		for i, e := range it.entries {
+			if i == 0 {
+				e.Prefix.Value = joinLine(pending, e.Prefix.Value)
+			}
+			result.Entries = append(result.Entries, e)
+		}
+		pending = it.after
+		m.conflicts += it.conflicts
+	}
+	result.Suffix.Value = joinLine(pending, result.Suffix.Value)
+
+	return result, m.conflicts
+}
+
+// merger holds the state of a single merge
+type merger struct {
+	options   Options
+	conflicts int
+}
+
+// setDefaults sets default options
+func (m *merger) setDefaults() {
+	if m.options.OursLabel == "" {
+		m.options.OursLabel = "ours"
+	}
+	if m.options.TheirsLabel == "" {
+		m.options.TheirsLabel = "theirs"
+	}
+	if m.options.MarkerSize <= 0 {
+		m.options.MarkerSize = 7
+	}
+}
+
+// markers returns the opening, separating and closing conflict markers, without newlines
+func (m *merger) markers() (open, sep, close string) {
+	open = strings.Repeat("<", m.options.MarkerSize) + " " + m.options.OursLabel
+	sep = strings.Repeat("=", m.options.MarkerSize)
+	close = strings.Repeat(">", m.options.MarkerSize) + " " + m.options.TheirsLabel
+	return
+}
+
+// item is a group of entries within the merged file
+type item struct {
+	entries   []*bibliography.BibEntry
+	after     string // text to insert after the entries
+	conflicts int    // number of conflicts within this item
+}
+
+// entryConflict creates an item marking a conflict between two versions of an entry.
+// Either version may be nil, indicating the entry was deleted.
+func (m *merger) entryConflict(ours, theirs *bibliography.BibEntry) *item {
+	open, sep, close := m.markers()
+	it := &item{conflicts: 1}
+
+	switch {
+	case ours != nil && theirs != nil:
+		o, t := ours.Clone(), theirs.Clone()
+		o.Prefix.Value = lineBreak(o.Prefix.Value) + open + "\n"
+		t.Prefix.Value = "\n" + sep + "\n"
+		it.entries = []*bibliography.BibEntry{o, t}
+		it.after = "\n" + close
+	case ours != nil:
+		o := ours.Clone()
+		o.Prefix.Value = lineBreak(o.Prefix.Value) + open + "\n"
+		it.entries = []*bibliography.BibEntry{o}
+		it.after = "\n" + sep + "\n" + close
+	case theirs != nil:
+		t := theirs.Clone()
+		t.Prefix.Value = lineBreak(t.Prefix.Value) + open + "\n" + sep + "\n"
+		it.entries = []*bibliography.BibEntry{t}
+		it.after = "\n" + close
+	}
+	return it
+}
+
+// lineBreak ensures that s is empty or ends with a newline
+func lineBreak(s string) string {
+	if s == "" || strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
+
+// joinLine joins text following an entry to s, ensuring that s starts on a new line
+func joinLine(text, s string) string {
+	if text == "" || strings.HasPrefix(s, "\n") {
+		return text + s
+	}
+	return text + "\n" + s
+}
+
+// mergeEntry merges two versions of an entry with a common ancestor base.
+// base may be nil if both sides added the entry.
+func (m *merger) mergeEntry(base, ours, theirs *bibliography.BibEntry) *item {
+	ot, tt := text(ours), text(theirs)
+	switch {
+	case ot == tt:
+		return &item{entries: []*bibliography.BibEntry{ours.Clone()}}
+	case base != nil && text(base) == ot:
+		result := theirs.Clone()
+		result.Prefix = ours.Prefix
+		return &item{entries: []*bibliography.BibEntry{result}}
+	case base != nil && text(base) == tt:
+		return &item{entries: []*bibliography.BibEntry{ours.Clone()}}
+	}
+
+	// merge the kind
+	var baseKind string
+	if base != nil {
+		baseKind = strings.ToLower(base.Kind.Value)
+	}
+	ok, tk := strings.ToLower(ours.Kind.Value), strings.ToLower(theirs.Kind.Value)
+	if ok != tk && ok != baseKind && tk != baseKind {
+		return m.entryConflict(ours, theirs)
+	}
+
+	result := ours.Clone()
+	if ok == baseKind && tk != baseKind {
+		result.Kind.Value = theirs.Kind.Value
+	}
+
+	// merge the fields
+	bf := fieldsOf(base)
+	of := fieldsOf(ours)
+	tf := fieldsOf(theirs)
+
+	it := &item{entries: []*bibliography.BibEntry{result}}
+	var fields []*bibliography.BibField
+	var added []*bibliography.BibField
+	for i, f := range result.Fields {
+		name := strings.ToLower(f.Name())
+		if name == "" || of[name] != ours.Fields[i] {
+			fields = append(fields, f)
+			continue
+		}
+
+		b, inBase := bf[name]
+		t, inTheirs := tf[name]
+		vo := value(f)
+
+		switch {
+		case inTheirs && vo == value(t):
+			fields = append(fields, f)
+		case inTheirs && inBase && value(b) == vo:
+			replaceValue(f, t)
+			fields = append(fields, f)
+		case inTheirs && inBase && value(b) == value(t):
+			fields = append(fields, f)
+		case inTheirs:
+			fields = append(fields, m.fieldConflict(f, t)...)
+			it.conflicts++
+		case !inBase:
+			fields = append(fields, f) // added by ours
+		case value(b) == vo:
+			fields = append(fields, removeField(f)...) // deleted by theirs
+		default:
+			fields = append(fields, m.fieldConflict(f, nil)...)
+			it.conflicts++
+		}
+	}
+
+	// fields only in theirs
+	for _, t := range theirs.Fields {
+		name := strings.ToLower(t.Name())
+		if name == "" || tf[name] != t {
+			continue
+		}
+		if _, ok := of[name]; ok {
+			continue
+		}
+
+		b, inBase := bf[name]
+		switch {
+		case !inBase:
+			added = append(added, t.Clone()) // added by theirs
+		case value(b) == value(t):
+			// deleted by ours
+		default:
+			added = append(added, m.fieldConflict(nil, t)...)
+			it.conflicts++
+		}
+	}
+
+	result.Fields = appendFields(fields, added)
+	return it
+}
+
+// fieldConflict creates fields marking a conflict between two versions of a field.
+// Either version may be nil, indicating the field was deleted.
+// The returned fields always end with a ','.
+func (m *merger) fieldConflict(ours, theirs *bibliography.BibField) (fields []*bibliography.BibField) {
+	open, sep, close := m.markers()
+
+	var closing *bibliography.BibField
+	if ours != nil {
+		closing = terminate(ours)
+		ours.Prefix.Value = "\n" + open + startLine(ours.Prefix.Value)
+		fields = append(fields, ours)
+	}
+
+	if theirs == nil {
+		ours.Suffix.Value = ",\n" + sep + "\n" + close
+	} else {
+		t := theirs.Clone()
+		terminate(t)
+		if ours == nil {
+			t.Prefix.Value = "\n" + open + "\n" + sep + startLine(t.Prefix.Value)
+		} else {
+			t.Prefix.Value = "\n" + sep + startLine(t.Prefix.Value)
+		}
+		t.Suffix.Value = ",\n" + close
+		fields = append(fields, t)
+	}
+
+	if closing != nil {
+		closing.Prefix.Value = startLine(closing.Prefix.Value)
+		fields = append(fields, closing)
+	}
+	return
+}
+
+// startLine ensures that s starts with a newline
+func startLine(s string) string {
+	if strings.HasPrefix(s, "\n") {
+		return s
+	}
+	return "\n" + s
+}
+
+// terminate makes field end with a ',' instead of the closing '}' of an entry.
+// If it did end with a '}', returns an empty field closing the entry.
+func terminate(field *bibliography.BibField) (closing *bibliography.BibField) {
+	if field.Suffix.Value != "}" {
+		return nil
+	}
+
+	// move trailing whitespace to the closing field
+	closing = &bibliography.BibField{Suffix: bibliography.BibString{Value: "}"}}
+	if n := len(field.Elements); n > 0 {
+		closing.Prefix.Value = field.Elements[n-1].Suffix.Value
+		field.Elements[n-1].Suffix.Value = ""
+	}
+	field.Suffix.Value = ","
+	return closing
+}
+
+// removeField removes a field from an entry.
+// If the field closes the entry, returns an empty field closing the entry instead.
+func removeField(field *bibliography.BibField) []*bibliography.BibField {
+	if closing := terminate(field); closing != nil {
+		return []*bibliography.BibField{closing}
+	}
+	return nil
+}
+
+// appendFields appends added to fields, keeping the closing '}' at the end
+func appendFields(fields, added []*bibliography.BibField) []*bibliography.BibField {
+	if len(added) == 0 {
+		return fields
+	}
+
+	// ensure that added fields end with a ','
+	for _, f := range added {
+		if closing := terminate(f); closing != nil {
+			f.Elements[len(f.Elements)-1].Suffix.Value = ""
+		}
+	}
+
+	if len(fields) == 0 {
+		return append(added, &bibliography.BibField{Suffix: bibliography.BibString{Value: "}"}})
+	}
+
+	// make sure the last field is an empty closing field
+	last := fields[len(fields)-1]
+	if !last.Empty() || last.Suffix.Value != "}" {
+		if closing := terminate(last); closing != nil {
+			fields = append(fields, closing)
+		}
+	}
+
+	// insert before the closing field, using the indentation of the preceding field
+	indent := "\n"
+	if len(fields) > 1 {
+		indent = fields[len(fields)-2].Prefix.Value
+	}
+	for _, f := range added {
+		if strings.TrimSpace(f.Prefix.Value) == "" {
+			f.Prefix.Value = indent
+		}
+	}
+	n := len(fields) - 1
+	return append(fields[:n], append(added, fields[n])...)
+}
+
+// replaceValue replaces the value of field with the value of other
+func replaceValue(field, other *bibliography.BibField) {
+	key := field.Elements[0]
+	trailing := ""
+	if n := len(field.Elements); n > 0 {
+		trailing = field.Elements[n-1].Suffix.Value
+	}
+
+	var elements []*bibliography.BibFieldElement
+	for _, e := range other.GetValue() {
+		elements = append(elements, e.Clone())
+	}
+	if n := len(elements); n > 0 {
+		elements[n-1].Suffix.Value = trailing
+	}
+	field.Elements = append([]*bibliography.BibFieldElement{key}, elements...)
+}
+
+// fieldsOf returns the first occurrence of each 'key = value' field of entry, keyed by lower case name
+func fieldsOf(entry *bibliography.BibEntry) map[string]*bibliography.BibField {
+	fields := make(map[string]*bibliography.BibField)
+	if entry == nil {
+		return fields
+	}
+	for _, f := range entry.Fields {
+		name := strings.ToLower(f.Name())
+		if name == "" {
+			continue
+		}
+		if _, ok := fields[name]; !ok {
+			fields[name] = f
+		}
+	}
+	return fields
+}
+
+// value returns the value of a field for comparison, ignoring whitespace around elements
+func value(field *bibliography.BibField) string {
+	var parts []string
+	for _, e := range field.GetValue() {
+		var buffer bytes.Buffer
+		e.Value.Write(&buffer)
+		parts = append(parts, buffer.String())
+	}
+	return strings.Join(parts, " # ")
+}
+
+// text returns the text of an entry, excluding its prefix
+func text(entry *bibliography.BibEntry) string {
+	clone := *entry
+	clone.Prefix = bibliography.BibString{}
+
+	var buffer bytes.Buffer
+	clone.Write(&buffer)
+	return buffer.String()
+}
+
+// merge3 performs a three-way merge on a string, preferring ours on conflicts
+func merge3(base, ours, theirs string) string {
+	if ours == base {
+		return theirs
+	}
+	return ours
+}
+
+// indexed holds the entries of a file by key
+type indexed struct {
+	entries map[string]*bibliography.BibEntry
+	order   []string
+}
+
+// index indexes the entries of file by their key
+func index(file *bibliography.BibFile) *indexed {
+	idx := &indexed{entries: make(map[string]*bibliography.BibEntry)}
+	counts := make(map[string]int)
+	for _, entry := range file.Entries {
+		key := entryKey(entry)
+
+		// disambiguate duplicate keys by their occurrence
+		counts[key]++
+		if counts[key] > 1 {
+			key = fmt.Sprintf("%s#%d", key, counts[key])
+		}
+
+		idx.entries[key] = entry
+		idx.order = append(idx.order, key)
+	}
+	return idx
+}
+
+// entryKey returns the key used to match an entry across files
+func entryKey(entry *bibliography.BibEntry) string {
+	switch {
+	case entry.IsKind("string"):
+		for _, f := range entry.Fields {
+			if name := f.Name(); name != "" {
+				return "@string:" + strings.ToLower(name)
+			}
+		}
+		return "@string"
+	case entry.IsSpecial():
+		return "@" + strings.ToLower(entry.Kind.Value) + ":" + text(entry)
+	default:
+		return "entry:" + strings.ToLower(entry.Label())
+	}
+}
@@ -0,0 +1,162 @@
+package merge
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name          string
+		base          string
+		ours          string
+		theirs        string
+		want          string
+		wantConflicts int
+	}{
+		{
+			"identical changes",
+			"@misc{a, title = {A}}\n",
+			"@misc{a, title = {B}}\n",
+			"@misc{a, title = {B}}\n",
+			"@misc{a, title = {B}}\n",
+			0,
+		},
+		{
+			"changes to different fields",
+			"@article{a,\n  title = {A},\n  year = 2000\n}\n",
+			"@article{a,\n  title = {A ours},\n  year = 2000\n}\n",
+			"@article{a,\n  title = {A},\n  year = 2001\n}\n",
+			"@article{a,\n  title = {A ours},\n  year = 2001\n}\n",
+			0,
+		},
+		{
+			"fields added and removed",
+			"@article{a,\n  title = {A},\n  year = 2000\n}\n",
+			"@article{a,\n  title = {A},\n  year = 2000,\n  note = {ours}\n}\n",
+			"@article{a,\n  title = {A}\n}\n",
+			"@article{a,\n  title = {A},\n  note = {ours}\n}\n",
+			0,
+		},
+		{
+			"field added by theirs",
+			"@article{a,\n  title = {A}\n}\n",
+			"@ARTICLE{a,\n  title = {A}\n}\n",
+			"@article{a,\n  title = {A},\n  year = 2000\n}\n",
+			"@ARTICLE{a,\n  title = {A},\n  year = 2000,\n}\n",
+			0,
+		},
+		{
+			"entries added and removed",
+			"@misc{a, title = {A}}\n@misc{b, title = {B}}\n@misc{c, title = {C}}\n",
+			"@misc{a, title = {A}}\n@misc{c, title = {C}}\n@misc{d, title = {D}}\n",
+			"@misc{a, title = {A}}\n@misc{e, title = {E}}\n@misc{b, title = {B}}\n@misc{c, title = {C}}\n",
+			"@misc{a, title = {A}}\n@misc{e, title = {E}}\n@misc{c, title = {C}}\n@misc{d, title = {D}}\n",
+			0,
+		},
+		{
+			"labels match case-insensitively",
+			"@misc{Key, title = {A}, year = 2000}\n",
+			"@misc{Key, title = {B}, year = 2000}\n",
+			"@misc{key, title = {A}, year = 2001}\n",
+			"@misc{Key, title = {B}, year = 2001}\n",
+			0,
+		},
+		{
+			"conflicting field",
+			"@article{a,\n  title = {A},\n  year = 2000\n}\n",
+			"@article{a,\n  title = {A ours},\n  year = 2000\n}\n",
+			"@article{a,\n  title = {A theirs},\n  year = 2000\n}\n",
+			"@article{a,\n<<<<<<< ours\n  title = {A ours},\n=======\n  title = {A theirs},\n>>>>>>> theirs\n  year = 2000\n}\n",
+			1,
+		},
+		{
+			"conflicting last field",
+			"@article{a,\n  year = 2000\n}\n",
+			"@article{a,\n  year = 2001\n}\n",
+			"@article{a,\n  year = 2002\n}\n",
+			"@article{a,\n<<<<<<< ours\n  year = 2001,\n=======\n  year = 2002,\n>>>>>>> theirs\n}\n",
+			1,
+		},
+		{
+			"modified and deleted entry",
+			"@misc{a, title = {A}}\n\n@misc{b, title = {B}}\n",
+			"@misc{a, title = {A2}}\n\n@misc{b, title = {B}}\n",
+			"@misc{b, title = {B}}\n",
+			"<<<<<<< ours\n@misc{a, title = {A2}}\n=======\n>>>>>>> theirs\n\n@misc{b, title = {B}}\n",
+			1,
+		},
+		{
+			"conflicting kinds",
+			"@misc{a, title = {A}}\n",
+			"@book{a, title = {A}}\n",
+			"@article{a, title = {A}}\n",
+			"<<<<<<< ours\n@book{a, title = {A}}\n=======\n@article{a, title = {A}}\n>>>>>>> theirs\n",
+			1,
+		},
+		{
+			"both added",
+			"",
+			"@misc{a, title = {A}, year = 2000}\n",
+			"@misc{a, title = {A}, note = {N}}\n",
+			"@misc{a, title = {A}, year = 2000, note = {N},}\n",
+			0,
+		},
+		{
+			"string macros",
+			"@string{me = {Me}}\n@misc{a, author = me}\n",
+			"@string{me = {Myself}}\n@misc{a, author = me}\n",
+			"@string{me = {Me}}\n@string{you = {You}}\n@misc{a, author = me}\n",
+			"@string{me = {Myself}}\n@string{you = {You}}\n@misc{a, author = me}\n",
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, ours, theirs := mustParse(t, tt.base), mustParse(t, tt.ours), mustParse(t, tt.theirs)
+			before := mustWrite(t, ours)
+
+			result, conflicts := Merge(base, ours, theirs, Options{})
+			if got := mustWrite(t, result); got != tt.want {
+				t.Errorf("Merge() got = %q, want %q", got, tt.want)
+			}
+			if conflicts != tt.wantConflicts {
+				t.Errorf("Merge() conflicts = %v, want %v", conflicts, tt.wantConflicts)
+			}
+			if after := mustWrite(t, ours); after != before {
+				t.Errorf("Merge() modified ours = %q, want %q", after, before)
+			}
+		})
+	}
+}
+
+func TestMerge_options(t *testing.T) {
+	base := mustParse(t, "@misc{a, year = 2000}")
+	ours := mustParse(t, "@misc{a, year = 2001}")
+	theirs := mustParse(t, "@misc{a, year = 2002}")
+
+	result, _ := Merge(base, ours, theirs, Options{OursLabel: "HEAD", TheirsLabel: "branch", MarkerSize: 3})
+	want := "@misc{a,\n<<< HEAD\n year = 2001,\n===\n year = 2002,\n>>> branch\n}"
+	if got := mustWrite(t, result); got != want {
+		t.Errorf("Merge() got = %q, want %q", got, want)
+	}
+}
+
+func mustParse(t *testing.T, s string) *bibliography.BibFile {
+	file, err := bibliography.NewBibFileFromReader(utils.NewRuneReaderFromString(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func mustWrite(t *testing.T, file *bibliography.BibFile) string {
+	var buffer bytes.Buffer
+	if err := file.Write(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.String()
+}
@@ -0,0 +1,334 @@
+package query
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// Parse parses a query.
+// If not nil, err is an instance of utils.ReaderError.
+func Parse(source string) (query *Query, err error) {
+	p := &parser{reader: utils.NewRuneReaderFromString(source)}
+	if err = p.lex(); err != nil {
+		return
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorAt(tok, "Unexpected %q in query", tok.value)
+	}
+	if root == nil {
+		return nil, p.errorAt(p.peek(), "Empty query")
+	}
+	return &Query{source: source, root: root}, nil
+}
+
+// MustParse is like Parse, but panics if the query cannot be parsed
+func MustParse(source string) *Query {
+	query, err := Parse(source)
+	if err != nil {
+		panic(err)
+	}
+	return query
+}
+
+// tokenKind is the kind of a token
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenOpen
+	tokenClose
+)
+
+// token is a single token of a query
+type token struct {
+	kind  tokenKind
+	value string
+	pos   utils.ReaderPosition
+}
+
+// isOperatorRune checks if r may start an operator
+func isOperatorRune(r rune) bool {
+	return strings.ContainsRune(":=!~<>", r)
+}
+
+// parser holds the state of parsing a query
+type parser struct {
+	reader *utils.RuneReader
+	tokens []token
+	index  int
+}
+
+// lex splits the query into tokens
+func (p *parser) lex() (err error) {
+	afterOperator := false
+	for {
+		if _, err = p.reader.EatWhile(unicode.IsSpace); err != nil {
+			return utils.WrapErrorF(p.reader, err, "Unexpected error while attempting to read query")
+		}
+
+		var r rune
+		var pos utils.ReaderPosition
+		r, pos, err = p.reader.Peek()
+		if err != nil {
+			return utils.WrapErrorF(p.reader, err, "Unexpected error while attempting to read query")
+		}
+
+		tok := token{pos: pos}
+		switch {
+		case pos.EOF:
+			p.tokens = append(p.tokens, tok)
+			return nil
+		case r == '(' || r == ')':
+			p.reader.Eat()
+			tok.kind, tok.value = tokenOpen, string(r)
+			if r == ')' {
+				tok.kind = tokenClose
+			}
+		case r == '"':
+			tok.kind = tokenString
+			if tok.value, err = p.readString(); err != nil {
+				return
+			}
+		case isOperatorRune(r) && !afterOperator:
+			tok.kind = tokenOperator
+			if tok.value, err = p.readOperator(); err != nil {
+				return
+			}
+		default:
+			// values may contain operator characters, field names may not
+			tok.kind = tokenWord
+			tok.value, _, err = p.reader.ReadWhile(func(r rune) bool {
+				return !unicode.IsSpace(r) && r != '(' && r != ')' && r != '"' && (afterOperator || !isOperatorRune(r))
+			})
+			if err != nil {
+				return utils.WrapErrorF(p.reader, err, "Unexpected error while attempting to read query")
+			}
+		}
+
+		afterOperator = tok.kind == tokenOperator
+		p.tokens = append(p.tokens, tok)
+	}
+}
+
+// readString reads a double-quoted string
+func (p *parser) readString() (value string, err error) {
+	p.reader.Eat() // opening '"'
+
+	var builder strings.Builder
+	escaped := false
+	for {
+		var r rune
+		var pos utils.ReaderPosition
+		r, pos, err = p.reader.Read()
+		if err != nil {
+			return "", utils.WrapErrorF(p.reader, err, "Unexpected error while attempting to read string")
+		}
+		switch {
+		case pos.EOF:
+			return "", utils.NewUnexpectedError(p.reader, utils.CodeUnterminatedQuote, "string", "\"", "")
+		case escaped:
+			if r != '"' && r != '\\' {
+				builder.WriteRune('\\')
+			}
+			builder.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			return builder.String(), nil
+		default:
+			builder.WriteRune(r)
+		}
+	}
+}
+
+// readOperator reads a comparison operator
+func (p *parser) readOperator() (op string, err error) {
+	r, _, err := p.reader.Read()
+	if err != nil {
+		return "", utils.WrapErrorF(p.reader, err, "Unexpected error while attempting to read operator")
+	}
+	op = string(r)
+
+	// '!=', '<=' and '>=' consist of two characters
+	if r == '!' || r == '<' || r == '>' {
+		next, pos, err := p.reader.Peek()
+		if err != nil {
+			return "", utils.WrapErrorF(p.reader, err, "Unexpected error while attempting to read operator")
+		}
+		if !pos.EOF && next == '=' {
+			p.reader.Eat()
+			op += "="
+		}
+	}
+
+	if op == "!" {
+		return "", utils.NewErrorF(p.reader, "Unknown operator %q", op)
+	}
+	return op, nil
+}
+
+// peek returns the current token
+func (p *parser) peek() token {
+	return p.tokens[p.index]
+}
+
+// next returns the current token and advances to the next one
+func (p *parser) next() token {
+	tok := p.tokens[p.index]
+	if tok.kind != tokenEOF {
+		p.index++
+	}
+	return tok
+}
+
+// isKeyword checks if tok is the given keyword
+func (tok token) isKeyword(keyword string) bool {
+	return tok.kind == tokenWord && tok.value == keyword
+}
+
+// errorAt returns an error located at tok
+func (p *parser) errorAt(tok token, format string, args ...interface{}) *utils.ReaderError {
+	err := utils.NewErrorF(p.reader, format, args...)
+	err.Location = tok.pos
+	return err
+}
+
+// parseOr parses terms combined with 'OR'
+func (p *parser) parseOr() (n node, err error) {
+	if n, err = p.parseAnd(); err != nil || n == nil {
+		return
+	}
+	for p.peek().isKeyword("OR") {
+		tok := p.next()
+
+		var right node
+		if right, err = p.parseAnd(); err != nil {
+			return
+		}
+		if right == nil {
+			return nil, p.errorAt(tok, "Missing operand of 'OR'")
+		}
+		n = &orNode{left: n, right: right}
+	}
+	return
+}
+
+// parseAnd parses terms combined with 'AND' or juxtaposition
+func (p *parser) parseAnd() (n node, err error) {
+	if n, err = p.parseNot(); err != nil || n == nil {
+		return
+	}
+	for {
+		tok := p.peek()
+		explicit := tok.isKeyword("AND")
+		if explicit {
+			p.next()
+		}
+
+		var right node
+		if right, err = p.parseNot(); err != nil {
+			return
+		}
+		if right == nil {
+			if explicit {
+				return nil, p.errorAt(tok, "Missing operand of 'AND'")
+			}
+			return
+		}
+		n = &andNode{left: n, right: right}
+	}
+}
+
+// parseNot parses a term optionally preceded by 'NOT'.
+// Returns a nil node if there is no term.
+func (p *parser) parseNot() (n node, err error) {
+	tok := p.peek()
+	if !tok.isKeyword("NOT") {
+		return p.parseTerm()
+	}
+	p.next()
+
+	if n, err = p.parseNot(); err != nil {
+		return
+	}
+	if n == nil {
+		return nil, p.errorAt(tok, "Missing operand of 'NOT'")
+	}
+	return &notNode{operand: n}, nil
+}
+
+// parseTerm parses a single term or a parenthesized query.
+// Returns a nil node if there is no term.
+func (p *parser) parseTerm() (n node, err error) {
+	tok := p.peek()
+	switch {
+	case tok.kind == tokenOpen:
+		p.next()
+		if n, err = p.parseOr(); err != nil {
+			return
+		}
+		if n == nil {
+			return nil, p.errorAt(tok, "Empty parentheses")
+		}
+		if end := p.next(); end.kind != tokenClose {
+			return nil, p.errorAt(tok, "Unclosed parenthesis")
+		}
+		return
+	case tok.kind == tokenString:
+		p.next()
+		return &textNode{value: tok.value}, nil
+	case tok.kind != tokenWord || tok.isKeyword("AND") || tok.isKeyword("OR"):
+		return nil, nil
+	}
+	p.next()
+
+	// a word by itself
+	if p.peek().kind != tokenOperator {
+		return &textNode{value: tok.value}, nil
+	}
+
+	// a comparison
+	op := p.next()
+	value := p.next()
+	if value.kind != tokenWord && value.kind != tokenString {
+		return nil, p.errorAt(op, "Missing value after %q", op.value)
+	}
+	return newTerm(p, tok, op, value)
+}
+
+// newTerm creates a new comparison
+func newTerm(p *parser, field, op, value token) (n node, err error) {
+	name := strings.ToLower(field.value)
+	if name == "has" {
+		if op.value != ":" {
+			return nil, p.errorAt(op, "Unsupported operator %q for 'has'", op.value)
+		}
+		return &hasNode{field: strings.ToLower(value.value)}, nil
+	}
+
+	term := &termNode{field: name, op: op.value, value: value.value}
+	switch op.value {
+	case "~":
+		if term.regexp, err = regexp.Compile(value.value); err != nil {
+			return nil, p.errorAt(value, "Invalid regular expression %q: %s", value.value, err)
+		}
+	case "<", "<=", ">", ">=":
+		if term.number, err = strconv.ParseFloat(value.value, 64); err != nil {
+			return nil, p.errorAt(value, "Invalid number %q", value.value)
+		}
+	}
+	return term, nil
+}
@@ -0,0 +1,220 @@
+// Package query implements a small query language for selecting entries of a bibliography.
+//
+// A query consists of terms combined using the boolean operators 'AND', 'OR' and 'NOT' and grouped using parentheses.
+// 'NOT' binds strongest and 'OR' weakest; terms written next to each other are implicitly combined using 'AND'.
+//
+// A term either compares a field to a value, as in 'field:value', or consists of a single value, in which case it matches entries with the value occurring in their label or any of their fields.
+// Values are either words or double-quoted strings; a double quote within a string may be escaped using a backslash.
+// The following comparisons are supported:
+//
+//	field:value   field contains value, ignoring case
+//	field=value   field equals value, ignoring case
+//	field!=value  field exists and does not equal value, ignoring case
+//	field~regex   field matches the regular expression
+//	field<number  field is numerically less than number; similarly '<=', '>' and '>='
+//
+// Fields are compared using their evaluated values, with LaTeX decoded to unicode.
+// The special field names 'kind' and 'label' refer to the kind and label of an entry, for these ':' compares for equality.
+// The special term 'has:field' checks if an entry has the given field.
+//
+// For example:
+//
+//	kind:inproceedings AND year>=2018 AND author~"Kohlhase" AND NOT has:doi
+package query
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/latex"
+)
+
+// Query is a parsed query
+type Query struct {
+	source string
+	root   node
+}
+
+// String returns the source of this query
+func (query *Query) String() string {
+	return query.source
+}
+
+// Match checks if entry matches this query.
+// macros are used to evaluate fields, see BibFile.Macros().
+// Special entries, such as '@string' and '@preamble', never match.
+func (query *Query) Match(entry *bibliography.BibEntry, macros map[string]string) bool {
+	if entry.IsSpecial() {
+		return false
+	}
+	return query.root.match(&subject{entry: entry, macros: macros})
+}
+
+// Entries returns the entries of file matching this query, in their original order
+func (query *Query) Entries(file *bibliography.BibFile) (entries []*bibliography.BibEntry) {
+	macros := file.Macros()
+	for _, entry := range file.Entries {
+		if query.Match(entry, macros) {
+			entries = append(entries, entry)
+		}
+	}
+	return
+}
+
+// Filter returns a new BibFile containing the entries of file matching this query, in their original order.
+// Entries are shared with the original file, including the text before them.
+// To print matching entries, use Entries instead.
+func (query *Query) Filter(file *bibliography.BibFile) *bibliography.BibFile {
+	return &bibliography.BibFile{Entries: query.Entries(file), Suffix: file.Suffix, LineEnding: file.LineEnding, Encoding: file.Encoding, BOM: file.BOM}
+}
+
+// subject is an entry being matched against a query
+type subject struct {
+	entry  *bibliography.BibEntry
+	macros map[string]string
+	values map[string][]string // cache of decoded field values
+}
+
+// get returns the decoded values of all fields with the given (lower case) name
+func (s *subject) get(name string) []string {
+	switch name {
+	case "kind":
+		return []string{s.entry.Kind.Value}
+	case "label":
+		return []string{s.entry.Label()}
+	}
+
+	if values, ok := s.values[name]; ok {
+		return values
+	}
+	var values []string
+	for _, field := range s.entry.Fields {
+		if strings.EqualFold(field.Name(), name) {
+			values = append(values, latex.Decode(field.Evaluate(s.macros)))
+		}
+	}
+	if s.values == nil {
+		s.values = make(map[string][]string)
+	}
+	s.values[name] = values
+	return values
+}
+
+// all returns the label and the decoded values of all fields
+func (s *subject) all() (values []string) {
+	values = append(values, s.entry.Label())
+	for _, field := range s.entry.Fields {
+		if name := field.Name(); name != "" {
+			values = append(values, s.get(strings.ToLower(name))...)
+		}
+	}
+	return
+}
+
+// node is a node of a parsed query
+type node interface {
+	match(s *subject) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n *andNode) match(s *subject) bool { return n.left.match(s) && n.right.match(s) }
+
+type orNode struct{ left, right node }
+
+func (n *orNode) match(s *subject) bool { return n.left.match(s) || n.right.match(s) }
+
+type notNode struct{ operand node }
+
+func (n *notNode) match(s *subject) bool { return !n.operand.match(s) }
+
+// textNode matches a value anywhere within an entry
+type textNode struct{ value string }
+
+func (n *textNode) match(s *subject) bool {
+	for _, v := range s.all() {
+		if containsFold(v, n.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasNode matches entries having a field
+type hasNode struct{ field string }
+
+func (n *hasNode) match(s *subject) bool {
+	return len(s.get(n.field)) > 0
+}
+
+// termNode compares a field to a value
+type termNode struct {
+	field string
+	op    string
+	value string
+
+	regexp *regexp.Regexp // for '~'
+	number float64        // for '<', '<=', '>' and '>='
+}
+
+func (n *termNode) match(s *subject) bool {
+	for _, v := range s.get(n.field) {
+		if n.compare(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare compares a single value of a field
+func (n *termNode) compare(v string) bool {
+	switch n.op {
+	case ":":
+		if n.field == "kind" || n.field == "label" {
+			return strings.EqualFold(strings.TrimSpace(v), n.value)
+		}
+		return containsFold(v, n.value)
+	case "=":
+		return strings.EqualFold(strings.TrimSpace(v), n.value)
+	case "!=":
+		return !strings.EqualFold(strings.TrimSpace(v), n.value)
+	case "~":
+		return n.regexp.MatchString(v)
+	}
+
+	number, ok := leadingNumber(v)
+	if !ok {
+		return false
+	}
+	switch n.op {
+	case "<":
+		return number < n.number
+	case "<=":
+		return number <= n.number
+	case ">":
+		return number > n.number
+	case ">=":
+		return number >= n.number
+	}
+	return false
+}
+
+// containsFold checks if s contains substr, ignoring case
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// leadingNumber parses the number at the start of s, ignoring leading whitespace.
+// This allows values like '2018a' to be compared numerically.
+func leadingNumber(s string) (number float64, ok bool) {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	end := 0
+	for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.' || end == 0 && s[end] == '-') {
+		end++
+	}
+	number, err := strconv.ParseFloat(s[:end], 64)
+	return number, err == nil
+}
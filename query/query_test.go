@@ -0,0 +1,88 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+func TestQuery_Entries(t *testing.T) {
+	file, err := bibliography.NewBibFileFromFile("testdata/library.bib")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{`kind:inproceedings AND year>=2018 AND author~"Kohlhase" AND NOT has:doi`, []string{"Kohlhase2020"}},
+		{`kind:inproceedings`, []string{"Kohlhase2018", "Kohlhase2020"}},
+		{`kind:proceedings`, nil},
+		{`KIND=Book`, []string{"Old1999"}},
+		{`label:kohlhase2018`, []string{"Kohlhase2018"}},
+		{`has:doi`, []string{"Kohlhase2018"}},
+		{`year>2018`, []string{"Kohlhase2020", "Other2019"}},
+		{`year<=1999 OR year=2019`, []string{"Other2019", "Old1999"}},
+		{`year!=2018`, []string{"Kohlhase2020", "Other2019", "Old1999"}},
+		{`title:schöne`, []string{"Kohlhase2020"}},
+		{`title:"math in the web"`, []string{"Kohlhase2018"}},
+		{`author~"^Michael"`, []string{"Kohlhase2018", "Kohlhase2020"}},
+		{`wiesing`, []string{"Kohlhase2018"}},
+		{`"jane doe" OR smith`, []string{"Other2019", "Old1999"}},
+		{`kohlhase NOT year:2020`, []string{"Kohlhase2018"}},
+		{`NOT (kind:book OR kind:article)`, []string{"Kohlhase2018", "Kohlhase2020"}},
+		{`kind:article OR kind:book AND year<2000`, []string{"Other2019", "Old1999"}},
+		{`doi:10.1000/abc`, []string{"Kohlhase2018"}},
+		{`note:anything`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			var got []string
+			for _, entry := range query.Entries(file) {
+				got = append(got, entry.Label())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Query.Entries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_error(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantPos utils.ReaderPosition
+	}{
+		{``, utils.ReaderPosition{Line: 0, Column: 0, UTF16Column: 0, Offset: 0, RuneOffset: 0, EOF: true}},
+		{`year>=abc`, utils.ReaderPosition{Line: 0, Column: 6, UTF16Column: 6, Offset: 6, RuneOffset: 6}},
+		{`author~"("`, utils.ReaderPosition{Line: 0, Column: 7, UTF16Column: 7, Offset: 7, RuneOffset: 7}},
+		{`title:"unclosed`, utils.ReaderPosition{Line: 0, Column: 15, UTF16Column: 15, Offset: 15, RuneOffset: 15, EOF: true}},
+		{`(kind:book`, utils.ReaderPosition{Line: 0, Column: 0, UTF16Column: 0, Offset: 0, RuneOffset: 0}},
+		{`kind:book AND`, utils.ReaderPosition{Line: 0, Column: 10, UTF16Column: 10, Offset: 10, RuneOffset: 10}},
+		{`NOT`, utils.ReaderPosition{Line: 0, Column: 0, UTF16Column: 0, Offset: 0, RuneOffset: 0}},
+		{`year:`, utils.ReaderPosition{Line: 0, Column: 4, UTF16Column: 4, Offset: 4, RuneOffset: 4}},
+		{`year!2018`, utils.ReaderPosition{Line: 0, Column: 5, UTF16Column: 5, Offset: 5, RuneOffset: 5}},
+		{`has=doi`, utils.ReaderPosition{Line: 0, Column: 3, UTF16Column: 3, Offset: 3, RuneOffset: 3}},
+		{`kind:book)`, utils.ReaderPosition{Line: 0, Column: 9, UTF16Column: 9, Offset: 9, RuneOffset: 9}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			rerr, ok := err.(*utils.ReaderError)
+			if !ok {
+				t.Fatalf("Parse() error = %v, want a *utils.ReaderError", err)
+			}
+			if rerr.Location != tt.wantPos {
+				t.Errorf("Parse() error location = %v, want %v", rerr.Location, tt.wantPos)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package render
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/latex"
+)
+
+// Bibliography is the data passed to templates
+type Bibliography struct {
+	Entries []*Entry // citable entries in the order of the file
+}
+
+// Entry is a single citable entry of a Bibliography
+type Entry struct {
+	Kind   string            // kind of the entry, in lower case
+	Label  string            // label of the entry
+	Fields map[string]string // field names in lower case, mapped to their evaluated and still LaTeX-encoded values
+}
+
+// Group is a group of entries, see Bibliography.ByYear and Bibliography.ByKind
+type Group struct {
+	Key     string // the year or kind shared by all entries
+	Entries []*Entry
+}
+
+// NewBibliography creates a new Bibliography from the citable entries in file.
+// Macros are expanded using the '@string' entries of file, see bibliography.BibFile.Data.
+func NewBibliography(file *bibliography.BibFile) *Bibliography {
+	data := file.Data()
+
+	b := &Bibliography{Entries: make([]*Entry, len(data.Entries))}
+	for i, entry := range data.Entries {
+		b.Entries[i] = &Entry{Kind: entry.Kind, Label: entry.Label, Fields: entry.Fields}
+	}
+	return b
+}
+
+// ByYear groups entries by year, starting with the most recent year.
+// Years that are not numbers, such as 'forthcoming', follow in alphabetical order.
+// Entries without a year come last, in a group with an empty key.
+func (b *Bibliography) ByYear() []Group {
+	groups := group(b.Entries, (*Entry).Year)
+	sort.SliceStable(groups, func(i, j int) bool {
+		x, y := groups[i].Key, groups[j].Key
+		if x == "" || y == "" {
+			return y == ""
+		}
+		nx, errX := strconv.Atoi(x)
+		ny, errY := strconv.Atoi(y)
+		if errX != nil || errY != nil {
+			return errX == nil || (errY != nil && x < y)
+		}
+		return nx > ny
+	})
+	return groups
+}
+
+// kindOrder is the order of the groups returned by ByKind.
+// Other kinds follow in alphabetical order.
+var kindOrder = []string{"article", "inproceedings", "incollection", "inbook", "book", "proceedings", "phdthesis", "mastersthesis", "techreport", "manual", "unpublished", "misc"}
+
+// ByKind groups entries by kind, ordered from articles and papers to other publications.
+func (b *Bibliography) ByKind() []Group {
+	groups := group(b.Entries, func(e *Entry) string { return e.Kind })
+	rank := func(kind string) int {
+		for i, k := range kindOrder {
+			if k == kind {
+				return i
+			}
+		}
+		return len(kindOrder)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		ri, rj := rank(groups[i].Key), rank(groups[j].Key)
+		if ri != rj {
+			return ri < rj
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
+
+// group groups entries by key, keeping the order of entries within each group
+func group(entries []*Entry, key func(*Entry) string) (groups []Group) {
+	index := make(map[string]int)
+	for _, entry := range entries {
+		k := key(entry)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, Group{Key: k})
+		}
+		groups[i].Entries = append(groups[i].Entries, entry)
+	}
+	return
+}
+
+// Field returns the value of a field decoded into unicode text, or the empty string
+func (e *Entry) Field(name string) string {
+	return strings.TrimSpace(latex.Decode(e.Fields[strings.ToLower(name)]))
+}
+
+// Raw returns the LaTeX-encoded value of a field, or the empty string
+func (e *Entry) Raw(name string) string {
+	return e.Fields[strings.ToLower(name)]
+}
+
+// Title returns the decoded title of this entry
+func (e *Entry) Title() string {
+	return e.Field("title")
+}
+
+// Year returns the decoded year of this entry
+func (e *Entry) Year() string {
+	return e.Field("year")
+}
+
+// Authors returns the authors of this entry, parsed using bibliography.ParseNames
+func (e *Entry) Authors() []bibliography.Name {
+	return bibliography.ParseNames(e.Fields["author"])
+}
+
+// Editors returns the editors of this entry, parsed using bibliography.ParseNames
+func (e *Entry) Editors() []bibliography.Name {
+	return bibliography.ParseNames(e.Fields["editor"])
+}
+
+// Venue returns where this entry was published, such as the journal of an article or the school of a thesis
+func (e *Entry) Venue() string {
+	for _, name := range []string{"journal", "booktitle", "school", "institution", "organization", "publisher", "howpublished"} {
+		if value := e.Field(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// DOI returns the DOI of this entry, without a 'doi:' or resolver prefix
+func (e *Entry) DOI() string {
+	doi := rawURL(e.Fields["doi"])
+	for _, prefix := range []string{"https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/", "doi:"} {
+		if len(doi) >= len(prefix) && strings.EqualFold(doi[:len(prefix)], prefix) {
+			return doi[len(prefix):]
+		}
+	}
+	return doi
+}
+
+// URL returns a link to this entry, using its DOI if it has one
+func (e *Entry) URL() string {
+	if doi := e.DOI(); doi != "" {
+		return DOIURL(doi)
+	}
+	return rawURL(e.Fields["url"])
+}
+
+// rawURL turns the value of a field holding a URL or DOI into plain text.
+// Unlike decoding the value, only escaped characters and braces are replaced, so that characters like '~' remain intact.
+func rawURL(value string) string {
+	var builder strings.Builder
+	escaped := false
+	for _, r := range strings.TrimSpace(value) {
+		switch {
+		case escaped:
+			builder.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '{' || r == '}':
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// DOIURL returns the URL resolving doi
+func DOIURL(doi string) string {
+	return "https://doi.org/" + doi
+}
@@ -0,0 +1,95 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tkw1536/gotexml/bibliography"
+)
+
+// groupLabels returns the keys of groups, along with the labels of their entries
+func groupLabels(groups []Group) (labels [][]string) {
+	for _, g := range groups {
+		group := []string{g.Key}
+		for _, e := range g.Entries {
+			group = append(group, e.Label)
+		}
+		labels = append(labels, group)
+	}
+	return
+}
+
+func TestBibliography_ByYear(t *testing.T) {
+	file, err := bibliography.NewBibFileFromString(`
+		@misc{a, year = 2001} @misc{b} @misc{c, year = 1999} @misc{d, year = {2001}}
+		@misc{e, year = {forthcoming}} @misc{f, year = 2020} @string{x = "y"}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{{"2020", "f"}, {"2001", "a", "d"}, {"1999", "c"}, {"forthcoming", "e"}, {"", "b"}}
+	if got := groupLabels(NewBibliography(file).ByYear()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Bibliography.ByYear() = %v, want %v", got, want)
+	}
+}
+
+func TestBibliography_ByKind(t *testing.T) {
+	file, err := bibliography.NewBibFileFromString(`
+		@misc{a} @Book{b} @article{c} @online{d} @book{e} @dataset{f} @inproceedings{g}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{{"article", "c"}, {"inproceedings", "g"}, {"book", "b", "e"}, {"misc", "a"}, {"dataset", "f"}, {"online", "d"}}
+	if got := groupLabels(NewBibliography(file).ByKind()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Bibliography.ByKind() = %v, want %v", got, want)
+	}
+}
+
+func TestEntry(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		title  string
+		venue  string
+		doi    string
+		url    string
+	}{
+		{"empty", "@misc{a}", "", "", "", ""},
+		{"decoded", "@misc{a, title = {{\\'E}t{\\'e} -- {\\TeX}}}", "Été – TeX", "", "", ""},
+		{"macros", "@string{j = {Journal}} @article{a, journal = j # { of Tests}}", "", "Journal of Tests", "", ""},
+		{"venue order", "@inproceedings{a, publisher = {P}, booktitle = {B}}", "", "B", "", ""},
+		{"url", "@misc{a, url = {https://example.com/~user/a\\_b?x=1&y={2}}}", "", "", "", "https://example.com/~user/a_b?x=1&y=2"},
+		{"doi", "@misc{a, doi = {10.1000/a\\_b}, url = {https://example.com}}", "", "", "10.1000/a_b", "https://doi.org/10.1000/a_b"},
+		{"doi prefix", "@misc{a, doi = {doi:10.1000/x}}", "", "", "10.1000/x", "https://doi.org/10.1000/x"},
+		{"doi resolver", "@misc{a, DOI = {HTTPS://dx.doi.org/10.1000/x}}", "", "", "10.1000/x", "https://doi.org/10.1000/x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := bibliography.NewBibFileFromString(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries := NewBibliography(file).Entries
+			if len(entries) != 1 {
+				t.Fatalf("NewBibliography().Entries = %v, want a single entry", entries)
+			}
+			entry := entries[0]
+
+			if got := entry.Title(); got != tt.title {
+				t.Errorf("Entry.Title() = %q, want %q", got, tt.title)
+			}
+			if got := entry.Venue(); got != tt.venue {
+				t.Errorf("Entry.Venue() = %q, want %q", got, tt.venue)
+			}
+			if got := entry.DOI(); got != tt.doi {
+				t.Errorf("Entry.DOI() = %q, want %q", got, tt.doi)
+			}
+			if got := entry.URL(); got != tt.url {
+				t.Errorf("Entry.URL() = %q, want %q", got, tt.url)
+			}
+		})
+	}
+}
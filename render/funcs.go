@@ -0,0 +1,187 @@
+package render
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/latex"
+)
+
+// Funcs are the functions available to templates, in addition to the methods of Bibliography, Group and Entry
+var Funcs = map[string]interface{}{
+	"decode":     latex.Decode,
+	"names":      FormatNames,
+	"shortNames": FormatShortNames,
+	"kindName":   KindName,
+	"sentence":   Sentence,
+	"doiURL":     DOIURL,
+	"md":         EscapeMarkdown,
+	"mdURL":      EscapeMarkdownURL,
+}
+
+// FormatNames formats a list of names as decoded text, such as 'Donald E. Knuth, Leslie Lamport and Jane Doe'.
+// A final name 'others' is written as 'et al.'.
+func FormatNames(names []bibliography.Name) string {
+	return formatNames(names, func(name bibliography.Name) string {
+		return joinWords(latex.Decode(name.First), latex.Decode(name.Von), latex.Decode(name.Last)+suffix(name.Jr))
+	})
+}
+
+// FormatShortNames formats a list of names like FormatNames, but abbreviates first names to their initials, such as 'D. E. Knuth'
+func FormatShortNames(names []bibliography.Name) string {
+	return formatNames(names, func(name bibliography.Name) string {
+		return joinWords(Initials(latex.Decode(name.First)), latex.Decode(name.Von), latex.Decode(name.Last)+suffix(name.Jr))
+	})
+}
+
+// formatNames formats names using format for each name
+func formatNames(names []bibliography.Name, format func(bibliography.Name) string) string {
+	others := len(names) > 0 && names[len(names)-1].IsOthers()
+	if others {
+		names = names[:len(names)-1]
+	}
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = format(name)
+	}
+
+	switch {
+	case others && len(parts) > 0:
+		return strings.Join(parts, ", ") + " et al."
+	case len(parts) <= 1:
+		return strings.Join(parts, "")
+	default:
+		return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+	}
+}
+
+// suffix returns the decoded jr part of a name, preceded by a comma
+func suffix(jr string) string {
+	if jr = latex.Decode(jr); jr != "" {
+		return ", " + jr
+	}
+	return ""
+}
+
+// joinWords joins the non-empty words using spaces
+func joinWords(words ...string) string {
+	nonEmpty := words[:0]
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			nonEmpty = append(nonEmpty, w)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
+
+// Initials abbreviates decoded first names to their initials, such as 'Jean-Paul Donald' to 'J.-P. D.'.
+// Names that are already abbreviated are kept.
+func Initials(first string) string {
+	words := strings.Fields(first)
+	for i, word := range words {
+		parts := strings.Split(word, "-")
+		for j, part := range parts {
+			if r, _ := utf8.DecodeRuneInString(part); unicode.IsLetter(r) {
+				parts[j] = string(r) + "."
+			}
+		}
+		words[i] = strings.Join(parts, "-")
+	}
+	return strings.Join(words, " ")
+}
+
+// kindNames are the names of groups of entries of each kind
+var kindNames = map[string]string{
+	"article":       "Journal Articles",
+	"book":          "Books",
+	"booklet":       "Booklets",
+	"inbook":        "Book Chapters",
+	"incollection":  "Book Chapters",
+	"inproceedings": "Conference Papers",
+	"conference":    "Conference Papers",
+	"manual":        "Manuals",
+	"mastersthesis": "Master's Theses",
+	"phdthesis":     "PhD Theses",
+	"proceedings":   "Proceedings",
+	"techreport":    "Technical Reports",
+	"unpublished":   "Unpublished Work",
+	"misc":          "Other Publications",
+}
+
+// KindName returns a heading for entries of the given kind, such as 'Journal Articles' for 'article'
+func KindName(kind string) string {
+	if name, ok := kindNames[strings.ToLower(kind)]; ok {
+		return name
+	}
+	if kind == "" {
+		return kindNames["misc"]
+	}
+	r, size := utf8.DecodeRuneInString(kind)
+	return string(unicode.ToUpper(r)) + kind[size:]
+}
+
+// Sentence ends text with a period, unless it is empty or already ends with punctuation
+func Sentence(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.ContainsAny(text[len(text)-1:], ".?!") {
+		return text
+	}
+	return text + "."
+}
+
+// markdownEscapes are the characters escaped by EscapeMarkdown anywhere within text
+const markdownEscapes = "\\`*_[]<>|&~"
+
+// EscapeMarkdown escapes decoded text for use within Markdown, so that it is displayed as is.
+// Line breaks are replaced by spaces.
+func EscapeMarkdown(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	block := blockMarker(text)
+
+	var builder strings.Builder
+	for i, r := range text {
+		if i == block || strings.ContainsRune(markdownEscapes, r) {
+			builder.WriteByte('\\')
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// blockMarker returns the index of the character that would make text start a heading, list or quote at the beginning of a line, or -1
+func blockMarker(text string) int {
+	if text == "" {
+		return -1
+	}
+	switch text[0] {
+	case '#', '+', '-', '=', '>':
+		return 0
+	}
+
+	// ordered list items, such as '1984. '
+	digits := len(text) - len(strings.TrimLeft(text, "0123456789"))
+	if digits > 0 && digits < len(text) && (text[digits] == '.' || text[digits] == ')') {
+		return digits
+	}
+	return -1
+}
+
+// EscapeMarkdownURL escapes a URL for use as the destination of a Markdown link.
+// URLs using schemes other than http, https, ftp and mailto are replaced by '#'.
+func EscapeMarkdownURL(link string) string {
+	link = strings.TrimSpace(link)
+	u, err := url.Parse(link)
+	if err != nil {
+		return "#"
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "ftp", "mailto":
+	default:
+		return "#"
+	}
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E").Replace(link)
+}
@@ -0,0 +1,147 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/tkw1536/gotexml/bibliography"
+)
+
+func TestFormatNames(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		short string
+	}{
+		{"", "", ""},
+		{"Donald E. Knuth", "Donald E. Knuth", "D. E. Knuth"},
+		{"Knuth, Donald and Lamport, Leslie", "Donald Knuth and Leslie Lamport", "D. Knuth and L. Lamport"},
+		{"A and B and C", "A, B and C", "A, B and C"},
+		{"M{\\\"u}ller, Hans and others", "Hans Müller et al.", "H. Müller et al."},
+		{"van der Berg, Jr., Jean-Paul", "Jean-Paul van der Berg, Jr.", "J.-P. van der Berg, Jr."},
+		{"{World Health Organization}", "World Health Organization", "World Health Organization"},
+		{"{\\'E}mile Zola", "Émile Zola", "É. Zola"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			names := bibliography.ParseNames(tt.value)
+			if got := FormatNames(names); got != tt.want {
+				t.Errorf("FormatNames() = %q, want %q", got, tt.want)
+			}
+			if got := FormatShortNames(names); got != tt.short {
+				t.Errorf("FormatShortNames() = %q, want %q", got, tt.short)
+			}
+		})
+	}
+}
+
+func TestInitials(t *testing.T) {
+	tests := []struct {
+		first string
+		want  string
+	}{
+		{"", ""},
+		{"Donald", "D."},
+		{"Donald E.", "D. E."},
+		{"Jean-Paul", "J.-P."},
+		{"Ürsula", "Ü."},
+		{"(Bob)", "(Bob)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.first, func(t *testing.T) {
+			if got := Initials(tt.first); got != tt.want {
+				t.Errorf("Initials() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKindName(t *testing.T) {
+	tests := []struct {
+		kind string
+		want string
+	}{
+		{"article", "Journal Articles"},
+		{"InProceedings", "Conference Papers"},
+		{"dataset", "Dataset"},
+		{"ärticle", "Ärticle"},
+		{"über_note", "Über_note"},
+		{"", "Other Publications"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			if got := KindName(tt.kind); got != tt.want {
+				t.Errorf("KindName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSentence(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", ""},
+		{"Title", "Title."},
+		{" Title ", "Title."},
+		{"Why?", "Why?"},
+		{"D. E. Knuth", "D. E. Knuth."},
+		{"A. Author et al.", "A. Author et al."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Sentence(tt.text); got != tt.want {
+				t.Errorf("Sentence() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", ""},
+		{"plain text", "plain text"},
+		{"*bold* _it_ `code` [link](x) <b> a|b R&D ~x~ \\", "\\*bold\\* \\_it\\_ \\`code\\` \\[link\\](x) \\<b\\> a\\|b R\\&D \\~x\\~ \\\\"},
+		{"line\nbreaks  and\tspaces", "line breaks and spaces"},
+		{"# heading", "\\# heading"},
+		{"- item", "\\- item"},
+		{"> quote", "\\> quote"},
+		{"1984. Novel", "1984\\. Novel"},
+		{"2) Second", "2\\) Second"},
+		{"1984 Novel", "1984 Novel"},
+		{"a # b - c", "a # b - c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := EscapeMarkdown(tt.text); got != tt.want {
+				t.Errorf("EscapeMarkdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeMarkdownURL(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"https://example.com/a?b=1&c=2", "https://example.com/a?b=1&c=2"},
+		{" http://example.com/a b(c)<d> ", "http://example.com/a%20b%28c%29%3Cd%3E"},
+		{"mailto:someone@example.com", "mailto:someone@example.com"},
+		{"relative/path", "relative/path"},
+		{"javascript:alert(1)", "#"},
+		{"JavaScript:alert(1)", "#"},
+		{"data:text/html,x", "#"},
+		{"http://[::1", "#"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			if got := EscapeMarkdownURL(tt.link); got != tt.want {
+				t.Errorf("EscapeMarkdownURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// Package render renders bibliographies as HTML or Markdown using templates, such as publication lists for websites.
+//
+// Templates are executed with a Bibliography and may use the functions in Funcs.
+// Values are decoded from LaTeX before they are escaped: HTML templates escape them automatically, Markdown templates escape them using the 'md' and 'mdURL' functions.
+// Every template may use the template "entry" to render a single entry.
+package render
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/tkw1536/gotexml/bibliography"
+)
+
+// Format is an output format of a Template
+type Format string
+
+// Formats of templates
+const (
+	FormatHTML     Format = "html"
+	FormatMarkdown Format = "markdown"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// DefaultTemplates are the names of the templates shipped with this package, see NewDefaultTemplate.
+// Templates starting with 'html' produce HTML, those starting with 'markdown' produce Markdown.
+// Templates ending with '-kind' group entries by kind, the others by year.
+var DefaultTemplates = []string{"html", "html-kind", "markdown", "markdown-kind"}
+
+// Template renders a Bibliography in a specific Format
+type Template struct {
+	format  Format
+	execute func(writer io.Writer, data interface{}) error
+}
+
+// NewTemplate parses a template from source.
+// HTML templates are parsed using html/template, Markdown templates using text/template.
+func NewTemplate(format Format, source string) (*Template, error) {
+	switch format {
+	case FormatHTML:
+		entry, err := templates.ReadFile("templates/entry.html.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		t := htmltemplate.New("").Funcs(Funcs)
+		if _, err := t.New("entry.html.tmpl").Parse(string(entry)); err != nil {
+			return nil, err
+		}
+		if _, err := t.Parse(source); err != nil {
+			return nil, err
+		}
+		return &Template{format: format, execute: t.Execute}, nil
+	case FormatMarkdown:
+		entry, err := templates.ReadFile("templates/entry.md.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		t := texttemplate.New("").Funcs(Funcs)
+		if _, err := t.New("entry.md.tmpl").Parse(string(entry)); err != nil {
+			return nil, err
+		}
+		if _, err := t.Parse(source); err != nil {
+			return nil, err
+		}
+		return &Template{format: format, execute: t.Execute}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// NewDefaultTemplate returns the default template with the given name, see DefaultTemplates
+func NewDefaultTemplate(name string) (*Template, error) {
+	source, err := templates.ReadFile("templates/" + name + ".tmpl")
+	if err != nil || strings.HasPrefix(name, "entry.") {
+		return nil, fmt.Errorf("unknown template %q", name)
+	}
+
+	format := FormatHTML
+	if strings.HasPrefix(name, "markdown") {
+		format = FormatMarkdown
+	}
+	return NewTemplate(format, string(source))
+}
+
+// Format returns the format of this template
+func (t *Template) Format() Format {
+	return t.format
+}
+
+// Render renders the citable entries of file, see NewBibliography
+func (t *Template) Render(writer io.Writer, file *bibliography.BibFile) error {
+	return t.execute(writer, NewBibliography(file))
+}
@@ -0,0 +1,132 @@
+package render
+
+import (
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// readLibrary reads testdata/library.bib
+func readLibrary() *bibliography.BibFile {
+	file, err := bibliography.NewBibFileFromString(utils.ReadFileOrPanic(path.Join("testdata", "library.bib")))
+	if err != nil {
+		panic(err)
+	}
+	return file
+}
+
+func TestNewDefaultTemplate(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		asset  string
+	}{
+		{"html", FormatHTML, "library.html"},
+		{"html-kind", FormatHTML, "library-kind.html"},
+		{"markdown", FormatMarkdown, "library.md"},
+		{"markdown-kind", FormatMarkdown, "library-kind.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := NewDefaultTemplate(tt.name)
+			if err != nil {
+				t.Fatalf("NewDefaultTemplate() error = %v", err)
+			}
+			if got := template.Format(); got != tt.format {
+				t.Errorf("NewDefaultTemplate().Format() = %v, want %v", got, tt.format)
+			}
+
+			var builder strings.Builder
+			if err := template.Render(&builder, readLibrary()); err != nil {
+				t.Fatalf("NewDefaultTemplate().Render() error = %v", err)
+			}
+			want := utils.ReadFileOrPanic(path.Join("testdata", tt.asset))
+			if got := builder.String(); got != want {
+				t.Errorf("NewDefaultTemplate().Render() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestNewDefaultTemplate_markdownKind(t *testing.T) {
+	file, err := bibliography.NewBibFileFromString("@data_set*{a, title = {T}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	template, err := NewDefaultTemplate("markdown-kind")
+	if err != nil {
+		t.Fatalf("NewDefaultTemplate() error = %v", err)
+	}
+
+	var builder strings.Builder
+	if err := template.Render(&builder, file); err != nil {
+		t.Fatalf("NewDefaultTemplate().Render() error = %v", err)
+	}
+	want := "## Data\\_set\\*\n"
+	if got := builder.String(); !strings.HasPrefix(got, want) {
+		t.Errorf("NewDefaultTemplate().Render() = %q, want prefix %q", got, want)
+	}
+}
+
+func TestNewDefaultTemplate_unknown(t *testing.T) {
+	for _, name := range []string{"", "latex", "entry.html", "../render"} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewDefaultTemplate(name); err == nil {
+				t.Error("NewDefaultTemplate() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestNewTemplate(t *testing.T) {
+	const bib = "@misc{a&b, author = {Knuth, Donald}, title = {<b>{\\\"U}ber</b>}, year = 2000}\n@misc{c, title = {x}}\n"
+
+	tests := []struct {
+		name    string
+		format  Format
+		source  string
+		want    string
+		wantErr bool
+	}{
+		{"html escaping", FormatHTML, `{{range .Entries}}<p title="{{.Title}}">{{.Title}}</p>{{end}}`, `<p title="&lt;b&gt;Über&lt;/b&gt;">&lt;b&gt;Über&lt;/b&gt;</p><p title="x">x</p>`, false},
+		{"html entry", FormatHTML, `{{template "entry" index .Entries 0}}`, `<span class="authors">D. Knuth.</span> <span class="title">&lt;b&gt;Über&lt;/b&gt;.</span> 2000.`, false},
+		{"markdown without escaping", FormatMarkdown, `{{range .Entries}}{{.Title}};{{end}}`, `<b>Über</b>;x;`, false},
+		{"markdown escaping", FormatMarkdown, `{{range .Entries}}{{md .Title}};{{end}}`, `\<b\>Über\</b\>;x;`, false},
+		{"markdown entry", FormatMarkdown, `{{template "entry" index .Entries 0}}`, `D. Knuth. \<b\>Über\</b\>. 2000.`, false},
+		{"functions", FormatMarkdown, `{{with index .Entries 0}}{{names .Authors}}|{{.Raw "title"}}|{{decode (.Raw "title")}}|{{kindName .Kind}}{{end}}`, `Donald Knuth|<b>{\"U}ber</b>|<b>Über</b>|Other Publications`, false},
+		{"groups", FormatMarkdown, `{{range .ByYear}}[{{.Key}}:{{len .Entries}}]{{end}}`, `[2000:1][:1]`, false},
+		{"overriding entry", FormatMarkdown, `{{define "entry"}}{{.Label}}{{end}}{{range .Entries}}{{template "entry" .}};{{end}}`, `a&b;c;`, false},
+
+		{"unknown format", Format("latex"), ``, ``, true},
+		{"invalid html template", FormatHTML, `{{range}}`, ``, true},
+		{"invalid markdown template", FormatMarkdown, `{{.Entries`, ``, true},
+		{"unknown function", FormatMarkdown, `{{unknown .}}`, ``, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := NewTemplate(tt.format, tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+
+			file, err := bibliography.NewBibFileFromString(bib)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var builder strings.Builder
+			if err := template.Render(&builder, file); err != nil {
+				t.Fatalf("NewTemplate().Render() error = %v", err)
+			}
+			if got := builder.String(); got != tt.want {
+				t.Errorf("NewTemplate().Render() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package search
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+)
+
+// indexVersion is the version of the serialized index format
+const indexVersion = 1
+
+// encodedIndex is the serialized form of an Index
+type encodedIndex struct {
+	Version   int
+	Boosts    map[string]float64
+	Documents []Document
+	Fields    []string
+	Postings  map[string][]post
+}
+
+// Save serializes this index to writer
+func (index *Index) Save(writer io.Writer) error {
+	return gob.NewEncoder(writer).Encode(encodedIndex{
+		Version:   indexVersion,
+		Boosts:    index.Boosts,
+		Documents: index.documents,
+		Fields:    index.fields,
+		Postings:  index.postings,
+	})
+}
+
+// Load reads an index previously serialized using Save
+func Load(reader io.Reader) (index *Index, err error) {
+	var encoded encodedIndex
+	if err = gob.NewDecoder(reader).Decode(&encoded); err != nil {
+		return nil, err
+	}
+	if encoded.Version != indexVersion {
+		return nil, fmt.Errorf("unsupported index version %d", encoded.Version)
+	}
+
+	index = NewIndex()
+	if encoded.Boosts != nil {
+		index.Boosts = encoded.Boosts
+	}
+	index.documents = encoded.Documents
+	index.fields = encoded.Fields
+	for id, name := range index.fields {
+		index.fieldIDs[name] = id
+	}
+	if encoded.Postings != nil {
+		index.postings = encoded.Postings
+	}
+	return
+}
@@ -0,0 +1,281 @@
+// Package search implements a full-text search index over bibliographies.
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/latex"
+)
+
+// DefaultBoosts are the default boosts of fields.
+// Fields not listed have a boost of 1.
+var DefaultBoosts = map[string]float64{
+	"label":    2,
+	"title":    3,
+	"author":   2,
+	"editor":   1.5,
+	"keywords": 2,
+	"abstract": 0.5,
+}
+
+// Index is an in-memory inverted index over entries of bibliographies.
+// The zero value is not usable, use NewIndex instead.
+// Searches may run concurrently, but not concurrently with adding entries.
+type Index struct {
+	// Boosts maps lower case field names to the weight of matches within them.
+	// Fields not contained in Boosts have a weight of 1.
+	Boosts map[string]float64
+
+	documents []Document
+	fields    []string          // names of indexed fields
+	fieldIDs  map[string]int    // index into fields
+	postings  map[string][]post // postings by term, ordered by document
+
+	termsMu sync.Mutex // guards terms, which concurrent searches may build
+	terms   []string   // sorted terms, built lazily for prefix queries
+}
+
+// Document is a single entry within the index
+type Document struct {
+	Label string
+	Kind  string
+	Title string // decoded title, if any
+}
+
+// post records the occurrences of a term within a single field of a document
+type post struct {
+	Document int
+	Field    int
+	Count    int // number of occurrences of the term within the field
+	Length   int // number of tokens in the field
+}
+
+// Result is a single search result
+type Result struct {
+	Document
+	ID    int // id of the document within the index
+	Score float64
+}
+
+// NewIndex creates a new empty index using DefaultBoosts
+func NewIndex() *Index {
+	boosts := make(map[string]float64, len(DefaultBoosts))
+	for field, boost := range DefaultBoosts {
+		boosts[field] = boost
+	}
+	return &Index{
+		Boosts:   boosts,
+		fieldIDs: make(map[string]int),
+		postings: make(map[string][]post),
+	}
+}
+
+// Len returns the number of documents in this index
+func (index *Index) Len() int {
+	return len(index.documents)
+}
+
+// Document returns the document with the given id
+func (index *Index) Document(id int) Document {
+	return index.documents[id]
+}
+
+// Add adds the entries of file to this index.
+// Special entries, such as '@string' and '@preamble', are skipped; the macros they define are used to evaluate fields.
+func (index *Index) Add(file *bibliography.BibFile) {
+	macros := file.Macros()
+	for _, entry := range file.Entries {
+		if !entry.IsSpecial() {
+			index.AddEntry(entry, macros)
+		}
+	}
+}
+
+// AddEntry adds a single entry to this index and returns its id.
+// macros are used to evaluate fields, see BibFile.Macros().
+func (index *Index) AddEntry(entry *bibliography.BibEntry, macros map[string]string) (id int) {
+	id = len(index.documents)
+	doc := Document{Label: entry.Label(), Kind: entry.Kind.Value}
+
+	index.addField(id, "label", Tokenize(doc.Label))
+	for _, field := range entry.Fields {
+		name := strings.ToLower(field.Name())
+		if name == "" {
+			continue
+		}
+		value := latex.Decode(field.Evaluate(macros))
+		if name == "title" && doc.Title == "" {
+			doc.Title = value
+		}
+		index.addField(id, name, Tokenize(value))
+	}
+
+	index.documents = append(index.documents, doc)
+	index.terms = nil
+	return
+}
+
+// addField adds the tokens of a field of a document
+func (index *Index) addField(id int, name string, tokens []string) {
+	if len(tokens) == 0 {
+		return
+	}
+
+	field, ok := index.fieldIDs[name]
+	if !ok {
+		field = len(index.fields)
+		index.fields = append(index.fields, name)
+		index.fieldIDs[name] = field
+	}
+
+	counts := make(map[string]int)
+	var order []string
+	for _, token := range tokens {
+		if counts[token] == 0 {
+			order = append(order, token)
+		}
+		counts[token]++
+	}
+	for _, token := range order {
+		index.postings[token] = append(index.postings[token], post{
+			Document: id,
+			Field:    field,
+			Count:    counts[token],
+			Length:   len(tokens),
+		})
+	}
+}
+
+// Search searches this index and returns matching documents ordered by descending score.
+//
+// The query is split into terms like field values.
+// A term ending in '*' matches all terms starting with it, a term of the form 'field:term' only matches within the given field.
+// Documents must match every term of the query.
+// If limit is positive, at most limit results are returned.
+func (index *Index) Search(query string, limit int) (results []Result) {
+	terms := parseQuery(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var scores map[int]float64
+	for _, term := range terms {
+		termScores := index.score(term)
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id, score := range scores {
+			if s, ok := termScores[id]; ok {
+				scores[id] = score + s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	for id, score := range scores {
+		results = append(results, Result{Document: index.documents[id], ID: id, Score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return
+}
+
+// score computes the score of every document matching a single query term
+func (index *Index) score(term queryTerm) map[int]float64 {
+	scores := make(map[int]float64)
+	for _, token := range index.expand(term) {
+		postings := index.postings[token]
+
+		// inverse document frequency; postings are ordered by document, so count distinct runs
+		df := 0
+		for i, p := range postings {
+			if i == 0 || postings[i-1].Document != p.Document {
+				df++
+			}
+		}
+		idf := math.Log(1 + float64(len(index.documents))/float64(df))
+
+		for _, p := range postings {
+			name := index.fields[p.Field]
+			if term.field != "" && term.field != name {
+				continue
+			}
+			boost, ok := index.Boosts[name]
+			if !ok {
+				boost = 1
+			}
+			tf := (1 + math.Log(float64(p.Count))) / math.Sqrt(float64(p.Length))
+			scores[p.Document] += boost * tf * idf
+		}
+	}
+	return scores
+}
+
+// expand returns the indexed tokens matched by a query term
+func (index *Index) expand(term queryTerm) []string {
+	if !term.prefix {
+		if _, ok := index.postings[term.token]; ok {
+			return []string{term.token}
+		}
+		return nil
+	}
+
+	index.termsMu.Lock()
+	if index.terms == nil {
+		index.terms = make([]string, 0, len(index.postings))
+		for token := range index.postings {
+			index.terms = append(index.terms, token)
+		}
+		sort.Strings(index.terms)
+	}
+	terms := index.terms
+	index.termsMu.Unlock()
+
+	start := sort.SearchStrings(terms, term.token)
+	end := start
+	for end < len(terms) && strings.HasPrefix(terms[end], term.token) {
+		end++
+	}
+	return terms[start:end]
+}
+
+// queryTerm is a single term of a query
+type queryTerm struct {
+	field  string
+	token  string
+	prefix bool
+}
+
+// parseQuery splits a query into terms
+func parseQuery(query string) (terms []queryTerm) {
+	for _, word := range strings.Fields(query) {
+		var field string
+		if i := strings.IndexRune(word, ':'); i > 0 {
+			field, word = strings.ToLower(word[:i]), word[i+1:]
+		}
+		prefix := strings.HasSuffix(word, "*")
+
+		tokens := Tokenize(latex.Decode(word))
+		for i, token := range tokens {
+			terms = append(terms, queryTerm{
+				field:  field,
+				token:  token,
+				prefix: prefix && i == len(tokens)-1,
+			})
+		}
+	}
+	return
+}
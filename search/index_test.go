@@ -0,0 +1,115 @@
+package search
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/tkw1536/gotexml/bibliography"
+)
+
+func newTestIndex(t *testing.T) *Index {
+	file, err := bibliography.NewBibFileFromFile("testdata/library.bib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := NewIndex()
+	index.Add(file)
+	return index
+}
+
+func TestIndex_Search(t *testing.T) {
+	index := newTestIndex(t)
+	if got := index.Len(); got != 4 {
+		t.Fatalf("Index.Len() = %v, want %v", got, 4)
+	}
+
+	tests := []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		{"", 0, nil},
+		{"kohlhase", 0, []string{"Kohlhase2020", "Kohlhase2018"}},
+		{"kohlhase", 1, []string{"Kohlhase2020"}},
+		{"kohlhase web", 0, []string{"Kohlhase2018"}},
+		{"schone", 0, []string{"Kohlhase2020"}},
+		{`Sch{\"o}ne`, 0, []string{"Kohlhase2020"}},
+		{"form*", 0, []string{"Kohlhase2020"}},
+		{"s*", 0, []string{"Kohlhase2020", "Other2019", "Old1999"}},
+		{"title:book", 0, []string{"Old1999"}},
+		{"author:book", 0, nil},
+		{"1999", 0, []string{"Old1999"}},
+		{"missing", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got []string
+			for _, result := range index.Search(tt.query, tt.limit) {
+				got = append(got, result.Label)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Index.Search() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestIndex_Search_concurrent runs prefix queries concurrently, see 'go test -race'
+func TestIndex_Search_concurrent(t *testing.T) {
+	index := newTestIndex(t)
+
+	var wg sync.WaitGroup
+	results := make([][]string, 8)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, r := range index.Search("s*", 0) {
+				results[i] = append(results[i], r.Label)
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := []string{"Kohlhase2020", "Other2019", "Old1999"}
+	for _, got := range results {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Index.Search() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestIndex_Boosts(t *testing.T) {
+	index := newTestIndex(t)
+
+	// 'book' only occurs in the title of Old1999
+	index.Boosts["title"] = 0
+	if results := index.Search("title:book", 0); len(results) != 1 || results[0].Score != 0 {
+		t.Errorf("Index.Search() = %v, want a single result with score 0", results)
+	}
+}
+
+func TestIndex_Save(t *testing.T) {
+	index := newTestIndex(t)
+
+	var buffer bytes.Buffer
+	if err := index.Save(&buffer); err != nil {
+		t.Fatalf("Index.Save() error = %v", err)
+	}
+	loaded, err := Load(&buffer)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	for _, query := range []string{"kohlhase", "s*", "title:book"} {
+		want := index.Search(query, 0)
+		if got := loaded.Search(query, 0); !reflect.DeepEqual(got, want) {
+			t.Errorf("Load().Search(%q) = %v, want %v", query, got, want)
+		}
+	}
+	if got := loaded.Document(0); got != index.Document(0) {
+		t.Errorf("Load().Document() = %v, want %v", got, index.Document(0))
+	}
+}
@@ -0,0 +1,37 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/tkw1536/gotexml/latex"
+)
+
+// Tokenize splits text, with LaTeX already decoded, into lower case tokens.
+// Tokens consist of letters and digits; accented letters are folded into their ascii equivalents, so that 'Schöne' and 'Schone' produce the same token.
+func Tokenize(text string) (tokens []string) {
+	var builder strings.Builder
+	flush := func() {
+		if builder.Len() > 0 {
+			tokens = append(tokens, builder.String())
+			builder.Reset()
+		}
+	}
+
+	for _, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		r = unicode.ToLower(r)
+		if r > unicode.MaxASCII {
+			if folded := latex.ASCII(string(r)); folded != "" {
+				builder.WriteString(strings.ToLower(folded))
+				continue
+			}
+		}
+		builder.WriteRune(r)
+	}
+	flush()
+	return
+}
@@ -0,0 +1,28 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		wantTokens []string
+	}{
+		{"empty", "", nil},
+		{"words", "Modular Math in the Web", []string{"modular", "math", "in", "the", "web"}},
+		{"punctuation", "Hello, World! (2018)", []string{"hello", "world", "2018"}},
+		{"accents", "Schöne Größe", []string{"schone", "grosse"}},
+		{"non-latin", "日本 x", []string{"日本", "x"}},
+		{"dashes", "state-of-the-art", []string{"state", "of", "the", "art"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotTokens := Tokenize(tt.text); !reflect.DeepEqual(gotTokens, tt.wantTokens) {
+				t.Errorf("Tokenize() = %v, want %v", gotTokens, tt.wantTokens)
+			}
+		})
+	}
+}
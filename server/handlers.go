@@ -0,0 +1,401 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/latex"
+	"github.com/tkw1536/gotexml/query"
+	"github.com/tkw1536/gotexml/utils"
+)
+
+// maxBodySize is the maximal size of a request body
+const maxBodySize = 1 << 20
+
+// Entry is the representation of an entry within the API
+type Entry struct {
+	Label  string  `json:"label"`
+	Kind   string  `json:"kind"`
+	File   string  `json:"file"`
+	Fields []Field `json:"fields"`
+	BibTeX string  `json:"bibtex"`          // source of the entry
+	Score  float64 `json:"score,omitempty"` // score of a search result
+}
+
+// Field is the representation of a field within the API
+type Field struct {
+	Name  string `json:"name"`
+	Value string `json:"value"` // evaluated value, with LaTeX decoded
+	Raw   string `json:"raw"`   // value as written in the source
+}
+
+// File is the representation of a file within the API
+type File struct {
+	Name    string `json:"name"`
+	Entries int    `json:"entries"`
+}
+
+// routes sets up the routes of the server
+func (server *Server) routes() {
+	server.mux = http.NewServeMux()
+	server.mux.HandleFunc("GET /files", server.handleFiles)
+	server.mux.HandleFunc("GET /entries", server.handleList)
+	server.mux.HandleFunc("POST /entries", server.handleCreate)
+	server.mux.HandleFunc("GET /entries/{label}", server.handleGet)
+	server.mux.HandleFunc("PUT /entries/{label}", server.handleUpdate)
+	server.mux.HandleFunc("DELETE /entries/{label}", server.handleDelete)
+	server.mux.HandleFunc("GET /export", server.handleExport)
+}
+
+// ServeHTTP serves the API.
+//
+// The following endpoints are supported:
+//
+//	GET    /files            list the served files
+//	GET    /entries          list entries, optionally filtered by 'file', a query 'q' (see package query), or a full-text 'search' with 'limit'
+//	POST   /entries          create an entry in the file given by 'file', or the first file; the body is the entry in BibTeX
+//	GET    /entries/{label}  get a single entry
+//	PUT    /entries/{label}  replace a single entry; the body is the new entry in BibTeX
+//	DELETE /entries/{label}  delete a single entry
+//	GET    /export           export entries, filtered like /entries, in the 'format' 'bibtex' (default) or 'json'
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.mux.ServeHTTP(w, r)
+}
+
+func (server *Server) handleFiles(w http.ResponseWriter, r *http.Request) {
+	server.mu.RLock()
+	defer server.mu.RUnlock()
+
+	files := make([]File, 0, len(server.files))
+	for _, f := range server.files {
+		count := 0
+		for _, entry := range f.file.Entries {
+			if !entry.IsSpecial() {
+				count++
+			}
+		}
+		files = append(files, File{Name: f.name(), Entries: count})
+	}
+	writeJSON(w, http.StatusOK, files)
+}
+
+func (server *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	server.mu.RLock()
+	defer server.mu.RUnlock()
+
+	entries, status, err := server.selectEntries(r)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (server *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	server.mu.RLock()
+	defer server.mu.RUnlock()
+
+	entries, status, err := server.selectEntries(r)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "bibtex":
+		w.Header().Set("Content-Type", "text/x-bibtex; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		for _, entry := range entries {
+			io.WriteString(w, entry.BibTeX)
+			io.WriteString(w, "\n\n")
+		}
+	case "json":
+		writeJSON(w, http.StatusOK, entries)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown format %q", format))
+	}
+}
+
+func (server *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	server.mu.RLock()
+	defer server.mu.RUnlock()
+
+	f, i, ok := server.find(r.PathValue("label"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("entry %q not found", r.PathValue("label")))
+		return
+	}
+	writeJSON(w, http.StatusOK, newEntry(f, f.file.Entries[i], f.file.Macros()))
+}
+
+func (server *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	entry, err := readEntry(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if !server.reloadOrFail(w) {
+		return
+	}
+
+	f, ok := server.file(r.URL.Query().Get("file"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("file %q not found", r.URL.Query().Get("file")))
+		return
+	}
+	if _, _, exists := server.find(entry.Label()); exists {
+		writeError(w, http.StatusConflict, fmt.Errorf("entry %q already exists", entry.Label()))
+		return
+	}
+
+	file := f.file.Clone()
+	entry.Prefix.Value = ""
+	if len(file.Entries) > 0 {
+		entry.Prefix.Value = "\n\n"
+	}
+	file.Entries = append(file.Entries, entry)
+
+	if err := server.save(f, file); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newEntry(f, entry, file.Macros()))
+}
+
+func (server *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	entry, err := readEntry(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if !server.reloadOrFail(w) {
+		return
+	}
+
+	label := r.PathValue("label")
+	f, i, ok := server.find(label)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("entry %q not found", label))
+		return
+	}
+	if g, j, exists := server.find(entry.Label()); exists && (g != f || j != i) {
+		writeError(w, http.StatusConflict, fmt.Errorf("entry %q already exists", entry.Label()))
+		return
+	}
+
+	// keep the whitespace and comments preceding the entry
+	file := f.file.Clone()
+	entry.Prefix = file.Entries[i].Prefix
+	file.Entries[i] = entry
+
+	if err := server.save(f, file); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newEntry(f, entry, file.Macros()))
+}
+
+func (server *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if !server.reloadOrFail(w) {
+		return
+	}
+
+	label := r.PathValue("label")
+	f, i, ok := server.find(label)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("entry %q not found", label))
+		return
+	}
+
+	// keep comments preceding the entry, by moving them in front of whatever follows it
+	file := f.file.Clone()
+	prefix := file.Entries[i].Prefix
+	file.Entries = append(file.Entries[:i], file.Entries[i+1:]...)
+	switch {
+	case strings.TrimSpace(prefix.Value) == "":
+	case i < len(file.Entries):
+		file.Entries[i].Prefix.Value = prefix.Value + file.Entries[i].Prefix.Value
+	default:
+		file.Suffix.Value = prefix.Value + file.Suffix.Value
+	}
+
+	if err := server.save(f, file); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reloadOrFail reloads files that have changed on disk before they are modified.
+// If a file fails to reload, it writes an error and returns false, so that stale contents are never written back to disk.
+// The caller must hold the write lock.
+func (server *Server) reloadOrFail(w http.ResponseWriter) bool {
+	if err := server.reload(); err != nil {
+		writeError(w, http.StatusConflict, fmt.Errorf("file changed on disk and could not be reloaded: %w", err))
+		return false
+	}
+	return true
+}
+
+// save saves a modified file and updates the index.
+// The caller must hold the write lock.
+func (server *Server) save(f *bibFile, file *bibliography.BibFile) error {
+	if err := f.save(file); err != nil {
+		return err
+	}
+	server.reindex()
+	return nil
+}
+
+// selectEntries selects entries according to the query parameters of r.
+// The caller must hold a lock.
+func (server *Server) selectEntries(r *http.Request) (entries []Entry, status int, err error) {
+	params := r.URL.Query()
+	entries = []Entry{}
+
+	name := params.Get("file")
+	if _, ok := server.file(name); name != "" && !ok {
+		return nil, http.StatusNotFound, fmt.Errorf("file %q not found", name)
+	}
+
+	var q *query.Query
+	if source := params.Get("q"); source != "" {
+		if q, err = query.Parse(source); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	limit := 0
+	if l := params.Get("limit"); l != "" {
+		if limit, err = strconv.Atoi(l); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid limit %q", l)
+		}
+	}
+
+	include := func(ref entryRef, macros map[string]string) bool {
+		if name != "" && ref.file.name() != name && ref.file.path != name {
+			return false
+		}
+		return q == nil || q.Match(ref.entry, macros)
+	}
+
+	// full-text search, ordered by score
+	if text := params.Get("search"); text != "" {
+		macros := make(map[*bibFile]map[string]string)
+		for _, result := range server.index.Search(text, 0) {
+			ref := server.indexed[result.ID]
+			if macros[ref.file] == nil {
+				macros[ref.file] = ref.file.file.Macros()
+			}
+			if !include(ref, macros[ref.file]) {
+				continue
+			}
+			entry := newEntry(ref.file, ref.entry, macros[ref.file])
+			entry.Score = result.Score
+			entries = append(entries, entry)
+			if limit > 0 && len(entries) == limit {
+				break
+			}
+		}
+		return
+	}
+
+	// all entries, in order
+	for _, f := range server.files {
+		macros := f.file.Macros()
+		for _, entry := range f.file.Entries {
+			if entry.IsSpecial() || !include(entryRef{file: f, entry: entry}, macros) {
+				continue
+			}
+			entries = append(entries, newEntry(f, entry, macros))
+			if limit > 0 && len(entries) == limit {
+				return
+			}
+		}
+	}
+	return
+}
+
+// newEntry creates the representation of an entry
+func newEntry(f *bibFile, entry *bibliography.BibEntry, macros map[string]string) Entry {
+	result := Entry{
+		Label:  entry.Label(),
+		Kind:   entry.Kind.Value,
+		File:   f.name(),
+		Fields: []Field{},
+	}
+
+	// write the entry without the preceding whitespace
+	clone := *entry
+	clone.Prefix = bibliography.BibString{}
+	var buffer bytes.Buffer
+	clone.Write(&buffer)
+	result.BibTeX = buffer.String()
+
+	for _, field := range entry.Fields {
+		name := field.Name()
+		if name == "" {
+			continue
+		}
+
+		var raw []string
+		for _, element := range field.GetValue() {
+			var b bytes.Buffer
+			element.Value.Write(&b)
+			raw = append(raw, b.String())
+		}
+
+		result.Fields = append(result.Fields, Field{
+			Name:  name,
+			Value: latex.Decode(field.Evaluate(macros)),
+			Raw:   strings.Join(raw, " # "),
+		})
+	}
+	return result
+}
+
+// errNoEntry is returned when a request body does not contain an entry
+var errNoEntry = errors.New("request body must contain exactly one entry")
+
+// readEntry reads a single entry from the body of r
+func readEntry(r *http.Request) (entry *bibliography.BibEntry, err error) {
+	file, err := bibliography.NewBibFileFromReader(utils.NewRuneReaderFromReader(io.LimitReader(r.Body, maxBodySize)))
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Entries) != 1 || file.Entries[0].IsSpecial() {
+		return nil, errNoEntry
+	}
+	entry = file.Entries[0]
+	if !bibliography.IsValidLabel(entry.Label()) {
+		return nil, fmt.Errorf("invalid label %q", entry.Label())
+	}
+	return
+}
+
+// writeJSON writes a json response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes an error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,214 @@
+// Package server implements an HTTP/JSON API for bibliography files.
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tkw1536/gotexml/bibliography"
+	"github.com/tkw1536/gotexml/search"
+)
+
+// Server serves the entries of a set of .bib files.
+// Changes made through the API are written back to disk.
+type Server struct {
+	mu    sync.RWMutex
+	files []*bibFile
+
+	index   *search.Index
+	indexed []entryRef // entries by document id of index
+
+	mux *http.ServeMux
+}
+
+// bibFile is a single file served by a Server
+type bibFile struct {
+	path    string
+	file    *bibliography.BibFile
+	modTime time.Time
+	size    int64
+}
+
+// entryRef references a single entry of a file
+type entryRef struct {
+	file  *bibFile
+	entry *bibliography.BibEntry
+}
+
+// New creates a new Server serving the given files
+func New(paths ...string) (server *Server, err error) {
+	server = &Server{}
+	for _, path := range paths {
+		f := &bibFile{path: path}
+		if err = f.load(); err != nil {
+			return nil, err
+		}
+		server.files = append(server.files, f)
+	}
+	server.reindex()
+	server.routes()
+	return
+}
+
+// load (re-)loads a file from disk
+func (f *bibFile) load() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	file, err := bibliography.NewBibFileFromFile(f.path)
+	if err != nil {
+		return err
+	}
+	f.file, f.modTime, f.size = file, info.ModTime(), info.Size()
+	return nil
+}
+
+// changed checks if a file has changed on disk since it was last loaded or saved
+func (f *bibFile) changed() (bool, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return false, err
+	}
+	return !info.ModTime().Equal(f.modTime) || info.Size() != f.size, nil
+}
+
+// save writes file to disk, and replaces the contents of f with it on success
+func (f *bibFile) save(file *bibliography.BibFile) error {
+	var buffer bytes.Buffer
+	if err := file.Write(&buffer); err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so that the file is never left half-written
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), "."+filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buffer.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if info, err := os.Stat(f.path); err == nil {
+		os.Chmod(tmp.Name(), info.Mode())
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	f.file, f.modTime, f.size = file, info.ModTime(), info.Size()
+	return nil
+}
+
+// name returns the name of a file as used in the API
+func (f *bibFile) name() string {
+	return filepath.Base(f.path)
+}
+
+// Reload reloads all files that have changed on disk.
+// Files that fail to load are left unchanged, and the first such error is returned.
+func (server *Server) Reload() error {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	return server.reload()
+}
+
+// reload implements Reload.
+// It is also called before modifying files, so that modifications are made to their latest version.
+// The caller must hold the write lock.
+func (server *Server) reload() (err error) {
+	reloaded := false
+	for _, f := range server.files {
+		changed, cerr := f.changed()
+		if cerr == nil && changed {
+			cerr = f.load()
+			reloaded = reloaded || cerr == nil
+		}
+		if err == nil {
+			err = cerr
+		}
+	}
+	if reloaded {
+		server.reindex()
+	}
+	return
+}
+
+// Watch polls the files for changes every interval, and reloads them when they change.
+// onError, if not nil, is called with errors that occur while reloading.
+// Watch blocks until ctx is cancelled.
+func (server *Server) Watch(ctx context.Context, interval time.Duration, onError func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := server.Reload(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// reindex rebuilds the search index.
+// The caller must hold the write lock.
+func (server *Server) reindex() {
+	server.index = search.NewIndex()
+	server.indexed = nil
+	for _, f := range server.files {
+		macros := f.file.Macros()
+		for _, entry := range f.file.Entries {
+			if entry.IsSpecial() {
+				continue
+			}
+			server.index.AddEntry(entry, macros)
+			server.indexed = append(server.indexed, entryRef{file: f, entry: entry})
+		}
+	}
+}
+
+// find finds the entry with the given label, compared case-insensitively.
+// Returns the file containing it, and its index within the file.
+// The caller must hold a lock.
+func (server *Server) find(label string) (f *bibFile, index int, ok bool) {
+	for _, f := range server.files {
+		for i, entry := range f.file.Entries {
+			if !entry.IsSpecial() && strings.EqualFold(entry.Label(), label) {
+				return f, i, true
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// file finds the file with the given name.
+// If name is empty, returns the first file.
+// The caller must hold a lock.
+func (server *Server) file(name string) (f *bibFile, ok bool) {
+	if name == "" && len(server.files) > 0 {
+		return server.files[0], true
+	}
+	for _, f := range server.files {
+		if f.name() == name || f.path == name {
+			return f, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,279 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer creates a server serving copies of the files in testdata
+func newTestServer(t *testing.T) (server *Server, dir string) {
+	dir = t.TempDir()
+	var paths []string
+	for _, name := range []string{"library.bib", "other.bib"} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0666); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	server, err := New(paths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, dir
+}
+
+// do performs a request against server and returns the status and body of the response
+func do(t *testing.T, server *Server, method, target, body string) (status int, response string) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	data, err := io.ReadAll(rec.Result().Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, string(data)
+}
+
+// labels returns the labels of a json list of entries
+func labels(t *testing.T, body string) (labels []string) {
+	var entries []Entry
+	if err := json.Unmarshal([]byte(body), &entries); err != nil {
+		t.Fatalf("invalid json %q: %v", body, err)
+	}
+	for _, entry := range entries {
+		labels = append(labels, entry.Label)
+	}
+	return
+}
+
+func TestServer_list(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	tests := []struct {
+		target     string
+		wantStatus int
+		wantLabels []string
+	}{
+		{"/entries", http.StatusOK, []string{"Kohlhase2018", "Kohlhase2020", "Other2019", "Old1999", "extra"}},
+		{"/entries?file=other.bib", http.StatusOK, []string{"extra"}},
+		{"/entries?limit=2", http.StatusOK, []string{"Kohlhase2018", "Kohlhase2020"}},
+		{"/entries?q=year%3E2018", http.StatusOK, []string{"Kohlhase2020", "Other2019"}},
+		{"/entries?search=kohlhase&limit=1", http.StatusOK, []string{"Kohlhase2020"}},
+		{"/entries?search=extra", http.StatusOK, []string{"extra"}},
+		{"/entries?search=kohlhase&q=has:doi", http.StatusOK, []string{"Kohlhase2018"}},
+		{"/entries?q=year%3E%3D", http.StatusBadRequest, nil},
+		{"/entries?file=missing.bib", http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			status, body := do(t, server, http.MethodGet, tt.target, "")
+			if status != tt.wantStatus {
+				t.Errorf("GET %s status = %v, want %v", tt.target, status, tt.wantStatus)
+			}
+			if status != http.StatusOK {
+				return
+			}
+			if got := labels(t, body); !reflect.DeepEqual(got, tt.wantLabels) {
+				t.Errorf("GET %s = %v, want %v", tt.target, got, tt.wantLabels)
+			}
+		})
+	}
+}
+
+func TestServer_get(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	status, body := do(t, server, http.MethodGet, "/entries/kohlhase2020", "")
+	if status != http.StatusOK {
+		t.Fatalf("GET status = %v, want %v", status, http.StatusOK)
+	}
+	var got Entry
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := Entry{
+		Label: "Kohlhase2020",
+		Kind:  "inproceedings",
+		File:  "library.bib",
+		Fields: []Field{
+			{Name: "author", Value: "Michael Kohlhase", Raw: "mk"},
+			{Name: "title", Value: "Schöne Formeln", Raw: `{Sch{\"o}ne Formeln}`},
+			{Name: "year", Value: "2020a", Raw: "{2020a}"},
+		},
+		BibTeX: "@inproceedings{Kohlhase2020,\n  author = mk,\n  title = {Sch{\\\"o}ne Formeln},\n  year = {2020a}\n}",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GET = %v, want %v", got, want)
+	}
+
+	if status, _ := do(t, server, http.MethodGet, "/entries/missing", ""); status != http.StatusNotFound {
+		t.Errorf("GET missing status = %v, want %v", status, http.StatusNotFound)
+	}
+}
+
+func TestServer_modify(t *testing.T) {
+	server, dir := newTestServer(t)
+	other := filepath.Join(dir, "other.bib")
+
+	steps := []struct {
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantFile   string
+	}{
+		{http.MethodPost, "/entries?file=other.bib", "@book{new, title = {New}}", http.StatusCreated, "% other references\n@misc{extra, title = {Extra}}\n\n@book{new, title = {New}}\n"},
+		{http.MethodPost, "/entries?file=other.bib", "@book{NEW, title = {Again}}", http.StatusConflict, ""},
+		{http.MethodPost, "/entries?file=other.bib", "@string{a = {b}}", http.StatusBadRequest, ""},
+		{http.MethodPost, "/entries?file=other.bib", "@book{x, title = {A}} @book{y, title = {B}}", http.StatusBadRequest, ""},
+		{http.MethodPut, "/entries/extra", "@misc{extra,\n  title = {Changed}\n}", http.StatusOK, "% other references\n@misc{extra,\n  title = {Changed}\n}\n\n@book{new, title = {New}}\n"},
+		{http.MethodPut, "/entries/extra", "@misc{Old1999, title = {Clash}}", http.StatusConflict, ""},
+		{http.MethodPut, "/entries/missing", "@misc{missing, title = {Missing}}", http.StatusNotFound, ""},
+		{http.MethodDelete, "/entries/extra", "", http.StatusNoContent, "% other references\n\n\n@book{new, title = {New}}\n"},
+		{http.MethodDelete, "/entries/extra", "", http.StatusNotFound, ""},
+	}
+	for _, step := range steps {
+		status, body := do(t, server, step.method, step.target, step.body)
+		if status != step.wantStatus {
+			t.Fatalf("%s %s status = %v, want %v: %s", step.method, step.target, status, step.wantStatus, body)
+		}
+		if step.wantFile == "" {
+			continue
+		}
+		data, err := os.ReadFile(other)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != step.wantFile {
+			t.Errorf("%s %s wrote %q, want %q", step.method, step.target, string(data), step.wantFile)
+		}
+	}
+
+	// library.bib should be untouched
+	original, _ := os.ReadFile(filepath.Join("testdata", "library.bib"))
+	current, _ := os.ReadFile(filepath.Join(dir, "library.bib"))
+	if string(original) != string(current) {
+		t.Errorf("modified library.bib = %q, want %q", string(current), string(original))
+	}
+}
+
+func TestServer_delete(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		label  string
+		want   string
+	}{
+		{"first", "@misc{x}\n@misc{y}\n", "x", "\n@misc{y}\n"},
+		{"comment before next", "@misc{x}\n% comment about y\n@misc{y}", "x", "\n% comment about y\n@misc{y}"},
+		{"comments before both", "% comment about x\n@misc{x}\n% comment about y\n@misc{y}", "x", "% comment about x\n\n% comment about y\n@misc{y}"},
+		{"comment before last", "@misc{x}\n% comment about y\n@misc{y}\n% end\n", "y", "@misc{x}\n% comment about y\n\n% end\n"},
+		{"whitespace before last", "@misc{x}\n\n@misc{y}\n", "y", "@misc{x}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.bib")
+			if err := os.WriteFile(path, []byte(tt.source), 0666); err != nil {
+				t.Fatal(err)
+			}
+			server, err := New(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if status, body := do(t, server, http.MethodDelete, "/entries/"+tt.label, ""); status != http.StatusNoContent {
+				t.Fatalf("DELETE status = %v, want %v: %s", status, http.StatusNoContent, body)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("DELETE wrote %q, want %q", string(data), tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_Reload(t *testing.T) {
+	server, dir := newTestServer(t)
+	other := filepath.Join(dir, "other.bib")
+
+	// change the file behind the server's back
+	if err := os.WriteFile(other, []byte("@misc{changed, title = {Changed on disk}}\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(other, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Reload(); err != nil {
+		t.Fatalf("Server.Reload() error = %v", err)
+	}
+
+	_, body := do(t, server, http.MethodGet, "/entries?file=other.bib", "")
+	if got, want := labels(t, body), []string{"changed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GET after Reload() = %v, want %v", got, want)
+	}
+	_, body = do(t, server, http.MethodGet, "/entries?search=disk", "")
+	if got, want := labels(t, body), []string{"changed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GET search after Reload() = %v, want %v", got, want)
+	}
+
+	// a broken file should keep the last known state
+	if err := os.WriteFile(other, []byte("@misc{broken,"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Reload(); err == nil {
+		t.Errorf("Server.Reload() error = nil, want an error")
+	}
+	_, body = do(t, server, http.MethodGet, "/entries?file=other.bib", "")
+	if got, want := labels(t, body), []string{"changed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GET after failed Reload() = %v, want %v", got, want)
+	}
+
+	// modifications should not overwrite the broken file with the last known state
+	for _, step := range []struct{ method, target, body string }{
+		{http.MethodPost, "/entries?file=other.bib", "@misc{new}"},
+		{http.MethodPut, "/entries/changed", "@misc{changed}"},
+		{http.MethodDelete, "/entries/changed", ""},
+	} {
+		if status, _ := do(t, server, step.method, step.target, step.body); status != http.StatusConflict {
+			t.Errorf("%s %s after failed Reload() status = %v, want %v", step.method, step.target, status, http.StatusConflict)
+		}
+	}
+	if data, _ := os.ReadFile(other); string(data) != "@misc{broken," {
+		t.Errorf("modification after failed Reload() wrote %q", string(data))
+	}
+}
+
+func TestServer_export(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	status, body := do(t, server, http.MethodGet, "/export?file=other.bib", "")
+	if want := "@misc{extra, title = {Extra}}\n\n"; status != http.StatusOK || body != want {
+		t.Errorf("GET /export = %v %q, want %v %q", status, body, http.StatusOK, want)
+	}
+	status, body = do(t, server, http.MethodGet, "/export?file=other.bib&format=json", "")
+	if got, want := labels(t, body), []string{"extra"}; status != http.StatusOK || !reflect.DeepEqual(got, want) {
+		t.Errorf("GET /export json = %v %v, want %v %v", status, got, http.StatusOK, want)
+	}
+	if status, _ := do(t, server, http.MethodGet, "/export?format=pdf", ""); status != http.StatusBadRequest {
+		t.Errorf("GET /export pdf status = %v, want %v", status, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Severity is the severity of a Diagnostic
+type Severity int
+
+// severities of diagnostics
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityNote
+)
+
+// String returns the name of this severity
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityNote:
+		return "note"
+	}
+	return fmt.Sprintf("Severity(%d)", int(severity))
+}
+
+// Diagnostic is a message about a range of an input, such as an error or a warning
+type Diagnostic struct {
+	Severity Severity
+	Message  string
+	Range    ReaderRange // inclusive range the message refers to
+
+	Notes []string // additional messages, such as the context an error occurred in
+}
+
+// NewDiagnosticFromError creates a new error diagnostic from err.
+//
+// If err wraps one or more *ReaderErrors, the diagnostic is located at the innermost one, which describes the actual problem.
+// The messages of the wrapping errors are turned into notes, from the inside out.
+func NewDiagnosticFromError(err error) (diagnostic Diagnostic) {
+	diagnostic.Severity = SeverityError
+
+	// collect the messages of all reader errors, from the outside in
+	var innermost *ReaderError
+	var messages []string
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if rerr, ok := e.(*ReaderError); ok {
+			innermost = rerr
+			messages = append(messages, ownMessage(rerr.error))
+		}
+	}
+
+	if innermost == nil {
+		diagnostic.Message = err.Error()
+		return
+	}
+
+	// the innermost message includes any cause that is not a reader error
+	diagnostic.Range = ReaderRange{Start: innermost.Location, End: innermost.Location}
+	diagnostic.Message = innermost.error.Error()
+	for i := len(messages) - 2; i >= 0; i-- {
+		diagnostic.Notes = append(diagnostic.Notes, messages[i])
+	}
+	return
+}
+
+// ownMessage returns the message of err, without the message of the error it wraps
+func ownMessage(err error) string {
+	message := err.Error()
+	for e := errors.Unwrap(err); e != nil; e = errors.Unwrap(e) {
+		if inner := e.Error(); inner != message {
+			return strings.TrimSuffix(message, ": "+inner)
+		}
+	}
+	return message
+}
+
+// Renderer renders diagnostics about a single source file in a human-readable form.
+//
+// Each diagnostic is rendered as a headline, the one-based location, and the offending source line with the range underlined.
+// For example:
+//
+//	error: Unexpected end of input while attempting to read literal
+//	 --> refs.bib:3:12
+//	  |
+//	3 |     title = foo
+//	  |             ^^^
+//	  = note: Unexpected error while attempting to read field
+type Renderer struct {
+	Filename string // name of the file, as shown in locations
+	Source   string // source text the diagnostics refer to
+
+	Color bool // if true, use ANSI escape sequences to color the output
+
+	lines []string // lines of source, populated when first needed
+}
+
+// ANSI escape sequences used by the renderer
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[1;31m"
+	ansiYellow = "\x1b[1;33m"
+	ansiCyan   = "\x1b[1;36m"
+	ansiBlue   = "\x1b[1;34m"
+)
+
+// paint wraps s in the given escape sequence, if color is enabled
+func (renderer *Renderer) paint(s, color string) string {
+	if !renderer.Color {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+// Render renders a single diagnostic into writer
+func (renderer *Renderer) Render(writer io.Writer, diagnostic Diagnostic) error {
+	var builder strings.Builder
+
+	severity := diagnostic.Severity.String()
+	switch diagnostic.Severity {
+	case SeverityError:
+		severity = renderer.paint(severity, ansiRed)
+	case SeverityWarning:
+		severity = renderer.paint(severity, ansiYellow)
+	default:
+		severity = renderer.paint(severity, ansiCyan)
+	}
+	fmt.Fprintf(&builder, "%s: %s\n", severity, renderer.paint(diagnostic.Message, ansiBold))
+
+	// the source snippet
+	start := diagnostic.Range.Start
+	number := fmt.Sprint(start.Line + 1)
+	gutter := strings.Repeat(" ", len(number))
+	fmt.Fprintf(&builder, "%s%s %s:%d:%d\n", gutter, renderer.paint("-->", ansiBlue), renderer.Filename, start.Line+1, start.Column+1)
+	if line, ok := renderer.line(start.Line); ok {
+		bar := renderer.paint("|", ansiBlue)
+		fmt.Fprintf(&builder, "%s %s\n", gutter, bar)
+		fmt.Fprintf(&builder, "%s %s %s\n", renderer.paint(number, ansiBlue), bar, line)
+		fmt.Fprintf(&builder, "%s %s %s\n", gutter, bar, renderer.underline(line, diagnostic))
+	}
+
+	for _, note := range diagnostic.Notes {
+		fmt.Fprintf(&builder, "%s %s %s: %s\n", gutter, renderer.paint("=", ansiBlue), renderer.paint("note", ansiBold), note)
+	}
+
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+// RenderError renders err as a diagnostic into writer, see NewDiagnosticFromError
+func (renderer *Renderer) RenderError(writer io.Writer, err error) error {
+	return renderer.Render(writer, NewDiagnosticFromError(err))
+}
+
+// underline returns a line with carets below the part of line in the range of diagnostic.
+// Ranges spanning multiple lines are underlined until the end of the first line.
+func (renderer *Renderer) underline(line string, diagnostic Diagnostic) string {
+	start, end := diagnostic.Range.Start, diagnostic.Range.End
+
+	runes := []rune(line)
+	first := int(start.Column)
+	last := int(end.Column)
+	switch {
+	case end.Line != start.Line || last >= len(runes):
+		last = len(runes) - 1
+	case end.EOF:
+		last--
+	}
+	if last < first {
+		last = first // always show at least one caret
+	}
+
+	var builder strings.Builder
+	for i := 0; i < first; i++ {
+		// keep tabs, so that carets line up with the source
+		if i < len(runes) && runes[i] == '\t' {
+			builder.WriteRune('\t')
+		} else {
+			builder.WriteRune(' ')
+		}
+	}
+	color := ansiRed
+	if diagnostic.Severity != SeverityError {
+		color = ansiYellow
+	}
+	return builder.String() + renderer.paint(strings.Repeat("^", last-first+1), color)
+}
+
+// line returns the given zero-based line of the source
+func (renderer *Renderer) line(number uint) (line string, ok bool) {
+	if renderer.lines == nil {
+		renderer.lines = sourceLines(renderer.Source)
+	}
+	if int(number) >= len(renderer.lines) {
+		return "", false
+	}
+	return renderer.lines[number], true
+}
+
+// sourceLines splits text into lines, see LineSpans
+func sourceLines(text string) (lines []string) {
+	for _, span := range LineSpans(text) {
+		lines = append(lines, text[span.Start:span.End])
+	}
+	return
+}
+
+// LineSpan is the byte range of a line within a text, excluding the line break
+type LineSpan struct {
+	Start, End int
+}
+
+// LineSpans splits text into lines, treating '\n', '\r\n' and '\n\r' as line breaks like RuneReader.
+// There always is at least one line.
+func LineSpans(text string) (lines []LineSpan) {
+	start := 0
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\n' && text[i] != '\r' {
+			continue
+		}
+
+		end := i
+		switch {
+		case i+1 < len(text) && (text[i:i+2] == "\r\n" || text[i:i+2] == "\n\r"):
+			i++
+		case text[i] == '\r':
+			continue // a lone '\r' is not a line break
+		}
+		lines = append(lines, LineSpan{Start: start, End: end})
+		start = i + 1
+	}
+	return append(lines, LineSpan{Start: start, End: len(text)})
+}
@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// nestedError returns an error as produced by nested parsing functions
+func nestedError() error {
+	reader := NewRuneReaderFromString("@book{a,\n\ttitle = {foo} x,\n}")
+	reader.EatWhile(func(r rune) bool { return r != 'x' })
+	inner := NewErrorF(reader, "Unexpected start of literal")
+	reader.Eat()
+	return WrapErrorF(reader, WrapErrorF(reader, inner, "Unexpected error while attempting to read field"), "Unexpected error while attempting to read entry")
+}
+
+func TestNewDiagnosticFromError(t *testing.T) {
+	at := ReaderPosition{Line: 1, Column: 15, UTF16Column: 15, Offset: 24, RuneOffset: 24}
+	tests := []struct {
+		name string
+		err  error
+		want Diagnostic
+	}{
+		{
+			"plain error",
+			errors.New("something went wrong"),
+			Diagnostic{Severity: SeverityError, Message: "something went wrong"},
+		},
+		{
+			"nested reader errors",
+			nestedError(),
+			Diagnostic{
+				Severity: SeverityError,
+				Message:  "Unexpected start of literal",
+				Range:    ReaderRange{Start: at, End: at},
+				Notes: []string{
+					"Unexpected error while attempting to read field",
+					"Unexpected error while attempting to read entry",
+				},
+			},
+		},
+		{
+			"reader error wrapping a plain error",
+			WrapErrorF(NewRuneReaderFromString(""), errors.New("disk on fire"), "Unable to read"),
+			Diagnostic{Severity: SeverityError, Message: "Unable to read: disk on fire"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewDiagnosticFromError(tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewDiagnosticFromError() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderer_Render(t *testing.T) {
+	source := "@book{a,\n\ttitle = {foo} x,\n  year = 20ab,\n}"
+	tests := []struct {
+		name       string
+		color      bool
+		diagnostic Diagnostic
+		want       string
+	}{
+		{
+			"error with notes",
+			false,
+			NewDiagnosticFromError(nestedError()),
+			"error: Unexpected start of literal\n" +
+				" --> refs.bib:2:16\n" +
+				"  |\n" +
+				"2 | \ttitle = {foo} x,\n" +
+				"  | \t              ^\n" +
+				"  = note: Unexpected error while attempting to read field\n" +
+				"  = note: Unexpected error while attempting to read entry\n",
+		},
+		{
+			"warning spanning a range",
+			false,
+			Diagnostic{
+				Severity: SeverityWarning,
+				Message:  "year is not a number",
+				Range:    ReaderRange{Start: ReaderPosition{Line: 2, Column: 9}, End: ReaderPosition{Line: 2, Column: 12}},
+			},
+			"warning: year is not a number\n" +
+				" --> refs.bib:3:10\n" +
+				"  |\n" +
+				"3 |   year = 20ab,\n" +
+				"  |          ^^^^\n",
+		},
+		{
+			"range spanning multiple lines",
+			false,
+			Diagnostic{
+				Severity: SeverityNote,
+				Message:  "entry starts here",
+				Range:    ReaderRange{Start: ReaderPosition{Line: 0, Column: 6}, End: ReaderPosition{Line: 3, Column: 0}},
+			},
+			"note: entry starts here\n" +
+				" --> refs.bib:1:7\n" +
+				"  |\n" +
+				"1 | @book{a,\n" +
+				"  |       ^^\n",
+		},
+		{
+			"end of input",
+			false,
+			Diagnostic{
+				Severity: SeverityError,
+				Message:  "Unexpected end of input",
+				Range:    ReaderRange{Start: ReaderPosition{Line: 3, Column: 1, EOF: true}, End: ReaderPosition{Line: 3, Column: 1, EOF: true}},
+			},
+			"error: Unexpected end of input\n" +
+				" --> refs.bib:4:2\n" +
+				"  |\n" +
+				"4 | }\n" +
+				"  |  ^\n",
+		},
+		{
+			"no source line",
+			false,
+			Diagnostic{Severity: SeverityError, Message: "boom", Range: ReaderRange{Start: ReaderPosition{Line: 10}}},
+			"error: boom\n" +
+				"  --> refs.bib:11:1\n",
+		},
+		{
+			"color",
+			true,
+			Diagnostic{
+				Severity: SeverityWarning,
+				Message:  "year is not a number",
+				Range:    ReaderRange{Start: ReaderPosition{Line: 2, Column: 9}, End: ReaderPosition{Line: 2, Column: 12}},
+				Notes:    []string{"numbers only"},
+			},
+			"\x1b[1;33mwarning\x1b[0m: \x1b[1myear is not a number\x1b[0m\n" +
+				" \x1b[1;34m-->\x1b[0m refs.bib:3:10\n" +
+				"  \x1b[1;34m|\x1b[0m\n" +
+				"\x1b[1;34m3\x1b[0m \x1b[1;34m|\x1b[0m   year = 20ab,\n" +
+				"  \x1b[1;34m|\x1b[0m          \x1b[1;33m^^^^\x1b[0m\n" +
+				"  \x1b[1;34m=\x1b[0m \x1b[1mnote\x1b[0m: numbers only\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := &Renderer{Filename: "refs.bib", Source: source, Color: tt.color}
+
+			var buffer bytes.Buffer
+			if err := renderer.Render(&buffer, tt.diagnostic); err != nil {
+				t.Errorf("Renderer.Render() error = %v", err)
+			}
+			if got := buffer.String(); got != tt.want {
+				t.Errorf("Renderer.Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineSpans(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantLines []string
+	}{
+		{"empty", "", []string{""}},
+		{"single line", "hello", []string{"hello"}},
+		{"trailing newline", "a\n", []string{"a", ""}},
+		{"unix", "a\nb", []string{"a", "b"}},
+		{"windows", "a\r\nb", []string{"a", "b"}},
+		{"reversed", "a\n\rb", []string{"a", "b"}},
+		{"lone carriage return", "a\rb", []string{"a\rb"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotLines []string
+			for _, span := range LineSpans(tt.text) {
+				gotLines = append(gotLines, tt.text[span.Start:span.End])
+			}
+			if !reflect.DeepEqual(gotLines, tt.wantLines) {
+				t.Errorf("LineSpans() = %q, want %q", gotLines, tt.wantLines)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding represents the character encoding of an input
+type Encoding string
+
+// supported encodings
+const (
+	EncodingUTF8        Encoding = "utf-8"
+	EncodingUTF16LE     Encoding = "utf-16le"
+	EncodingUTF16BE     Encoding = "utf-16be"
+	EncodingLatin1      Encoding = "iso-8859-1"
+	EncodingWindows1252 Encoding = "windows-1252"
+
+	// EncodingAuto is not an actual encoding.
+	// It instructs NewRuneReaderWithEncoding to detect the encoding using DetectEncoding.
+	EncodingAuto Encoding = "auto"
+)
+
+// encodingNames maps (lowercase) names of encodings to encodings
+var encodingNames = map[string]Encoding{
+	"utf-8":        EncodingUTF8,
+	"utf8":         EncodingUTF8,
+	"utf-16le":     EncodingUTF16LE,
+	"utf-16be":     EncodingUTF16BE,
+	"iso-8859-1":   EncodingLatin1,
+	"latin1":       EncodingLatin1,
+	"latin-1":      EncodingLatin1,
+	"windows-1252": EncodingWindows1252,
+	"cp1252":       EncodingWindows1252,
+	"auto":         EncodingAuto,
+}
+
+// ParseEncoding parses the name of an encoding, such as "utf-8", "latin1" or "cp1252"
+func ParseEncoding(name string) (Encoding, error) {
+	if encoding, ok := encodingNames[strings.ToLower(name)]; ok {
+		return encoding, nil
+	}
+	return "", fmt.Errorf("unknown encoding %q", name)
+}
+
+// BOM returns the byte order mark of this encoding, or nil if it does not have one
+func (encoding Encoding) BOM() []byte {
+	switch encoding {
+	case EncodingUTF8:
+		return []byte{0xEF, 0xBB, 0xBF}
+	case EncodingUTF16LE:
+		return []byte{0xFF, 0xFE}
+	case EncodingUTF16BE:
+		return []byte{0xFE, 0xFF}
+	}
+	return nil
+}
+
+// DetectEncoding guesses the encoding of an input starting with sample.
+// Inputs starting with a byte order mark use the corresponding encoding.
+// Otherwise samples that are valid UTF-8 are assumed to be UTF-8, and all others to be 8-bit.
+// 8-bit samples containing characters only printable in Windows-1252 are assumed to be Windows-1252, all others Latin-1.
+func DetectEncoding(sample []byte) Encoding {
+	if encoding := detectBOM(sample); encoding != "" {
+		return encoding
+	}
+
+	// a truncated sample may end within a valid sequence
+	valid := sample
+	for i := 1; i < utf8.UTFMax && i <= len(valid); i++ {
+		if utf8.RuneStart(valid[len(valid)-i]) {
+			if !utf8.FullRune(valid[len(valid)-i:]) {
+				valid = valid[:len(valid)-i]
+			}
+			break
+		}
+	}
+	if utf8.Valid(valid) {
+		return EncodingUTF8
+	}
+
+	for _, b := range sample {
+		if b >= 0x80 && b < 0xA0 && windows1252[b-0x80] != rune(b) {
+			return EncodingWindows1252
+		}
+	}
+	return EncodingLatin1
+}
+
+// detectBOM returns the encoding indicated by the byte order mark at the start of data, if any
+func detectBOM(data []byte) Encoding {
+	for _, encoding := range []Encoding{EncodingUTF8, EncodingUTF16LE, EncodingUTF16BE} {
+		if bytes.HasPrefix(data, encoding.BOM()) {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// errInvalidInput is returned by decoders when they encounter input that is not valid in their encoding
+var errInvalidInput = errors.New("invalid input")
+
+// newDecoder returns an io.RuneReader that decodes the given encoding from rd.
+// Sizes returned by the decoder are the number of bytes read from rd.
+func newDecoder(rd *bufio.Reader, encoding Encoding) io.RuneReader {
+	switch encoding {
+	case EncodingUTF16LE:
+		return &utf16Decoder{reader: rd, bigEndian: false}
+	case EncodingUTF16BE:
+		return &utf16Decoder{reader: rd, bigEndian: true}
+	case EncodingLatin1:
+		return &byteDecoder{reader: rd}
+	case EncodingWindows1252:
+		return &byteDecoder{reader: rd, high: &windows1252}
+	}
+	return rd
+}
+
+// byteDecoder decodes an 8-bit encoding that is identical to Latin-1 apart from bytes 0x80 to 0x9F
+type byteDecoder struct {
+	reader *bufio.Reader
+	high   *[32]rune // runes for bytes 0x80 to 0x9F, nil for Latin-1
+}
+
+func (bd *byteDecoder) ReadRune() (r rune, size int, err error) {
+	b, err := bd.reader.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	if bd.high != nil && b >= 0x80 && b < 0xA0 {
+		return bd.high[b-0x80], 1, nil
+	}
+	return rune(b), 1, nil
+}
+
+// utf16Decoder decodes UTF-16
+type utf16Decoder struct {
+	reader    *bufio.Reader
+	bigEndian bool
+}
+
+func (ud *utf16Decoder) ReadRune() (r rune, size int, err error) {
+	first, err := ud.unit()
+	if err != nil {
+		return 0, 0, err
+	}
+	if !utf16.IsSurrogate(first) {
+		return first, 2, nil
+	}
+
+	// read the second half of a surrogate pair, without consuming anything that isn't
+	data, err := ud.reader.Peek(2)
+	if err != nil {
+		return utf8.RuneError, 2, errInvalidInput
+	}
+	r = utf16.DecodeRune(first, ud.decode(data))
+	if r == utf8.RuneError {
+		return r, 2, errInvalidInput
+	}
+	ud.reader.Discard(2)
+	return r, 4, nil
+}
+
+// unit reads a single code unit
+func (ud *utf16Decoder) unit() (rune, error) {
+	data, err := ud.reader.Peek(2)
+	switch {
+	case err == io.EOF && len(data) == 0:
+		return 0, io.EOF
+	case err == io.EOF:
+		ud.reader.Discard(len(data))
+		return 0, errInvalidInput
+	case err != nil:
+		return 0, err
+	}
+	ud.reader.Discard(2)
+	return ud.decode(data), nil
+}
+
+// decode decodes the code unit stored in data
+func (ud *utf16Decoder) decode(data []byte) rune {
+	if ud.bigEndian {
+		return rune(data[0])<<8 | rune(data[1])
+	}
+	return rune(data[1])<<8 | rune(data[0])
+}
+
+// NewEncodingWriter returns a writer that encodes UTF-8 written to it into encoding, and writes it to writer.
+// Runes that can not be represented in encoding cause an error.
+func NewEncodingWriter(writer io.Writer, encoding Encoding) io.Writer {
+	if encoding == EncodingUTF8 || encoding == "" {
+		return writer
+	}
+	return &encodingWriter{writer: writer, encoding: encoding}
+}
+
+// encodingWriter encodes UTF-8 into a different encoding
+type encodingWriter struct {
+	writer   io.Writer
+	encoding Encoding
+
+	pending []byte // incomplete utf-8 sequence from the previous write
+	buffer  []byte
+}
+
+func (ew *encodingWriter) Write(p []byte) (n int, err error) {
+	data := append(ew.pending, p...)
+	ew.pending = nil
+
+	ew.buffer = ew.buffer[:0]
+	for len(data) > 0 {
+		if !utf8.FullRune(data) {
+			ew.pending = append([]byte(nil), data...)
+			break
+		}
+
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size == 1 {
+			return 0, fmt.Errorf("invalid UTF-8 in output")
+		}
+		if ew.buffer, err = ew.encode(ew.buffer, r); err != nil {
+			return 0, err
+		}
+		data = data[size:]
+	}
+
+	if _, err := ew.writer.Write(ew.buffer); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// encode appends the encoding of r to buffer
+func (ew *encodingWriter) encode(buffer []byte, r rune) ([]byte, error) {
+	switch ew.encoding {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		units := []rune{r}
+		if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+			units = []rune{r1, r2}
+		}
+		for _, u := range units {
+			if ew.encoding == EncodingUTF16BE {
+				buffer = append(buffer, byte(u>>8), byte(u))
+			} else {
+				buffer = append(buffer, byte(u), byte(u>>8))
+			}
+		}
+		return buffer, nil
+	case EncodingWindows1252:
+		for i, h := range windows1252 {
+			if h == r {
+				return append(buffer, byte(0x80+i)), nil
+			}
+		}
+		if r >= 0x80 && r < 0xA0 {
+			break
+		}
+		fallthrough
+	case EncodingLatin1:
+		if r < 0x100 {
+			return append(buffer, byte(r)), nil
+		}
+	}
+	return buffer, fmt.Errorf("%q can not be encoded as %s", r, ew.encoding)
+}
+
+// windows1252 contains the runes of bytes 0x80 to 0x9F in Windows-1252.
+// Undefined bytes are mapped to the corresponding control characters.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample string
+		want   Encoding
+	}{
+		{"empty", "", EncodingUTF8},
+		{"ascii", "hello world", EncodingUTF8},
+		{"utf-8", "caf\xc3\xa9", EncodingUTF8},
+		{"truncated utf-8", "caf\xc3", EncodingUTF8},
+		{"utf-8 bom", "\xef\xbb\xbfhello", EncodingUTF8},
+		{"utf-16le bom", "\xff\xfeh\x00", EncodingUTF16LE},
+		{"utf-16be bom", "\xfe\xff\x00h", EncodingUTF16BE},
+		{"latin-1", "caf\xe9 M\xfcller", EncodingLatin1},
+		{"windows-1252", "\x93caf\xe9\x94", EncodingWindows1252},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectEncoding([]byte(tt.sample)); got != tt.want {
+				t.Errorf("DetectEncoding() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Encoding
+		wantErr bool
+	}{
+		{"UTF-8", EncodingUTF8, false},
+		{"latin1", EncodingLatin1, false},
+		{"cp1252", EncodingWindows1252, false},
+		{"auto", EncodingAuto, false},
+		{"ebcdic", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEncoding(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseEncoding() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseEncoding() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRuneReaderWithEncoding(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		encoding     Encoding
+		wantText     string
+		wantEncoding Encoding
+		wantBOM      bool
+		wantEnd      ReaderPosition
+	}{
+		{"utf-8", "caf\xc3\xa9", EncodingUTF8, "café", EncodingUTF8, false, ReaderPosition{0, 4, 4, 5, 4, true}},
+		{"utf-8 with bom", "\xef\xbb\xbfcaf\xc3\xa9", EncodingUTF8, "café", EncodingUTF8, true, ReaderPosition{0, 4, 4, 8, 4, true}},
+		{"bom takes precedence", "\xef\xbb\xbfcaf\xc3\xa9", EncodingLatin1, "café", EncodingUTF8, true, ReaderPosition{0, 4, 4, 8, 4, true}},
+		{"utf-16le", "\xff\xfec\x00a\x00f\x00\xe9\x00\r\x00\n\x00=\xd8\x00\xde", EncodingUTF8, "café\n😀", EncodingUTF16LE, true, ReaderPosition{1, 1, 2, 18, 7, true}},
+		{"utf-16be", "\xfe\xff\x00c\x00a\x00f\x00\xe9", EncodingUTF8, "café", EncodingUTF16BE, true, ReaderPosition{0, 4, 4, 10, 4, true}},
+		{"latin-1", "caf\xe9 \xa4", EncodingLatin1, "café ¤", EncodingLatin1, false, ReaderPosition{0, 6, 6, 6, 6, true}},
+		{"windows-1252", "\x93caf\xe9\x94 \x80", EncodingWindows1252, "“café” €", EncodingWindows1252, false, ReaderPosition{0, 8, 8, 8, 8, true}},
+		{"auto", "\x93caf\xe9\x94", EncodingAuto, "“café”", EncodingWindows1252, false, ReaderPosition{0, 6, 6, 6, 6, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewRuneReaderWithEncoding(strings.NewReader(tt.input), tt.encoding)
+			gotText, loc, err := reader.ReadWhile(func(r rune) bool { return true })
+			if err != nil {
+				t.Fatalf("RuneReader.ReadWhile() error = %v", err)
+			}
+			if gotText != tt.wantText {
+				t.Errorf("RuneReader.ReadWhile() = %q, want %q", gotText, tt.wantText)
+			}
+			if loc.End != tt.wantEnd {
+				t.Errorf("RuneReader.ReadWhile() end = %#v, want %#v", loc.End, tt.wantEnd)
+			}
+			if got := reader.Encoding(); got != tt.wantEncoding {
+				t.Errorf("RuneReader.Encoding() = %v, want %v", got, tt.wantEncoding)
+			}
+			if got := reader.BOM(); got != tt.wantBOM {
+				t.Errorf("RuneReader.BOM() = %v, want %v", got, tt.wantBOM)
+			}
+		})
+	}
+}
+
+func TestRuneReader_Read_invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		encoding Encoding
+		wantPos  ReaderPosition
+	}{
+		{"invalid utf-8", "ab\ncaf\xe9!", EncodingUTF8, ReaderPosition{1, 3, 3, 6, 6, false}},
+		{"invalid utf-8 after carriage return", "ab\r\xe9", EncodingUTF8, ReaderPosition{0, 3, 3, 3, 3, false}},
+		{"unpaired surrogate", "a\x00\x00\xd8b\x00", EncodingUTF16LE, ReaderPosition{0, 1, 1, 2, 1, false}},
+		{"odd length", "a\x00b", EncodingUTF16LE, ReaderPosition{0, 1, 1, 2, 1, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewRuneReaderWithEncoding(strings.NewReader(tt.input), tt.encoding)
+			_, err := reader.EatWhile(func(r rune) bool { return true })
+			rerr, ok := err.(*ReaderError)
+			if !ok {
+				t.Fatalf("RuneReader.EatWhile() error = %v, want a *ReaderError", err)
+			}
+			if rerr.Location != tt.wantPos {
+				t.Errorf("RuneReader.EatWhile() error location = %#v, want %#v", rerr.Location, tt.wantPos)
+			}
+		})
+	}
+}
+
+func TestNewEncodingWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding Encoding
+		input    []string
+		want     string
+		wantErr  bool
+	}{
+		{"utf-8", EncodingUTF8, []string{"café"}, "caf\xc3\xa9", false},
+		{"utf-16le", EncodingUTF16LE, []string{"é😀"}, "\xe9\x00=\xd8\x00\xde", false},
+		{"utf-16be", EncodingUTF16BE, []string{"é"}, "\x00\xe9", false},
+		{"latin-1", EncodingLatin1, []string{"café ¤"}, "caf\xe9 \xa4", false},
+		{"latin-1 split rune", EncodingLatin1, []string{"caf\xc3", "\xa9"}, "caf\xe9", false},
+		{"latin-1 unencodable", EncodingLatin1, []string{"€"}, "", true},
+		{"windows-1252", EncodingWindows1252, []string{"“café” €"}, "\x93caf\xe9\x94 \x80", false},
+		{"windows-1252 unencodable", EncodingWindows1252, []string{"\u0080"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			writer := NewEncodingWriter(&buffer, tt.encoding)
+
+			var err error
+			for _, s := range tt.input {
+				if _, err = writer.Write([]byte(s)); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewEncodingWriter().Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := buffer.String(); !tt.wantErr && got != tt.want {
+				t.Errorf("NewEncodingWriter().Write() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,23 +1,87 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+)
+
+// ErrorCode identifies the kind of a ReaderError.
+// Codes are stable and can be used to check for specific errors using errors.Is:
+//
+//	if errors.Is(err, utils.CodeUnexpectedEOF) { ... }
+type ErrorCode int
 
-	"github.com/pkg/errors"
+// error codes of ReaderErrors
+const (
+	CodeUnknown             ErrorCode = iota // an error without a specific code
+	CodeUnexpectedEOF                        // the input ended unexpectedly
+	CodeUnexpectedCharacter                  // a character occurred where it is not allowed
+	CodeMissingAt                            // an entry did not start with an '@'
+	CodeMissingBrace                         // an opening '{' was expected
+	CodeMissingQuote                         // an opening '"' was expected
+	CodeUnbalancedBrace                      // the input ended before all braces were closed
+	CodeUnterminatedQuote                    // the input ended before a quote was closed
+	CodeInvalidEncoding                      // the input was not valid in its encoding
+	CodeReadFailed                           // the underlying reader returned an error
 )
 
-// ReaderError represents an error of an error
+// errorCodeNames are the names of error codes
+var errorCodeNames = map[ErrorCode]string{
+	CodeUnknown:             "unknown",
+	CodeUnexpectedEOF:       "unexpected-eof",
+	CodeUnexpectedCharacter: "unexpected-character",
+	CodeMissingAt:           "missing-at",
+	CodeMissingBrace:        "missing-brace",
+	CodeMissingQuote:        "missing-quote",
+	CodeUnbalancedBrace:     "unbalanced-brace",
+	CodeUnterminatedQuote:   "unterminated-quote",
+	CodeInvalidEncoding:     "invalid-encoding",
+	CodeReadFailed:          "read-failed",
+}
+
+// String returns the stable name of this code, such as "unexpected-eof"
+func (code ErrorCode) String() string {
+	if name, ok := errorCodeNames[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("ErrorCode(%d)", int(code))
+}
+
+// Error returns the name of this code, so that codes can be used as targets of errors.Is
+func (code ErrorCode) Error() string {
+	return code.String()
+}
+
+// MarshalText marshals this code as its name
+func (code ErrorCode) MarshalText() ([]byte, error) {
+	return []byte(code.String()), nil
+}
+
+// ReaderError represents an error that occurred at a specific location of a RuneReader
 type ReaderError struct {
 	error
 	Reader   *RuneReader
 	Location ReaderPosition
+
+	Code     ErrorCode // the kind of error
+	Expected string    // what was expected at Location, if known
+	Actual   string    // what was found at Location instead, empty at the end of input
+
+	EntryKind  string // kind of the entry that was being read, if any
+	EntryLabel string // label of the entry that was being read, if known
 }
 
-// Cause returns the underlying cause of this error
-func (r ReaderError) Cause() error {
+// Unwrap returns the underlying error
+func (r *ReaderError) Unwrap() error {
 	return r.error
 }
 
+// Is checks if target is the code of this error
+func (r *ReaderError) Is(target error) bool {
+	code, ok := target.(ErrorCode)
+	return ok && code != CodeUnknown && code == r.Code
+}
+
 // Error returns the error
 func (r *ReaderError) Error() string {
 	return fmt.Sprintf("%s near %s", r.error.Error(), r.Location)
@@ -25,18 +89,54 @@ func (r *ReaderError) Error() string {
 
 // NewErrorF returns a new error for the given reader and error message
 func NewErrorF(reader *RuneReader, format string, args ...interface{}) *ReaderError {
+	return NewCodeErrorF(reader, CodeUnknown, format, args...)
+}
+
+// NewCodeErrorF returns a new error with the given code for the given reader and error message
+func NewCodeErrorF(reader *RuneReader, code ErrorCode, format string, args ...interface{}) *ReaderError {
 	return &ReaderError{
 		error:    fmt.Errorf(format, args...),
 		Reader:   reader,
 		Location: reader.Position(),
+		Code:     code,
 	}
 }
 
-// WrapErrorF returns a new error for the given reader and underlying error
+// NewUnexpectedError returns a new error with the given code, reporting that actual was found instead of expected.
+// The message is generated from what was being read, e.g. "field".
+func NewUnexpectedError(reader *RuneReader, code ErrorCode, reading string, expected string, actual string) *ReaderError {
+	var err *ReaderError
+	switch {
+	case actual == "":
+		err = NewCodeErrorF(reader, code, "Unexpected end of input while attempting to read %s", reading)
+	case expected == "":
+		err = NewCodeErrorF(reader, code, "Unexpected %q while attempting to read %s", actual, reading)
+	default:
+		err = NewCodeErrorF(reader, code, "Expected to find %q but got %q while attempting to read %s", expected, actual, reading)
+	}
+	err.Expected = expected
+	err.Actual = actual
+	return err
+}
+
+// WrapErrorF returns a new error for the given reader and underlying error.
+// If err is or wraps a ReaderError, the returned error inherits its code, tokens and entry.
 func WrapErrorF(reader *RuneReader, err error, format string, args ...interface{}) *ReaderError {
-	return &ReaderError{
-		error:    errors.Wrapf(err, format, args...),
+	wrapped := &ReaderError{
+		error:    fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), err),
 		Reader:   reader,
 		Location: reader.Position(),
 	}
+
+	var cause *ReaderError
+	if errors.As(err, &cause) {
+		wrapped.Code = cause.Code
+		wrapped.Expected = cause.Expected
+		wrapped.Actual = cause.Actual
+		wrapped.EntryKind = cause.EntryKind
+		wrapped.EntryLabel = cause.EntryLabel
+	} else {
+		wrapped.Code = CodeReadFailed
+	}
+	return wrapped
 }
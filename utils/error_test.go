@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestErrorCode_String(t *testing.T) {
+	tests := []struct {
+		code ErrorCode
+		want string
+	}{
+		{CodeUnknown, "unknown"},
+		{CodeUnexpectedEOF, "unexpected-eof"},
+		{CodeUnbalancedBrace, "unbalanced-brace"},
+		{ErrorCode(100), "ErrorCode(100)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.code.String(); got != tt.want {
+				t.Errorf("ErrorCode.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewUnexpectedError(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		want     string
+	}{
+		{"end of input", "}", "", "Unexpected end of input while attempting to read braces near line 0 column 0"},
+		{"unexpected character", "", "=", "Unexpected \"=\" while attempting to read braces near line 0 column 0"},
+		{"expected character", "{", "x", "Expected to find \"{\" but got \"x\" while attempting to read braces near line 0 column 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewUnexpectedError(NewRuneReaderFromString(""), CodeMissingBrace, "braces", tt.expected, tt.actual)
+			if got := err.Error(); got != tt.want {
+				t.Errorf("NewUnexpectedError().Error() = %v, want %v", got, tt.want)
+			}
+			if err.Code != CodeMissingBrace || err.Expected != tt.expected || err.Actual != tt.actual {
+				t.Errorf("NewUnexpectedError() = %v, %q, %q, want %v, %q, %q", err.Code, err.Expected, err.Actual, CodeMissingBrace, tt.expected, tt.actual)
+			}
+		})
+	}
+}
+
+func TestWrapErrorF(t *testing.T) {
+	reader := NewRuneReaderFromString("")
+
+	inner := NewUnexpectedError(reader, CodeUnbalancedBrace, "braces", "}", "")
+	inner.EntryKind, inner.EntryLabel = "book", "knuth"
+	wrapped := WrapErrorF(reader, WrapErrorF(reader, inner, "while reading field"), "while reading entry")
+
+	if wrapped.Code != CodeUnbalancedBrace || wrapped.Expected != "}" || wrapped.EntryKind != "book" || wrapped.EntryLabel != "knuth" {
+		t.Errorf("WrapErrorF() did not inherit from its cause, got %#v", wrapped)
+	}
+	if !errors.Is(wrapped, CodeUnbalancedBrace) {
+		t.Errorf("errors.Is(WrapErrorF(), CodeUnbalancedBrace) = false, want true")
+	}
+	if errors.Is(wrapped, CodeUnexpectedEOF) {
+		t.Errorf("errors.Is(WrapErrorF(), CodeUnexpectedEOF) = true, want false")
+	}
+
+	var rerr *ReaderError
+	if !errors.As(wrapped, &rerr) || rerr != wrapped {
+		t.Errorf("errors.As(WrapErrorF()) did not find the outermost error")
+	}
+
+	// wrapping other errors indicates a failed read
+	plain := WrapErrorF(reader, io.ErrUnexpectedEOF, "while reading")
+	if plain.Code != CodeReadFailed || !errors.Is(plain, io.ErrUnexpectedEOF) {
+		t.Errorf("WrapErrorF() = %#v, want a wrapped read failure", plain)
+	}
+}
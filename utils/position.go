@@ -1,12 +1,21 @@
 package utils
 
-import "fmt"
+import (
+	"fmt"
+	"unicode/utf16"
+)
 
-// ReaderPosition represents the position of a character within a document
+// ReaderPosition represents the position of a character within a document.
+// In JSON, offsets and UTF-16 columns are omitted when zero, so positions encoded by earlier versions remain valid.
 type ReaderPosition struct {
-	Line   uint `json:"line"`          // zero-based line number
-	Column uint `json:"column"`        // zero-based column number
-	EOF    bool `json:"eof,omitempty"` // true iff we are at the end of the input
+	Line   uint `json:"line"`   // zero-based line number
+	Column uint `json:"column"` // zero-based column number, counted in runes
+
+	UTF16Column uint `json:"utf16Column,omitempty"` // zero-based column number, counted in UTF-16 code units
+	Offset      uint `json:"offset,omitempty"`      // zero-based byte offset within the input
+	RuneOffset  uint `json:"runeOffset,omitempty"`  // zero-based rune offset within the input
+
+	EOF bool `json:"eof,omitempty"` // true iff we are at the end of the input
 }
 
 // String turns this ReaderPosition into a string
@@ -18,6 +27,24 @@ func (rp ReaderPosition) String() string {
 	return fmt.Sprintf("line %d column %d%s", rp.Line, rp.Column, s)
 }
 
+// advance moves this position behind raw.
+// Line breaks are not handled, the caller is responsible for starting a new line.
+func (rp *ReaderPosition) advance(raw rawRune) {
+	rp.Column++
+	rp.UTF16Column += uint(UTF16Len(raw.r))
+	rp.Offset += uint(raw.size)
+	rp.RuneOffset++
+}
+
+// UTF16Len returns the number of UTF-16 code units needed to encode r.
+// Invalid runes count as a single code unit, as they are encoded as utf8.RuneError.
+func UTF16Len(r rune) int {
+	if n := utf16.RuneLen(r); n > 0 {
+		return n
+	}
+	return 1
+}
+
 // ReaderRange represents a range of read characters from a RuneReader
 // The range is defined to contain all characters from Start to End (inclusive).
 // A ReaderRange object on its own can not express an empty range, instead the read characters should be returned alongside the range.
@@ -25,3 +52,16 @@ type ReaderRange struct {
 	Start ReaderPosition `json:"start"` // the first character included within the range
 	End   ReaderPosition `json:"end"`   // the last character included within the range
 }
+
+// Contains checks if the character at pos is contained within this range.
+// Positions are compared by line and column only.
+// An End at the end of the input is not a character, and is not contained within the range.
+func (rr ReaderRange) Contains(pos ReaderPosition) bool {
+	if pos.Line < rr.Start.Line || (pos.Line == rr.Start.Line && pos.Column < rr.Start.Column) {
+		return false
+	}
+	if rr.End.EOF {
+		return pos.Line < rr.End.Line || (pos.Line == rr.End.Line && pos.Column < rr.End.Column)
+	}
+	return pos.Line < rr.End.Line || (pos.Line == rr.End.Line && pos.Column <= rr.End.Column)
+}
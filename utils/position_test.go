@@ -0,0 +1,32 @@
+package utils
+
+import "testing"
+
+func TestReaderRange_Contains(t *testing.T) {
+	rng := ReaderRange{Start: ReaderPosition{Line: 1, Column: 4}, End: ReaderPosition{Line: 2, Column: 2}}
+	eof := ReaderRange{Start: ReaderPosition{Line: 1, Column: 4}, End: ReaderPosition{Line: 2, Column: 2, EOF: true}}
+
+	tests := []struct {
+		name string
+		rng  ReaderRange
+		pos  ReaderPosition
+		want bool
+	}{
+		{"previous line", rng, ReaderPosition{Line: 0, Column: 10}, false},
+		{"before start", rng, ReaderPosition{Line: 1, Column: 3}, false},
+		{"start", rng, ReaderPosition{Line: 1, Column: 4}, true},
+		{"end of line", rng, ReaderPosition{Line: 1, Column: 80}, true},
+		{"end", rng, ReaderPosition{Line: 2, Column: 2}, true},
+		{"behind end", rng, ReaderPosition{Line: 2, Column: 3}, false},
+		{"next line", rng, ReaderPosition{Line: 3, Column: 0}, false},
+		{"before end of input", eof, ReaderPosition{Line: 2, Column: 1}, true},
+		{"end of input", eof, ReaderPosition{Line: 2, Column: 2}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rng.Contains(tt.pos); got != tt.want {
+				t.Errorf("ReaderRange.Contains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"io"
 	"strings"
+	"unicode/utf8"
 )
 
 // RuneReader represents something that can read runes from a RuneReader
@@ -12,23 +13,85 @@ type RuneReader struct {
 	Reader io.RuneReader
 
 	position ReaderPosition // current position
-	pushback []rune         // runes that have been unread
+	pushback []unread       // runes that have been unread
+
+	lookahead *rawRune // raw rune that has been peeked from Reader, if any
+
+	lineEnding string // the first line break that has been read, if any
+
+	encoding Encoding // encoding of the input
+	bom      bool     // if the input started with a byte order mark
+}
+
+// unread is a rune that has been unread, along with the position directly behind it
+type unread struct {
+	r    rune
+	next ReaderPosition
 }
 
-// NewRuneReaderFromReader creates a new RuneReader from an io.Reader
+// rawRune is a rune read from the underlying reader, along with its size in bytes and the error that occurred reading it
+type rawRune struct {
+	r    rune
+	size int
+	err  error
+}
+
+// NewRuneReaderFromReader creates a new RuneReader from an io.Reader.
+// The input is assumed to be UTF-8, unless it starts with a UTF-16 byte order mark.
 func NewRuneReaderFromReader(rd io.Reader) *RuneReader {
-	reader := &RuneReader{}
-
-	// if we already have a RuneReader, we can just use it
-	// else we make one using bufio!
-	if rr, ok := rd.(io.RuneReader); ok {
-		reader.Reader = rr
-	} else {
-		reader.Reader = bufio.NewReader(rd)
+	return NewRuneReaderWithEncoding(rd, EncodingUTF8)
+}
+
+// detectSize is the number of bytes used to detect the encoding of an input
+const detectSize = 64 * 1024
+
+// NewRuneReaderWithEncoding creates a new RuneReader reading input of the given encoding from an io.Reader.
+// If encoding is EncodingAuto, the encoding is detected using the beginning of the input.
+//
+// A byte order mark at the beginning of the input is skipped, and takes precedence over encoding.
+// Byte offsets of positions refer to the original input, and include the byte order mark.
+func NewRuneReaderWithEncoding(rd io.Reader, encoding Encoding) *RuneReader {
+	// make sure we can peek into the input
+	br, ok := rd.(*bufio.Reader)
+	if !ok {
+		size := 4096
+		if encoding == EncodingAuto {
+			size = detectSize
+		}
+		br = bufio.NewReaderSize(rd, size)
+	}
+
+	reader := &RuneReader{encoding: encoding}
+
+	// skip over the byte order mark
+	data, _ := br.Peek(3)
+	if bom := detectBOM(data); bom != "" {
+		reader.encoding, reader.bom = bom, true
+		reader.position.Offset = uint(len(bom.BOM()))
+		br.Discard(len(bom.BOM()))
 	}
+
+	if reader.encoding == EncodingAuto {
+		data, _ := br.Peek(detectSize)
+		reader.encoding = DetectEncoding(data)
+	}
+
+	reader.Reader = newDecoder(br, reader.encoding)
 	return reader
 }
 
+// NewRuneReaderAt creates a new RuneReader for UTF-8 input that continues a larger input at start.
+// Positions of characters refer to the larger input.
+// Unlike NewRuneReaderFromReader, a byte order mark is not detected.
+func NewRuneReaderAt(rd io.Reader, start ReaderPosition) *RuneReader {
+	start.EOF = false
+	return &RuneReader{
+		Reader:   bufio.NewReader(rd),
+		position: start,
+		encoding: EncodingUTF8,
+	}
+}
+
 // NewRuneReaderFromString creates a new RuneReader from a string
 func NewRuneReaderFromString(s string) *RuneReader {
 	return NewRuneReaderFromReader(strings.NewReader(s))
@@ -40,57 +103,77 @@ func (reader *RuneReader) Position() ReaderPosition {
 	return reader.position
 }
 
+// Encoding returns the encoding of the input
+func (reader *RuneReader) Encoding() Encoding {
+	return reader.encoding
+}
+
+// BOM returns if the input started with a byte order mark
+func (reader *RuneReader) BOM() bool {
+	return reader.bom
+}
+
+// LineEnding returns the first line break read so far, before it was normalized into a single '\n'.
+// This is one of "\n", "\r\n" or "\n\r", or the empty string if no line break has been read.
+func (reader *RuneReader) LineEnding() string {
+	return reader.lineEnding
+}
+
 // Read reads the next character from the input and returns it
 func (reader *RuneReader) Read() (r rune, pos ReaderPosition, err error) {
 	// tell the caller that we read the current position
-	pos.Line = reader.position.Line
-	pos.Column = reader.position.Column
+	pos = reader.position
+	pos.EOF = false
+
+	// if we have unread a character, return it again
+	if n := len(reader.pushback); n > 0 {
+		r, reader.position = reader.pushback[n-1].r, reader.pushback[n-1].next
+		reader.pushback = reader.pushback[:n-1]
+		return
+	}
 
 	// read the next rune, and handle errors!
-	r, err = reader.readRaw()
-	if err != nil {
-		if err == io.EOF {
-			pos.EOF = true
-			reader.position.EOF = true
-			err = nil
-		}
+	raw := reader.readRaw()
+	switch raw.err {
+	case nil:
+	case io.EOF:
+		pos.EOF = true
+		reader.position.EOF = true
+		return
+	case errInvalidInput:
+		err = NewCodeErrorF(reader, CodeInvalidEncoding, "Invalid %s in input", reader.encoding)
+		return
+	default:
+		err = WrapErrorF(reader, raw.err, "Unable to read input")
 		return
 	}
+	r = raw.r
+	reader.position.advance(raw)
+	ending := "\n"
 
 	// handle '\r\n' and '\n\r' as a special newline and normalize them into a single '\n'
 	if r == '\n' || r == '\r' {
-		// lookahead to the next character
-		var l rune
-		l, err = reader.peekRaw()
-
-		// handle errors in the lookahead
-		if err != nil {
-			if err == io.EOF {
-				// io.EOF will be re-read in
-				err = nil
-
-				// we did have a linebreak
-				reader.position.Column = 0
-				reader.position.Line++
-			}
-			pos.EOF = false
-			return
-		}
+		// lookahead to the next character.
+		// errors in the lookahead are returned by the next call to Read.
+		l := reader.peekRaw()
 
 		// we caught a collapsed newline, and should collapse both into a single line.
-		if (r == '\n' && l == '\r') || (r == '\r' && l == '\n') {
-			reader.eatRaw() // skip the next character
+		if l.err == nil && ((r == '\n' && l.r == '\r') || (r == '\r' && l.r == '\n')) {
+			reader.lookahead = nil // skip the next character
+			reader.position.advance(l)
+			ending = string([]rune{r, l.r})
 			r = '\n'
-			pos.EOF = false
 		}
 	}
 
-	// increment the position of the reader!
-	if r != '\n' {
-		reader.position.Column++
-	} else {
-		reader.position.Column = 0
+	// a newline starts a new line
+	if r == '\n' {
+		if reader.lineEnding == "" {
+			reader.lineEnding = ending
+		}
 		reader.position.Line++
+		reader.position.Column = 0
+		reader.position.UTF16Column = 0
 	}
 
 	return
@@ -105,90 +188,55 @@ func (reader *RuneReader) Eat() (err error) {
 
 // Peek peeks into the next character
 func (reader *RuneReader) Peek() (r rune, pos ReaderPosition, err error) {
-	// read the current position
-	pos = reader.position
-
-	// peek the next raw character
-	r, err = reader.peekRaw()
+	// read the next character normally, then "unread" it
+	position := reader.position
+	r, pos, err = reader.Read()
 	if err != nil {
-		if err == io.EOF {
-			pos.EOF = true
-			err = nil
-		}
 		return
 	}
 
-	// we need to take care of special cases '\r\n' and '\n\r'
-	// three cases:
-	// - read an '\r' => if the next character is an '\n', we need to return it
-	// - read an '\n' => the next character doesn't matter, we have an actual '\n'
-	// - read anything else => we have the actual characters
-
-	if r != '\r' {
+	// at the end of the input there is nothing to unread
+	if pos.EOF {
+		reader.position = position
 		return
 	}
 
-	// read the next character normally, then "unread" it
-	r, pos, err = reader.Read()
-	if err != nil {
-		return
-	}
 	reader.Unread(r, pos)
-
 	return
 }
 
-// Unread unreads a character from the input
+// Unread unreads a character from the input.
+// pos should be the position returned by the call to Read() that returned r.
 func (reader *RuneReader) Unread(r rune, pos ReaderPosition) {
-	reader.pushback = append(reader.pushback, r) // store read rune
-	reader.position = pos                        // and update position
+	// the end of input will be read again anyways
+	if !pos.EOF {
+		reader.pushback = append(reader.pushback, unread{r: r, next: reader.position}) // store read rune
+	}
+	reader.position = pos // and update position
 }
 
 // readRaw reads the next character, without taking care of special newlines
-// Return err == io.EOF for the end of file
-func (reader *RuneReader) readRaw() (r rune, err error) {
-	if len(reader.pushback) > 0 {
-		// pop from the pushback
-		r, reader.pushback = reader.pushback[len(reader.pushback)-1], reader.pushback[:len(reader.pushback)-1]
-
-		// take care of an EOF
-		if reader.position.EOF {
-			err = io.EOF
-		}
+// raw.err == io.EOF indicates the end of file
+func (reader *RuneReader) readRaw() (raw rawRune) {
+	if reader.lookahead != nil {
+		raw, reader.lookahead = *reader.lookahead, nil
 		return
 	}
 
-	r, _, err = reader.Reader.ReadRune()
-	return
-}
-
-// eatRaw eats the next character, without taking care of special newlines
-func (reader *RuneReader) eatRaw() (err error) {
-	// drop an element from the pushback if we have it
-	if len(reader.pushback) > 0 {
-		reader.pushback = reader.pushback[:len(reader.pushback)-1]
-		return
+	raw.r, raw.size, raw.err = reader.Reader.ReadRune()
+	if raw.err == nil && raw.r == utf8.RuneError && raw.size == 1 {
+		raw.err = errInvalidInput // invalid utf-8
 	}
-
-	_, _, err = reader.Reader.ReadRune()
 	return
 }
 
-// PeekRaw peeks the next character without taking care of special newlines.
-func (reader *RuneReader) peekRaw() (r rune, err error) {
-	// if we already have a cached value, just use it!
-	if len(reader.pushback) > 0 {
-		r = reader.pushback[len(reader.pushback)-1]
-		return
-	}
-
-	// do a real read, and then unread it!
-	r, _, err = reader.Reader.ReadRune()
-	if err != nil {
-		return
+// peekRaw peeks the next character without taking care of special newlines.
+func (reader *RuneReader) peekRaw() rawRune {
+	if reader.lookahead == nil {
+		raw := reader.readRaw()
+		reader.lookahead = &raw
 	}
-	reader.Unread(r, reader.position) // TODO: We shouldn't have to pass reader.position here!
-	return
+	return *reader.lookahead
 }
 
 // ReadWhile reads runes from a string as long as they and the partial string so far match the regexp
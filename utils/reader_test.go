@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -18,74 +19,74 @@ var testOutput = []struct {
 	wantR   rune
 	wantPos ReaderPosition
 }{
-	{'l', ReaderPosition{0, 0, false}},
-	{'i', ReaderPosition{0, 1, false}},
-	{'n', ReaderPosition{0, 2, false}},
-	{'e', ReaderPosition{0, 3, false}},
-	{' ', ReaderPosition{0, 4, false}},
-	{'1', ReaderPosition{0, 5, false}},
-	{'\n', ReaderPosition{0, 6, false}},
-
-	{'l', ReaderPosition{1, 0, false}},
-	{'i', ReaderPosition{1, 1, false}},
-	{'n', ReaderPosition{1, 2, false}},
-	{'e', ReaderPosition{1, 3, false}},
-	{' ', ReaderPosition{1, 4, false}},
-	{'2', ReaderPosition{1, 5, false}},
-	{'\n', ReaderPosition{1, 6, false}},
-
-	{'l', ReaderPosition{2, 0, false}},
-	{'i', ReaderPosition{2, 1, false}},
-	{'n', ReaderPosition{2, 2, false}},
-	{'e', ReaderPosition{2, 3, false}},
-	{' ', ReaderPosition{2, 4, false}},
-	{'3', ReaderPosition{2, 5, false}},
-	{'\n', ReaderPosition{2, 6, false}},
-
-	{'l', ReaderPosition{3, 0, false}},
-	{'i', ReaderPosition{3, 1, false}},
-	{'n', ReaderPosition{3, 2, false}},
-	{'e', ReaderPosition{3, 3, false}},
-	{' ', ReaderPosition{3, 4, false}},
-	{'4', ReaderPosition{3, 5, false}},
-	{'\n', ReaderPosition{3, 6, false}},
-
-	{'l', ReaderPosition{4, 0, false}},
-	{'i', ReaderPosition{4, 1, false}},
-	{'n', ReaderPosition{4, 2, false}},
-	{'e', ReaderPosition{4, 3, false}},
-	{' ', ReaderPosition{4, 4, false}},
-	{'5', ReaderPosition{4, 5, false}},
-	{'\r', ReaderPosition{4, 6, false}},
-
-	{'a', ReaderPosition{4, 7, false}},
-	{'l', ReaderPosition{4, 8, false}},
-	{'s', ReaderPosition{4, 9, false}},
-	{'o', ReaderPosition{4, 10, false}},
-
-	{'\r', ReaderPosition{4, 11, false}},
-	{'\r', ReaderPosition{4, 12, false}},
-
-	{'s', ReaderPosition{4, 13, false}},
-	{'t', ReaderPosition{4, 14, false}},
-	{'i', ReaderPosition{4, 15, false}},
-	{'l', ReaderPosition{4, 16, false}},
-	{'l', ReaderPosition{4, 17, false}},
-
-	{'\n', ReaderPosition{4, 18, false}},
-	{'\n', ReaderPosition{5, 0, false}},
-
-	{'l', ReaderPosition{6, 0, false}},
-	{'i', ReaderPosition{6, 1, false}},
-	{'n', ReaderPosition{6, 2, false}},
-	{'e', ReaderPosition{6, 3, false}},
-	{' ', ReaderPosition{6, 4, false}},
-	{'7', ReaderPosition{6, 5, false}},
-
-	{'\n', ReaderPosition{6, 6, false}},
-
-	{rune(0), ReaderPosition{7, 0, true}},
-	{rune(0), ReaderPosition{7, 0, true}},
+	{'l', ReaderPosition{0, 0, 0, 0, 0, false}},
+	{'i', ReaderPosition{0, 1, 1, 1, 1, false}},
+	{'n', ReaderPosition{0, 2, 2, 2, 2, false}},
+	{'e', ReaderPosition{0, 3, 3, 3, 3, false}},
+	{' ', ReaderPosition{0, 4, 4, 4, 4, false}},
+	{'1', ReaderPosition{0, 5, 5, 5, 5, false}},
+	{'\n', ReaderPosition{0, 6, 6, 6, 6, false}},
+
+	{'l', ReaderPosition{1, 0, 0, 7, 7, false}},
+	{'i', ReaderPosition{1, 1, 1, 8, 8, false}},
+	{'n', ReaderPosition{1, 2, 2, 9, 9, false}},
+	{'e', ReaderPosition{1, 3, 3, 10, 10, false}},
+	{' ', ReaderPosition{1, 4, 4, 11, 11, false}},
+	{'2', ReaderPosition{1, 5, 5, 12, 12, false}},
+	{'\n', ReaderPosition{1, 6, 6, 13, 13, false}},
+
+	{'l', ReaderPosition{2, 0, 0, 15, 15, false}},
+	{'i', ReaderPosition{2, 1, 1, 16, 16, false}},
+	{'n', ReaderPosition{2, 2, 2, 17, 17, false}},
+	{'e', ReaderPosition{2, 3, 3, 18, 18, false}},
+	{' ', ReaderPosition{2, 4, 4, 19, 19, false}},
+	{'3', ReaderPosition{2, 5, 5, 20, 20, false}},
+	{'\n', ReaderPosition{2, 6, 6, 21, 21, false}},
+
+	{'l', ReaderPosition{3, 0, 0, 23, 23, false}},
+	{'i', ReaderPosition{3, 1, 1, 24, 24, false}},
+	{'n', ReaderPosition{3, 2, 2, 25, 25, false}},
+	{'e', ReaderPosition{3, 3, 3, 26, 26, false}},
+	{' ', ReaderPosition{3, 4, 4, 27, 27, false}},
+	{'4', ReaderPosition{3, 5, 5, 28, 28, false}},
+	{'\n', ReaderPosition{3, 6, 6, 29, 29, false}},
+
+	{'l', ReaderPosition{4, 0, 0, 30, 30, false}},
+	{'i', ReaderPosition{4, 1, 1, 31, 31, false}},
+	{'n', ReaderPosition{4, 2, 2, 32, 32, false}},
+	{'e', ReaderPosition{4, 3, 3, 33, 33, false}},
+	{' ', ReaderPosition{4, 4, 4, 34, 34, false}},
+	{'5', ReaderPosition{4, 5, 5, 35, 35, false}},
+	{'\r', ReaderPosition{4, 6, 6, 36, 36, false}},
+
+	{'a', ReaderPosition{4, 7, 7, 37, 37, false}},
+	{'l', ReaderPosition{4, 8, 8, 38, 38, false}},
+	{'s', ReaderPosition{4, 9, 9, 39, 39, false}},
+	{'o', ReaderPosition{4, 10, 10, 40, 40, false}},
+
+	{'\r', ReaderPosition{4, 11, 11, 41, 41, false}},
+	{'\r', ReaderPosition{4, 12, 12, 42, 42, false}},
+
+	{'s', ReaderPosition{4, 13, 13, 43, 43, false}},
+	{'t', ReaderPosition{4, 14, 14, 44, 44, false}},
+	{'i', ReaderPosition{4, 15, 15, 45, 45, false}},
+	{'l', ReaderPosition{4, 16, 16, 46, 46, false}},
+	{'l', ReaderPosition{4, 17, 17, 47, 47, false}},
+
+	{'\n', ReaderPosition{4, 18, 18, 48, 48, false}},
+	{'\n', ReaderPosition{5, 0, 0, 49, 49, false}},
+
+	{'l', ReaderPosition{6, 0, 0, 50, 50, false}},
+	{'i', ReaderPosition{6, 1, 1, 51, 51, false}},
+	{'n', ReaderPosition{6, 2, 2, 52, 52, false}},
+	{'e', ReaderPosition{6, 3, 3, 53, 53, false}},
+	{' ', ReaderPosition{6, 4, 4, 54, 54, false}},
+	{'7', ReaderPosition{6, 5, 5, 55, 55, false}},
+
+	{'\n', ReaderPosition{6, 6, 6, 56, 56, false}},
+
+	{rune(0), ReaderPosition{7, 0, 0, 57, 57, true}},
+	{rune(0), ReaderPosition{7, 0, 0, 57, 57, true}},
 }
 
 var testOutputLength = len(testOutput)
@@ -341,8 +342,8 @@ func TestRuneReader_ReadWhile(t *testing.T) {
 			func(r rune) bool { return false },
 			"",
 			ReaderRange{
-				ReaderPosition{0, 0, false},
-				ReaderPosition{0, 0, false},
+				ReaderPosition{0, 0, 0, 0, 0, false},
+				ReaderPosition{0, 0, 0, 0, 0, false},
 			},
 		},
 		{
@@ -350,8 +351,8 @@ func TestRuneReader_ReadWhile(t *testing.T) {
 			func(r rune) bool { return (r >= 'a' && r <= 'z') },
 			"line",
 			ReaderRange{
-				ReaderPosition{0, 0, false},
-				ReaderPosition{0, 3, false},
+				ReaderPosition{0, 0, 0, 0, 0, false},
+				ReaderPosition{0, 3, 3, 3, 3, false},
 			},
 		},
 		{
@@ -359,8 +360,8 @@ func TestRuneReader_ReadWhile(t *testing.T) {
 			func(r rune) bool { return true },
 			"line 1\nline 2\nline 3\nline 4\nline 5\ralso\r\rstill\n\nline 7\n",
 			ReaderRange{
-				ReaderPosition{0, 0, false},
-				ReaderPosition{7, 0, true},
+				ReaderPosition{0, 0, 0, 0, 0, false},
+				ReaderPosition{7, 0, 0, 57, 57, true},
 			},
 		},
 	}
@@ -400,19 +401,19 @@ func TestRuneReader_EatWhile(t *testing.T) {
 			"eat nothing",
 			func(r rune) bool { return false },
 			0,
-			ReaderPosition{0, 0, false},
+			ReaderPosition{0, 0, 0, 0, 0, false},
 		},
 		{
 			"eat letters",
 			func(r rune) bool { return (r >= 'a' && r <= 'z') },
 			4,
-			ReaderPosition{0, 4, false},
+			ReaderPosition{0, 4, 4, 4, 4, false},
 		},
 		{
 			"eat everything",
 			func(r rune) bool { return true },
 			55,
-			ReaderPosition{7, 0, true},
+			ReaderPosition{7, 0, 0, 57, 57, true},
 		},
 	}
 	for _, tt := range tests {
@@ -440,3 +441,83 @@ func Benchmark_EatWhile(b *testing.B) {
 		reader.ReadWhile(func(_ rune) bool { return true })
 	}
 }
+
+func TestRuneReader_Offsets(t *testing.T) {
+	// 'é' takes two bytes, '😀' takes four bytes and two UTF-16 code units
+	reader := NewRuneReaderFromString("aé😀\r\nb")
+	tests := []struct {
+		wantR   rune
+		wantPos ReaderPosition
+	}{
+		{'a', ReaderPosition{0, 0, 0, 0, 0, false}},
+		{'é', ReaderPosition{0, 1, 1, 1, 1, false}},
+		{'😀', ReaderPosition{0, 2, 2, 3, 2, false}},
+		{'\n', ReaderPosition{0, 3, 4, 7, 3, false}},
+		{'b', ReaderPosition{1, 0, 0, 9, 5, false}},
+		{rune(0), ReaderPosition{1, 1, 1, 10, 6, true}},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("peek + read + unread + read character %d", i), func(t *testing.T) {
+			gotR, gotPos, err := reader.Peek()
+			if err != nil || gotR != tt.wantR || !reflect.DeepEqual(gotPos, tt.wantPos) {
+				t.Errorf("RuneReader.Peek() = %v, %v, %v, want %v, %v, nil", gotR, gotPos, err, tt.wantR, tt.wantPos)
+			}
+
+			gotR, gotPos, err = reader.Read()
+			if err != nil || gotR != tt.wantR || !reflect.DeepEqual(gotPos, tt.wantPos) {
+				t.Errorf("RuneReader.Read() = %v, %v, %v, want %v, %v, nil", gotR, gotPos, err, tt.wantR, tt.wantPos)
+			}
+			next := reader.Position()
+
+			reader.Unread(gotR, gotPos)
+			gotR, gotPos, err = reader.Read()
+			if err != nil || gotR != tt.wantR || !reflect.DeepEqual(gotPos, tt.wantPos) {
+				t.Errorf("RuneReader.Read() = %v, %v, %v, want %v, %v, nil", gotR, gotPos, err, tt.wantR, tt.wantPos)
+			}
+			if got := reader.Position(); !reflect.DeepEqual(got, next) {
+				t.Errorf("RuneReader.Position() = %v, want %v", got, next)
+			}
+		})
+	}
+}
+
+func TestNewRuneReaderAt(t *testing.T) {
+	reader := NewRuneReaderAt(strings.NewReader("é\nb"), ReaderPosition{2, 3, 4, 10, 8, true})
+	tests := []struct {
+		wantR   rune
+		wantPos ReaderPosition
+	}{
+		{'é', ReaderPosition{2, 3, 4, 10, 8, false}},
+		{'\n', ReaderPosition{2, 4, 5, 12, 9, false}},
+		{'b', ReaderPosition{3, 0, 0, 13, 10, false}},
+		{rune(0), ReaderPosition{3, 1, 1, 14, 11, true}},
+	}
+	for i, tt := range tests {
+		gotR, gotPos, err := reader.Read()
+		if err != nil || gotR != tt.wantR || !reflect.DeepEqual(gotPos, tt.wantPos) {
+			t.Errorf("RuneReader.Read() #%d = %v, %v, %v, want %v, %v, nil", i, gotR, gotPos, err, tt.wantR, tt.wantPos)
+		}
+	}
+}
+
+func TestRuneReader_LineEnding(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", ""},
+		{"no line break\rat all", ""},
+		{"a\nb\r\nc", "\n"},
+		{"a\r\nb\nc", "\r\n"},
+		{"a\n\rb\r\nc", "\n\r"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.input), func(t *testing.T) {
+			reader := NewRuneReaderFromString(tt.input)
+			reader.EatWhile(func(r rune) bool { return true })
+			if got := reader.LineEnding(); got != tt.want {
+				t.Errorf("RuneReader.LineEnding() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
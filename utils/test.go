@@ -86,7 +86,7 @@ func CompressMarshalFileOrPanic(filename string, data interface{}) {
 		panic("Missing filename")
 	}
 
-	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY, 0644)
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
 		panic(err)
 	}